@@ -1 +1 @@
-./main &
+(./main migrate up && ./main) &
@@ -0,0 +1,248 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"techno-forum/src/attachments"
+	"techno-forum/src/blobstore"
+	"techno-forum/src/config"
+	"techno-forum/src/digests"
+	"techno-forum/src/events"
+	"techno-forum/src/health"
+	"techno-forum/src/mail"
+	"techno-forum/src/metrics"
+	"techno-forum/src/openapi"
+	"techno-forum/src/repository"
+	"techno-forum/src/repository/memory"
+	"techno-forum/src/repository/postgres"
+	"techno-forum/src/tracing"
+	"techno-forum/src/utils"
+	"techno-forum/src/webhooks"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type App struct {
+	cfg    *config.Config
+	dbpool *pgxpool.Pool
+	repos  *repository.Repositories
+	server *http.Server
+	health *health.Checker
+	events *events.Bus
+	// listen runs the event transport until its context is done. It is nil
+	// when events stay within the process.
+	listen func(context.Context)
+	// webhooks is nil when this instance doesn't deliver webhooks.
+	webhooks *webhooks.Worker
+	// digests is nil when this instance doesn't send digests.
+	digests *digests.Worker
+	blobs   blobstore.Store
+	cleaner *attachments.Cleaner
+
+	spec *openapi.Document
+	// specErr lists the routes and spec entries that don't match.
+	specErr error
+
+	log   *slog.Logger
+	ready atomic.Bool
+
+	stopTracing func(context.Context) error
+}
+
+// New connects to the storage and wires every layer together. It returns an
+// error instead of a half-initialized App when the database is unreachable.
+func New(ctx context.Context, cfg *config.Config, logger *slog.Logger) (*App, error) {
+	a := &App{cfg: cfg, log: logger, events: events.NewBus()}
+
+	switch cfg.Storage {
+	case config.StorageMemory:
+		a.repos = memory.NewRepositories(memory.NewStore())
+		a.health = health.NewChecker(cfg.Storage, nil, nil, a.Ready)
+	default:
+		dbErrors := &health.ErrorTracker{}
+
+		tracers := utils.QueryTracers{tracing.QueryTracer{}, dbErrors}
+		if cfg.Metrics.Enabled {
+			tracers = append(tracers, metrics.QueryTracer{})
+		}
+
+		dbpool, err := utils.InitPostgres(ctx, cfg.Database, logger, tracers)
+		if err != nil {
+			return nil, err
+		}
+
+		if cfg.Metrics.Enabled {
+			if err = metrics.RegisterPool(dbpool); err != nil {
+				dbpool.Close()
+				return nil, err
+			}
+		}
+
+		a.dbpool = dbpool
+		a.repos = postgres.NewRepositories(dbpool, logger)
+
+		transport := events.NewPgTransport(dbpool, a.events, logger, cfg.Database.RetryInterval)
+		a.events.SetTransport(transport)
+		a.listen = transport.Listen
+		a.health = health.NewChecker(cfg.Storage, dbpool, dbErrors, a.Ready)
+	}
+
+	blobs, err := blobstore.New(cfg.Attachments)
+	if err != nil {
+		a.close()
+		return nil, fmt.Errorf("setting up attachment storage: %w", err)
+	}
+	a.blobs = blobs
+	a.cleaner = attachments.NewCleaner(a.repos.Attachments, blobs, cfg.Attachments, logger)
+
+	if cfg.Webhooks.Enabled {
+		a.webhooks = webhooks.NewWorker(a.repos.Webhooks, cfg.Webhooks, logger)
+	}
+
+	if cfg.Digests.Enabled {
+		transport, err := mail.New(cfg.Mail)
+		if err != nil {
+			a.close()
+			return nil, fmt.Errorf("setting up mail: %w", err)
+		}
+		a.digests = digests.NewWorker(a.repos.Digests, transport, cfg.Digests, cfg.Mail.From, logger)
+	}
+
+	stopTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		a.close()
+		return nil, fmt.Errorf("setting up tracing: %w", err)
+	}
+	a.stopTracing = stopTracing
+
+	handler, err := a.router()
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+
+	a.server = &http.Server{
+		Addr:              cfg.Server.Listen,
+		Handler:           handler,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		ErrorLog:          slog.NewLogLogger(logger.Handler(), slog.LevelError),
+	}
+	// Streams only end when their subscription does.
+	a.server.RegisterOnShutdown(a.events.Close)
+
+	return a, nil
+}
+
+// OpenAPI returns the specification of the routes served by the App, and an
+// error when some of them aren't documented or the spec has extra entries.
+func (a *App) OpenAPI() (*openapi.Document, error) {
+	return a.spec, a.specErr
+}
+
+// Ready reports whether the App accepts traffic. It turns false as soon as
+// shutdown begins, before in-flight requests are drained.
+func (a *App) Ready() bool {
+	return a.ready.Load()
+}
+
+// Run serves HTTP until ctx is cancelled, then shuts down gracefully.
+func (a *App) Run(ctx context.Context) error {
+	defer a.close()
+
+	if a.listen != nil {
+		go a.listen(ctx)
+	}
+
+	// The workers must be done with the database before it is closed, also
+	// when the server fails to start.
+	var workers sync.WaitGroup
+	defer workers.Wait()
+
+	workerCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+
+	if a.webhooks != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			a.webhooks.Run(workerCtx)
+		}()
+	}
+
+	if a.digests != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			a.digests.Run(workerCtx)
+		}()
+	}
+
+	workers.Add(1)
+	go func() {
+		defer workers.Done()
+		a.cleaner.Run(workerCtx)
+	}()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		a.log.Info("listening", "addr", a.cfg.Server.Listen, "storage", a.cfg.Storage)
+		serveErr <- a.server.ListenAndServe()
+	}()
+
+	a.ready.Store(true)
+
+	select {
+	case err := <-serveErr:
+		a.ready.Store(false)
+		return err
+	case <-ctx.Done():
+	}
+
+	return a.shutdown()
+}
+
+func (a *App) shutdown() error {
+	a.ready.Store(false)
+	a.log.Info("shutting down")
+
+	if a.cfg.Server.ShutdownDelay > 0 {
+		// Give load balancers time to notice the failing readiness probe
+		// before the listener goes away.
+		time.Sleep(a.cfg.Server.ShutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	err := a.server.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		a.log.Warn("shutdown timeout expired, closing remaining connections")
+		a.server.Close()
+	}
+
+	return err
+}
+
+func (a *App) close() {
+	if a.stopTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		if err := a.stopTracing(ctx); err != nil {
+			a.log.Warn("flushing traces failed", "error", err)
+		}
+	}
+
+	if a.dbpool != nil {
+		a.dbpool.Close()
+	}
+}
@@ -0,0 +1,850 @@
+package app
+
+import (
+	"net/http"
+	"strings"
+	"techno-forum/src/delivery"
+	"techno-forum/src/events"
+	"techno-forum/src/models"
+	"techno-forum/src/openapi"
+	"techno-forum/src/webhooks"
+)
+
+var apiInfo = openapi.Info{
+	Title:       "Techno Forum API",
+	Description: "Forum with users, forums, threads, tree-structured posts and votes.",
+	Version:     "1.0",
+}
+
+const bearerAuth = "bearerAuth"
+
+// security documents the optional session token accepted by every route.
+func security(doc *openapi.Document) {
+	doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{
+		bearerAuth: {Type: "http", Scheme: "bearer", Description: "Session token from POST /api/auth/login."},
+	}
+	doc.Security = []openapi.SecurityRequirement{{}, {bearerAuth: {}}}
+}
+
+func errorResp(status int, description string) openapi.Resp {
+	return openapi.Resp{Status: status, Description: description, Body: delivery.ErrorMsg{}}
+}
+
+// operations documents every route registered in router. openapi.Build
+// refuses to start the service when the two disagree.
+func (a *App) operations() map[string]*openapi.Op {
+	limit := openapi.QueryParam("limit", "Maximum number of entries returned.",
+		openapi.Integer().WithDefault(a.cfg.Pagination.DefaultLimit).WithRange(1, float64(a.cfg.Pagination.MaxLimit)))
+	desc := openapi.QueryParam("desc", "Sort in descending order.", openapi.Boolean().WithDefault(false))
+
+	slug := openapi.PathParam("slug", "Forum slug.", openapi.String())
+	nickname := openapi.PathParam("nickname", "User nickname, case insensitive.", openapi.String())
+	slugOrId := openapi.PathParam("slugOrId", "Thread slug or numeric id.", openapi.String())
+	postId := openapi.PathParam("id", "Post id.", &openapi.Schema{Type: "integer", Format: "int64"})
+
+	badRequest := errorResp(http.StatusBadRequest, "Malformed request")
+	timeout := errorResp(http.StatusGatewayTimeout, "The database did not answer in time")
+	unauthorized := errorResp(http.StatusUnauthorized, "Invalid session token, or a token is required")
+	forbidden := errorResp(http.StatusForbidden, "Signed in as another user")
+	notModerator := errorResp(http.StatusForbidden, "Neither a moderator of the forum nor an admin")
+	banned := errorResp(http.StatusForbidden, "Signed in as another user, or banned in the forum")
+	notEditor := errorResp(http.StatusForbidden, "Neither the author, a moderator of the forum nor an admin")
+	notAdmin := errorResp(http.StatusForbidden, "Not an admin")
+	webhookId := openapi.PathParam("id", "Webhook id.", openapi.Integer())
+	webhookEvents := strings.Join([]string{events.PostCreated, events.ThreadCreated, events.ThreadVoted}, ", ")
+	from := openapi.QueryParam("from", "Revision to compare from. Defaults to the one before to.", openapi.Integer().WithMinimum(1))
+	to := openapi.QueryParam("to", "Revision to compare to. Defaults to the latest.", openapi.Integer().WithMinimum(1))
+	subscriber := openapi.QueryParam("nickname", "User whose subscription it is. Signed in users always use their own.", openapi.String())
+	digestToken := &openapi.Parameter{Name: "token", In: "query", Description: "Unsubscribe token from the digest email.", Required: true, Schema: openapi.String()}
+	attachmentId := openapi.PathParam("id", "Attachment id.", &openapi.Schema{Type: "integer", Format: "int64"})
+	render := openapi.QueryParam("render", "Set to html to add message_html, the message rendered from Markdown and sanitized.", openapi.Enum("html"))
+	noSubscription := errorResp(http.StatusNotFound, "Thread or user not found, or the user isn't subscribed")
+
+	ops := map[string]*openapi.Op{
+		"POST /api/forum/create": {
+			ID: "forumCreate", Tags: []string{"forum"}, Summary: "Create a forum",
+			Body: models.Forum{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusCreated, Description: "Forum created", Body: models.Forum{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "Author not found"),
+				{Status: http.StatusConflict, Description: "A forum with this slug exists, it is returned", Body: models.Forum{}},
+			},
+		},
+		"POST /api/forum/{slug}/create": {
+			ID: "threadCreate", Tags: []string{"forum"}, Summary: "Create a thread in the forum",
+			Params: []*openapi.Parameter{slug, render},
+			Body:   models.Thread{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusCreated, Description: "Thread created", Body: models.Thread{}},
+				badRequest,
+				unauthorized,
+				banned,
+				errorResp(http.StatusNotFound, "Author or forum not found"),
+				{Status: http.StatusConflict, Description: "A thread with this slug exists, it is returned", Body: models.Thread{}},
+			},
+		},
+		"GET /api/forum/{slug}/details": {
+			ID: "forumGetOne", Tags: []string{"forum"}, Summary: "Get forum details",
+			Params: []*openapi.Parameter{slug},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Forum{}},
+				errorResp(http.StatusNotFound, "Forum not found"),
+			},
+		},
+		"GET /api/forum/{slug}/threads": {
+			ID: "forumGetThreads", Tags: []string{"forum"}, Summary: "List threads of the forum by creation date",
+			Params: []*openapi.Parameter{slug, limit,
+				openapi.QueryParam("since", "Only threads created at or after (before, with desc) this time.", &openapi.Schema{Type: "string", Format: "date-time"}),
+				desc, render,
+			},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.Thread{}},
+				badRequest,
+				errorResp(http.StatusNotFound, "Forum not found"),
+			},
+		},
+		"GET /api/forum/{slug}/users": {
+			ID: "forumGetUsers", Tags: []string{"forum"}, Summary: "List users who posted in the forum, by nickname",
+			Params: []*openapi.Parameter{slug, limit,
+				openapi.QueryParam("since", "Only users with a nickname after (before, with desc) this one.", openapi.String()),
+				desc,
+			},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.User{}},
+				badRequest,
+				errorResp(http.StatusNotFound, "Forum not found"),
+			},
+		},
+		"GET /api/forum/{slug}/moderators": {
+			ID: "forumGetModerators", Tags: []string{"moderation"}, Summary: "List moderators of the forum",
+			Params: []*openapi.Parameter{slug},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.User{}},
+				errorResp(http.StatusNotFound, "Forum not found"),
+			},
+		},
+		"PUT /api/forum/{slug}/moderators/{nickname}": {
+			ID: "forumAddModerator", Tags: []string{"moderation"}, Summary: "Make a user moderator of the forum",
+			Description: "Allowed to the forum author and admins.",
+			Params:      []*openapi.Parameter{slug, nickname},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "The new moderator", Body: models.User{}},
+				unauthorized,
+				errorResp(http.StatusForbidden, "Neither the forum author nor an admin"),
+				errorResp(http.StatusNotFound, "Forum or user not found"),
+			},
+		},
+		"DELETE /api/forum/{slug}/moderators/{nickname}": {
+			ID: "forumRemoveModerator", Tags: []string{"moderation"}, Summary: "Revoke a moderator of the forum",
+			Description: "Allowed to the forum author and admins.",
+			Params:      []*openapi.Parameter{slug, nickname},
+			Responses: []openapi.Resp{
+				{Status: http.StatusNoContent, Description: "Moderator revoked"},
+				unauthorized,
+				errorResp(http.StatusForbidden, "Neither the forum author nor an admin"),
+				errorResp(http.StatusNotFound, "Forum or user not found, or not a moderator"),
+			},
+		},
+		"GET /api/forum/{slug}/bans": {
+			ID: "forumGetBans", Tags: []string{"moderation"}, Summary: "List active bans in the forum",
+			Params: []*openapi.Parameter{slug},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.Ban{}},
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Forum not found"),
+			},
+		},
+		"PUT /api/forum/{slug}/bans/{nickname}": {
+			ID: "forumBan", Tags: []string{"moderation"}, Summary: "Ban a user in the forum",
+			Description: "Banned users can't create threads, post or vote in the forum until the ban expires. " +
+				"Without expires the ban is permanent. Banning again replaces the ban.",
+			Params: []*openapi.Parameter{slug, nickname},
+			Body:   models.BanRequest{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Ban{}},
+				badRequest,
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Forum or user not found"),
+				errorResp(http.StatusUnprocessableEntity, "Expiry in the past"),
+			},
+		},
+		"DELETE /api/forum/{slug}/bans/{nickname}": {
+			ID: "forumUnban", Tags: []string{"moderation"}, Summary: "Lift the ban of a user in the forum",
+			Params: []*openapi.Parameter{slug, nickname},
+			Responses: []openapi.Resp{
+				{Status: http.StatusNoContent, Description: "Ban lifted"},
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Forum or user not found, or not banned"),
+			},
+		},
+		"GET /api/forum/{slug}/subscription": {
+			ID: "forumSubscription", Tags: []string{"forum"}, Summary: "Get the subscription of a user to a forum",
+			Params: []*openapi.Parameter{slug, subscriber},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.ForumSubscription{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "Forum or user not found, or the user isn't subscribed"),
+			},
+		},
+		"PUT /api/forum/{slug}/subscription": {
+			ID: "forumSubscribe", Tags: []string{"forum"}, Summary: "Subscribe to a forum",
+			Description: "The email digests of subscribers include the new posts in every thread of the forum, except the muted ones.",
+			Params:      []*openapi.Parameter{slug, subscriber},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "The subscription, new or existing", Body: models.ForumSubscription{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "Forum or user not found"),
+			},
+		},
+		"DELETE /api/forum/{slug}/subscription": {
+			ID: "forumUnsubscribe", Tags: []string{"forum"}, Summary: "Unsubscribe from a forum",
+			Params: []*openapi.Parameter{slug, subscriber},
+			Responses: []openapi.Resp{
+				{Status: http.StatusNoContent, Description: "Unsubscribed"},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "Forum or user not found, or the user isn't subscribed"),
+			},
+		},
+
+		"POST /api/user/{nickname}/create": {
+			ID: "userCreate", Tags: []string{"user"}, Summary: "Create a user",
+			Params: []*openapi.Parameter{nickname},
+			Body:   models.User{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusCreated, Description: "User created", Body: models.User{}},
+				badRequest,
+				{Status: http.StatusConflict, Description: "Users with the same nickname or email", Body: []models.User{}},
+				errorResp(http.StatusUnprocessableEntity, "The password is too short or too long, or missing while auth is required"),
+			},
+		},
+		"GET /api/user/{nickname}/profile": {
+			ID: "userGetOne", Tags: []string{"user"}, Summary: "Get a user profile",
+			Params: []*openapi.Parameter{nickname},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.User{}},
+				errorResp(http.StatusNotFound, "User not found"),
+			},
+		},
+		"POST /api/user/{nickname}/profile": {
+			ID: "userUpdate", Tags: []string{"user"}, Summary: "Update a user profile",
+			Description: "Empty fields are left unchanged. A profile with a password can only be updated by its user or an admin, and setting a password needs a signed in request.",
+			Params:      []*openapi.Parameter{nickname},
+			Body:        models.User{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.User{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "User not found"),
+				errorResp(http.StatusConflict, "The email belongs to another user"),
+			},
+		},
+		"POST /api/user/{nickname}/role": {
+			ID: "userSetRole", Tags: []string{"user"}, Summary: "Change the role of a user",
+			Description: "Admins only.",
+			Params:      []*openapi.Parameter{nickname},
+			Body:        models.RoleRequest{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Account{}},
+				badRequest,
+				unauthorized,
+				errorResp(http.StatusForbidden, "Not an admin"),
+				errorResp(http.StatusNotFound, "User not found"),
+				errorResp(http.StatusUnprocessableEntity, "Unknown role, or revoking an admin listed in auth.admins"),
+			},
+		},
+
+		"GET /api/user/{nickname}/notifications": {
+			ID: "userNotifications", Tags: []string{"user"}, Summary: "List notifications of a user, newest first",
+			Description: "Users are notified of replies to their posts, of posts mentioning them as @nickname " +
+				"and of every new post in the threads they watch, but of nothing in the threads they muted. " +
+				"Only the user may read their notifications. Notifications of deleted and hidden posts are left out.",
+			Params: []*openapi.Parameter{
+				nickname,
+				openapi.QueryParam("unread", "Only unread notifications.", openapi.Boolean().WithDefault(false)),
+				openapi.QueryParam("before", "Only notifications with a smaller id, to get the next page.",
+					(&openapi.Schema{Type: "integer", Format: "int64"}).WithMinimum(1)),
+				limit,
+			},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.Notification{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "User not found"),
+			},
+		},
+		"POST /api/user/{nickname}/notifications/read": {
+			ID: "userNotificationsRead", Tags: []string{"user"}, Summary: "Mark notifications read",
+			Description: "Marks the notifications with the given ids read, or all of them without a body or ids.",
+			Params:      []*openapi.Parameter{nickname},
+			Body:        models.NotificationsReadRequest{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "Number of notifications that were unread", Body: models.NotificationsRead{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "User not found"),
+			},
+		},
+		"POST /api/user/{nickname}/notifications/{id}/read": {
+			ID: "userNotificationRead", Tags: []string{"user"}, Summary: "Mark a notification read",
+			Params: []*openapi.Parameter{nickname, openapi.PathParam("id", "Notification id.", &openapi.Schema{Type: "integer", Format: "int64"})},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Notification{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "User or notification not found"),
+			},
+		},
+		"GET /api/user/{nickname}/digest": {
+			ID: "userDigest", Tags: []string{"user"}, Summary: "Get the email digest settings of a user",
+			Description: "Only the user may read their settings. Users who never chose a frequency have it off.",
+			Params:      []*openapi.Parameter{nickname},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.DigestSettings{}},
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "User not found"),
+			},
+		},
+		"PUT /api/user/{nickname}/digest": {
+			ID: "userDigestSet", Tags: []string{"user"}, Summary: "Choose how often a user gets email digests",
+			Description: "Digests are sent to the email of the user and list the new posts by others in the threads " +
+				"they are subscribed to and in the forums they are subscribed to, leaving out muted threads. " +
+				"The next digest is due one period after the change. Periods without new posts send nothing.",
+			Params: []*openapi.Parameter{nickname},
+			Body:   models.DigestSettingsRequest{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.DigestSettings{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "User not found"),
+				errorResp(http.StatusUnprocessableEntity, "Frequency is not off, daily or weekly"),
+			},
+		},
+
+		"GET /api/thread/{slugOrId}/details": {
+			ID: "threadGetOne", Tags: []string{"thread"}, Summary: "Get thread details",
+			Params: []*openapi.Parameter{slugOrId, render},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Thread{}},
+				badRequest,
+				errorResp(http.StatusNotFound, "Thread not found"),
+			},
+		},
+		"POST /api/thread/{slugOrId}/details": {
+			ID: "threadUpdate", Tags: []string{"thread"}, Summary: "Update a thread",
+			Description: "Only title and message can change; empty fields are left unchanged. Allowed to the author, moderators of the forum and admins.",
+			Params:      []*openapi.Parameter{slugOrId, render},
+			Body:        models.Thread{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Thread{}},
+				badRequest,
+				unauthorized,
+				notEditor,
+				errorResp(http.StatusNotFound, "Thread not found"),
+			},
+		},
+		"DELETE /api/thread/{slugOrId}/details": {
+			ID: "threadDelete", Tags: []string{"thread"}, Summary: "Delete a thread",
+			Description: "The thread and its posts disappear from listings and forum counters until a moderator restores it. " +
+				"Allowed to the author, moderators of the forum and admins.",
+			Params: []*openapi.Parameter{slugOrId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusNoContent, Description: "Thread deleted"},
+				unauthorized,
+				notEditor,
+				errorResp(http.StatusNotFound, "Thread not found"),
+			},
+		},
+		"POST /api/thread/{slugOrId}/restore": {
+			ID: "threadRestore", Tags: []string{"moderation"}, Summary: "Restore a deleted thread",
+			Params: []*openapi.Parameter{slugOrId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Thread{}},
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Thread not found"),
+			},
+		},
+		"GET /api/thread/{slugOrId}/revisions": {
+			ID: "threadRevisions", Tags: []string{"thread"}, Summary: "List revisions of a thread",
+			Description: "Revision 1 is the original title and message, each edit adds one. " +
+				"Revisions of a deleted thread are shown to moderators of the forum and admins only.",
+			Params: []*openapi.Parameter{slugOrId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.Revision{}},
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Thread not found"),
+			},
+		},
+		"GET /api/thread/{slugOrId}/diff": {
+			ID: "threadDiff", Tags: []string{"thread"}, Summary: "Compare two revisions of a thread",
+			Description: "A unified diff of the title and message, separated by an empty line.",
+			Params:      []*openapi.Parameter{slugOrId, from, to},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.RevisionDiff{}},
+				badRequest,
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Thread or revision not found"),
+			},
+		},
+		"POST /api/thread/{slugOrId}/create": {
+			ID: "postsCreate", Tags: []string{"thread"}, Summary: "Add posts to a thread",
+			Description: "All posts are created in one transaction with the same creation time. Signed in users post as themselves. Attachments are ids of unlinked uploads of the post's author.",
+			Params:      []*openapi.Parameter{slugOrId, render},
+			Body:        []models.Post{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusCreated, Description: "Posts created", Body: []models.Post{}},
+				badRequest,
+				unauthorized,
+				errorResp(http.StatusForbidden, "Signed in as another user, an author is banned in the forum, or the thread is locked"),
+				errorResp(http.StatusNotFound, "Thread or author not found"),
+				errorResp(http.StatusConflict, "A parent post is missing or belongs to another thread"),
+				errorResp(http.StatusUnprocessableEntity, "Too many attachments, or one isn't an unlinked upload of the author"),
+				timeout,
+			},
+		},
+		"GET /api/thread/{slugOrId}/posts": {
+			ID: "threadGetPosts", Tags: []string{"thread"}, Summary: "List posts of a thread",
+			Params: []*openapi.Parameter{slugOrId, limit,
+				openapi.QueryParam("since", "Only posts after (before, with desc) the post with this id.", &openapi.Schema{Type: "integer", Format: "int64"}),
+				openapi.QueryParam("sort", "flat orders by creation, tree by path, parent_tree pages by root posts.",
+					openapi.Enum("flat", "tree", "parent_tree").WithDefault("flat")),
+				desc, render,
+			},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.Post{}},
+				badRequest,
+				errorResp(http.StatusNotFound, "Thread not found"),
+				timeout,
+			},
+		},
+		"GET /api/thread/{slugOrId}/stream": {
+			ID: "threadStream", Tags: []string{"thread"}, Summary: "Stream changes of a thread",
+			Description: "Server-Sent Events: " + events.PostCreated + " and " + events.PostUpdated + " carry a post, " +
+				events.ThreadUpdated + " and " + events.ThreadVoted + " the thread. New posts have their id as the event id, " +
+				"so a client reconnecting with Last-Event-ID first receives the posts it missed. " +
+				"Comment lines are sent as keep-alives.",
+			Params: []*openapi.Parameter{slugOrId,
+				{Name: "Last-Event-ID", In: "header", Description: "Id of the last post received.", Schema: &openapi.Schema{Type: "integer", Format: "int64"}},
+				openapi.QueryParam("lastEventId", "Same as Last-Event-ID, for clients that can't set headers.", &openapi.Schema{Type: "integer", Format: "int64"}),
+			},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "A text/event-stream that stays open"},
+				badRequest,
+				errorResp(http.StatusNotFound, "Thread not found"),
+			},
+		},
+		"POST /api/thread/{slugOrId}/vote": {
+			ID: "threadVote", Tags: []string{"thread"}, Summary: "Vote for a thread",
+			Description: "A repeated vote by the same user replaces the previous one. Signed in users vote as themselves.",
+			Params:      []*openapi.Parameter{slugOrId},
+			Body:        models.VoteRequest{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "The thread with updated votes", Body: models.Thread{}},
+				badRequest,
+				unauthorized,
+				banned,
+				errorResp(http.StatusNotFound, "Thread or user not found"),
+				errorResp(http.StatusUnprocessableEntity, "Voice is neither 1 nor -1"),
+			},
+		},
+		"GET /api/thread/{slugOrId}/subscription": {
+			ID: "threadSubscription", Tags: []string{"thread"}, Summary: "Get the subscription of a user to a thread",
+			Params: []*openapi.Parameter{slugOrId, subscriber},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Subscription{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				noSubscription,
+			},
+		},
+		"PUT /api/thread/{slugOrId}/subscription": {
+			ID: "threadSubscribe", Tags: []string{"thread"}, Summary: "Subscribe to a thread",
+			Description: "Watchers are notified of every new post in the thread, trackers only of replies and mentions, " +
+				"and users who muted the thread of nothing in it. Thread authors watch their threads " +
+				"and posters track the threads they post in, unless they chose a level already.",
+			Params: []*openapi.Parameter{slugOrId},
+			Body:   models.SubscriptionRequest{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "The subscription, created or updated", Body: models.Subscription{}},
+				badRequest,
+				unauthorized,
+				forbidden,
+				errorResp(http.StatusNotFound, "Thread or user not found"),
+				errorResp(http.StatusUnprocessableEntity, "Unknown level"),
+			},
+		},
+		"DELETE /api/thread/{slugOrId}/subscription": {
+			ID: "threadUnsubscribe", Tags: []string{"thread"}, Summary: "Unsubscribe from a thread",
+			Description: "An unsubscribed user is notified of replies and mentions only, and starts tracking the thread again after posting in it.",
+			Params:      []*openapi.Parameter{slugOrId, subscriber},
+			Responses: []openapi.Resp{
+				{Status: http.StatusNoContent, Description: "Unsubscribed"},
+				badRequest,
+				unauthorized,
+				forbidden,
+				noSubscription,
+			},
+		},
+		"PUT /api/thread/{slugOrId}/lock": {
+			ID: "threadLock", Tags: []string{"moderation"}, Summary: "Lock a thread",
+			Description: "Nobody can post in a locked thread.",
+			Params:      []*openapi.Parameter{slugOrId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Thread{}},
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Thread not found"),
+			},
+		},
+		"DELETE /api/thread/{slugOrId}/lock": {
+			ID: "threadUnlock", Tags: []string{"moderation"}, Summary: "Unlock a thread",
+			Params: []*openapi.Parameter{slugOrId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Thread{}},
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Thread not found"),
+			},
+		},
+
+		"GET /api/post/{id}/details": {
+			ID: "postGetOne", Tags: []string{"post"}, Summary: "Get a post with related objects",
+			Params: []*openapi.Parameter{postId,
+				openapi.QueryParam("related", "Comma separated list of user, forum, thread.", openapi.String()),
+				render,
+			},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.PostFull{}},
+				badRequest,
+				errorResp(http.StatusNotFound, "Post not found"),
+			},
+		},
+		"POST /api/post/{id}/details": {
+			ID: "postUpdate", Tags: []string{"post"}, Summary: "Edit a post message",
+			Description: "Allowed to the author, moderators of the forum and admins.",
+			Params:      []*openapi.Parameter{postId, render},
+			Body:        models.Post{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Post{}},
+				badRequest,
+				unauthorized,
+				notEditor,
+				errorResp(http.StatusNotFound, "Post not found"),
+			},
+		},
+		"DELETE /api/post/{id}/details": {
+			ID: "postDelete", Tags: []string{"post"}, Summary: "Delete a post",
+			Description: "Flat listings leave the post out; tree listings keep it, with the message \"" + models.DeletedPostMessage +
+				"\", so that its replies keep their place. Allowed to the author, moderators of the forum and admins.",
+			Params: []*openapi.Parameter{postId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusNoContent, Description: "Post deleted"},
+				badRequest,
+				unauthorized,
+				notEditor,
+				errorResp(http.StatusNotFound, "Post not found"),
+			},
+		},
+		"POST /api/post/{id}/restore": {
+			ID: "postRestore", Tags: []string{"moderation"}, Summary: "Restore a deleted post",
+			Params: []*openapi.Parameter{postId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Post{}},
+				badRequest,
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Post not found"),
+			},
+		},
+		"GET /api/post/{id}/revisions": {
+			ID: "postRevisions", Tags: []string{"post"}, Summary: "List revisions of a post",
+			Description: "Revision 1 is the original message, each edit adds one. " +
+				"Revisions of hidden and deleted posts are shown to moderators of the forum and admins only.",
+			Params: []*openapi.Parameter{postId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.Revision{}},
+				badRequest,
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Post not found"),
+			},
+		},
+		"GET /api/post/{id}/diff": {
+			ID: "postDiff", Tags: []string{"post"}, Summary: "Compare two revisions of a post",
+			Description: "A unified diff of the messages.",
+			Params:      []*openapi.Parameter{postId, from, to},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.RevisionDiff{}},
+				badRequest,
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Post or revision not found"),
+			},
+		},
+		"PUT /api/post/{id}/hidden": {
+			ID: "postHide", Tags: []string{"moderation"}, Summary: "Hide a post",
+			Description: "A hidden post keeps its place in listings with its message replaced by \"" + models.HiddenPostMessage + "\".",
+			Params:      []*openapi.Parameter{postId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Post{}},
+				badRequest,
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Post not found"),
+			},
+		},
+		"DELETE /api/post/{id}/hidden": {
+			ID: "postUnhide", Tags: []string{"moderation"}, Summary: "Show a hidden post again",
+			Params: []*openapi.Parameter{postId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Post{}},
+				badRequest,
+				unauthorized,
+				notModerator,
+				errorResp(http.StatusNotFound, "Post not found"),
+			},
+		},
+
+		"POST /api/auth/login": {
+			ID: "login", Tags: []string{"auth"}, Summary: "Sign in with a nickname and password",
+			Description: "The returned token is sent back as \"Authorization: Bearer <token>\".",
+			Body:        models.Credentials{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "Session created", Body: models.Session{}},
+				badRequest,
+				errorResp(http.StatusUnauthorized, "Invalid nickname or password"),
+			},
+		},
+		"POST /api/auth/logout": {
+			ID: "logout", Tags: []string{"auth"}, Summary: "End the session of the token",
+			Responses: []openapi.Resp{
+				{Status: http.StatusNoContent, Description: "Session ended"},
+				unauthorized,
+			},
+		},
+		"GET /api/auth/me": {
+			ID: "me", Tags: []string{"auth"}, Summary: "Get the signed in user",
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Account{}},
+				unauthorized,
+			},
+		},
+
+		"POST /api/attachment/create": {
+			ID: "attachmentCreate", Tags: []string{"attachment"}, Summary: "Upload a file to attach to a post",
+			Description: "The file is the multipart part named file. Its type is detected from the contents and must be one of the " +
+				"configured types. Signed in users upload as themselves. Link the attachment by listing its id in the attachments of a new post. " +
+				"Until then only the uploader can get it, and it is deleted after the configured unlinked TTL.",
+			Params: []*openapi.Parameter{
+				openapi.QueryParam("nickname", "Uploader. Signed in users always upload as themselves.", openapi.String()),
+			},
+			Body: &openapi.Schema{
+				Type:       "object",
+				Properties: map[string]*openapi.Schema{"file": openapi.Binary()},
+				Required:   []string{"file"},
+			},
+			BodyType: "multipart/form-data",
+			Responses: []openapi.Resp{
+				{Status: http.StatusCreated, Description: "Attachment stored", Body: models.Attachment{}},
+				badRequest,
+				unauthorized,
+				errorResp(http.StatusForbidden, "Signed in as another user"),
+				errorResp(http.StatusNotFound, "User not found"),
+				errorResp(http.StatusRequestEntityTooLarge, "The file exceeds the size limit"),
+				errorResp(http.StatusUnsupportedMediaType, "Files of this type can't be attached"),
+				errorResp(http.StatusUnprocessableEntity, "The file is empty"),
+			},
+		},
+		"GET /api/attachment/{id}/details": {
+			ID: "attachmentGetOne", Tags: []string{"attachment"}, Summary: "Get attachment details",
+			Params: []*openapi.Parameter{attachmentId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Attachment{}},
+				badRequest,
+				errorResp(http.StatusNotFound, "Attachment not found, its post is deleted or hidden, or it is an upload of another user no post links yet"),
+			},
+		},
+		"GET /api/attachment/{id}/content": {
+			ID: "attachmentContent", Tags: []string{"attachment"}, Summary: "Download an attachment",
+			Description: "Responses carry the content hash as a strong ETag and may be cached for the configured max age. " +
+				"If-None-Match and If-Modified-Since are answered with 304. Images and plain text are shown inline, anything else is downloaded.",
+			Params: []*openapi.Parameter{attachmentId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "The file, with its detected content type", Body: openapi.Binary(), ContentType: "application/octet-stream"},
+				{Status: http.StatusNotModified, Description: "The cached copy is current"},
+				badRequest,
+				errorResp(http.StatusNotFound, "Attachment not found, its post is deleted or hidden, or it is an upload of another user no post links yet"),
+			},
+		},
+		"GET /api/search": {
+			ID: "search", Tags: []string{"search"}, Summary: "Search posts and threads",
+			Description: "Full-text search in Russian and English. q takes plain words, \"quoted phrases\", or and -excluded words. " +
+				"Snippets mark the matched words with " + models.SearchHighlightStart + models.SearchHighlightStop + ". " +
+				"Deleted and hidden posts and deleted threads are never found.",
+			Params: []*openapi.Parameter{
+				{Name: "q", In: "query", Description: "Search query.", Required: true, Schema: openapi.String()},
+				openapi.QueryParam("forum", "Only in the forum with this slug.", openapi.String()),
+				openapi.QueryParam("author", "Only by the user with this nickname.", openapi.String()),
+				openapi.QueryParam("type", "Only posts or only threads.", openapi.Enum(models.SearchPost, models.SearchThread)),
+				openapi.QueryParam("sort", "rank puts the best matches first, created orders by creation time.",
+					openapi.Enum("rank", "created").WithDefault("rank")),
+				openapi.QueryParam("since", "Only entries created at or after (before, with desc) this time. Needs sort=created.", &openapi.Schema{Type: "string", Format: "date-time"}),
+				openapi.QueryParam("offset", "Number of results to skip, to page through results sorted by rank.", openapi.Integer().WithMinimum(0).WithDefault(0)),
+				limit,
+				desc,
+			},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.SearchHit{}},
+				badRequest,
+				errorResp(http.StatusNotFound, "Forum or author not found"),
+				errorResp(http.StatusUnprocessableEntity, "Empty query"),
+				timeout,
+			},
+		},
+
+		"POST /api/webhooks": {
+			ID: "webhookCreate", Tags: []string{"webhooks"}, Summary: "Register a webhook",
+			Description: "Admins only. The webhook gets the events of the forum, or of every forum without one, " +
+				"as POST requests with the event as body. Without events it gets every type: " + webhookEvents + ". " +
+				"Each request is signed in the " + webhooks.SignatureHeader + " header as sha256=<hex HMAC-SHA256 of the body keyed by the secret>. " +
+				"The secret is generated when empty and only returned here. Failed deliveries are retried with exponential backoff.",
+			Body: models.WebhookRequest{},
+			Responses: []openapi.Resp{
+				{Status: http.StatusCreated, Description: "Webhook registered", Body: models.Webhook{}},
+				badRequest,
+				unauthorized,
+				notAdmin,
+				errorResp(http.StatusNotFound, "Forum not found"),
+				errorResp(http.StatusUnprocessableEntity, "Invalid URL or unknown event type"),
+			},
+		},
+		"GET /api/webhooks": {
+			ID: "webhookList", Tags: []string{"webhooks"}, Summary: "List webhooks",
+			Description: "Admins only.",
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.Webhook{}},
+				unauthorized,
+				notAdmin,
+			},
+		},
+		"GET /api/webhooks/{id}": {
+			ID: "webhookGetOne", Tags: []string{"webhooks"}, Summary: "Get a webhook",
+			Description: "Admins only.",
+			Params:      []*openapi.Parameter{webhookId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Webhook{}},
+				badRequest,
+				unauthorized,
+				notAdmin,
+				errorResp(http.StatusNotFound, "Webhook not found"),
+			},
+		},
+		"DELETE /api/webhooks/{id}": {
+			ID: "webhookDelete", Tags: []string{"webhooks"}, Summary: "Delete a webhook and its deliveries",
+			Description: "Admins only.",
+			Params:      []*openapi.Parameter{webhookId},
+			Responses: []openapi.Resp{
+				{Status: http.StatusNoContent, Description: "Webhook deleted"},
+				badRequest,
+				unauthorized,
+				notAdmin,
+				errorResp(http.StatusNotFound, "Webhook not found"),
+			},
+		},
+		"GET /api/webhooks/{id}/deliveries": {
+			ID: "webhookDeliveries", Tags: []string{"webhooks"}, Summary: "List deliveries of a webhook, newest first",
+			Description: "Admins only. Pending deliveries show when they are attempted next, " +
+				"failed ones gave up after the configured number of attempts.",
+			Params: []*openapi.Parameter{webhookId, limit},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: []models.WebhookDelivery{}},
+				badRequest,
+				unauthorized,
+				notAdmin,
+				errorResp(http.StatusNotFound, "Webhook not found"),
+			},
+		},
+
+		"GET /api/digest/unsubscribe": {
+			ID: "digestUnsubscribeConfirm", Tags: []string{"user"}, Summary: "Ask to confirm turning off email digests",
+			Description: "The link at the end of every digest. It changes nothing, the page it shows unsubscribes with a POST to the same URL.",
+			Params:      []*openapi.Parameter{digestToken},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "The confirmation page", Body: openapi.String(), ContentType: "text/html"},
+				badRequest,
+			},
+		},
+		"POST /api/digest/unsubscribe": {
+			ID: "digestUnsubscribe", Tags: []string{"user"}, Summary: "Turn off email digests with the token from an email",
+			Description: "Sent by the confirmation page and by the one-click unsubscribe of mail clients (RFC 8058). No session is needed, the token identifies the user. The request body is ignored, and requests accepting text/html get a page instead of the settings.",
+			Params:      []*openapi.Parameter{digestToken},
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Description: "The digest settings, now off", Body: models.DigestSettings{}},
+				badRequest,
+				errorResp(http.StatusNotFound, "Unknown token"),
+			},
+		},
+
+		"POST /api/service/clear": {
+			ID: "clear", Tags: []string{"service"}, Summary: "Delete all data",
+			Responses: []openapi.Resp{{Status: http.StatusOK, Description: "All data deleted"}},
+		},
+		"GET /api/service/status": {
+			ID: "status", Tags: []string{"service"}, Summary: "Count stored objects",
+			Responses: []openapi.Resp{{Status: http.StatusOK, Body: models.ServiceInfo{}}},
+		},
+		"GET /api/service/health": {
+			ID: "health", Tags: []string{"service"}, Summary: "Detailed health report",
+			Responses: []openapi.Resp{{Status: http.StatusOK, Body: models.HealthReport{}}},
+		},
+		"GET /api/openapi.json": {
+			ID: "openapi", Tags: []string{"service"}, Summary: "This document",
+			Responses: []openapi.Resp{{Status: http.StatusOK, Description: "OpenAPI 3 document"}},
+		},
+		"GET /api/docs":   {Hidden: true},
+		"GET /api/docs/*": {Hidden: true},
+
+		"GET /healthz": {
+			ID: "liveness", Tags: []string{"probes"}, Summary: "Liveness probe",
+			Responses: []openapi.Resp{{Status: http.StatusOK, Body: models.HealthCheck{}}},
+		},
+		"GET /readyz": {
+			ID: "readiness", Tags: []string{"probes"}, Summary: "Readiness probe",
+			Description: "A database schema older than this build fails the probe, a newer one is only a warning, so that replicas keep serving while migrations run ahead of a rollout.",
+			Responses: []openapi.Resp{
+				{Status: http.StatusOK, Body: models.Readiness{}},
+				{Status: http.StatusServiceUnavailable, Description: "Shutting down or a dependency is failing", Body: models.Readiness{}},
+			},
+		},
+	}
+
+	if a.cfg.Metrics.Enabled {
+		ops["GET "+a.cfg.Metrics.Path] = &openapi.Op{
+			ID: "metrics", Tags: []string{"probes"}, Summary: "Prometheus metrics",
+			Responses: []openapi.Resp{{Status: http.StatusOK, Description: "Prometheus text exposition format"}},
+		}
+	}
+
+	return ops
+}
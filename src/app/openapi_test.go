@@ -0,0 +1,68 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"techno-forum/src/config"
+	"testing"
+
+	"github.com/go-chi/chi"
+)
+
+// routeParam matches the chi path parameters, with their optional regexp.
+var routeParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+func newMemoryApp(t *testing.T) *App {
+	t.Helper()
+
+	cfg := config.Default()
+	cfg.Storage = config.StorageMemory
+	cfg.Attachments.Dir = t.TempDir()
+
+	a, err := New(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(a.close)
+	return a
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	a := newMemoryApp(t)
+
+	doc, err := a.OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	routes, ok := a.server.Handler.(chi.Routes)
+	if !ok {
+		t.Fatalf("the handler is a %T, not a chi router", a.server.Handler)
+	}
+
+	ops := a.operations()
+	err = chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := method + " " + route
+		op, ok := ops[key]
+		if !ok {
+			t.Errorf("%s has no spec entry", key)
+			return nil
+		}
+		if op.Hidden {
+			return nil
+		}
+
+		path := routeParam.ReplaceAllString(route, "{$1}")
+		if doc.Paths[path][strings.ToLower(method)] == nil {
+			t.Errorf("%s is missing from the document", key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
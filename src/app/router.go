@@ -0,0 +1,190 @@
+package app
+
+import (
+	"net/http"
+	"techno-forum/src/delivery"
+	"techno-forum/src/metrics"
+	"techno-forum/src/openapi"
+	"techno-forum/src/tracing"
+	"techno-forum/src/usecase"
+
+	"github.com/go-chi/chi"
+)
+
+func (a *App) router() (http.Handler, error) {
+	UserRepo := a.repos.Users
+	ForumRepo := a.repos.Forums
+	ThreadRepo := a.repos.Threads
+	PostsRepo := a.repos.Posts
+	AttachmentRepo := a.repos.Attachments
+	ServiceRepo := a.repos.Service
+	VoteRepo := a.repos.Votes
+	NotificationRepo := a.repos.Notifications
+	SessionRepo := a.repos.Sessions
+	BanRepo := a.repos.Bans
+	SearchRepo := a.repos.Search
+	WebhookRepo := a.repos.Webhooks
+	SubscriptionRepo := a.repos.Subscriptions
+	DigestRepo := a.repos.Digests
+
+	ForumUseCase := usecase.NewForumUseCase(ForumRepo, UserRepo, SubscriptionRepo)
+	ThreadUseCase := usecase.NewThreadUseCase(ThreadRepo, UserRepo, ForumRepo, BanRepo, VoteRepo, SubscriptionRepo, a.events)
+	PostsUseCase := usecase.NewPostUseCase(PostsRepo, ThreadRepo, AttachmentRepo, ForumRepo, UserRepo, BanRepo, a.cfg.Attachments.MaxPerPost, a.events)
+	ModerationUseCase := usecase.NewModerationUseCase(ForumRepo, ThreadRepo, PostsRepo, UserRepo, BanRepo)
+	SearchUseCase := usecase.NewSearchUseCase(SearchRepo, ForumRepo, UserRepo)
+	AuthUseCase := usecase.NewAuthUseCase(UserRepo, SessionRepo, a.cfg.Auth)
+	UserUseCase := usecase.NewUserUseCase(UserRepo, AuthUseCase)
+	WebhookUseCase := usecase.NewWebhookUseCase(WebhookRepo, ForumRepo)
+	NotificationUseCase := usecase.NewNotificationUseCase(NotificationRepo, UserRepo)
+	DigestUseCase := usecase.NewDigestUseCase(DigestRepo, UserRepo)
+	AttachmentUseCase := usecase.NewAttachmentUseCase(AttachmentRepo, UserRepo, a.blobs, a.cfg.Attachments)
+
+	AuthDelivery := delivery.NewAuthDelivery(AuthUseCase)
+	UserDelivery := delivery.NewUserDelivery(UserRepo, ForumRepo, AuthUseCase, UserUseCase, a.cfg.Pagination)
+	ForumDelivery := delivery.NewForumDelivery(ForumUseCase)
+	ThreadDelivery := delivery.NewThreadDelivery(ThreadUseCase, a.cfg.Pagination)
+	PostsDelivery := delivery.NewPostDelivery(PostsUseCase, ThreadUseCase, ForumUseCase, UserRepo, a.cfg.Pagination)
+	ServiceDelivery := delivery.NewServiceDelivery(ServiceRepo)
+	VoteDelivery := delivery.NewVoteDelivery(UserRepo, ThreadUseCase)
+	ModerationDelivery := delivery.NewModerationDelivery(ModerationUseCase)
+	SearchDelivery := delivery.NewSearchDelivery(SearchUseCase, a.cfg.Pagination)
+	StreamDelivery := delivery.NewStreamDelivery(PostsUseCase, ThreadUseCase, a.events, a.cfg.Server.StreamHeartbeat, a.cfg.Pagination)
+	NotificationDelivery := delivery.NewNotificationDelivery(NotificationUseCase, a.cfg.Pagination)
+	WebhookDelivery := delivery.NewWebhookDelivery(WebhookUseCase, a.cfg.Pagination)
+	DigestDelivery := delivery.NewDigestDelivery(DigestUseCase)
+	AttachmentDelivery := delivery.NewAttachmentDelivery(AttachmentUseCase, a.cfg.Attachments)
+	HealthDelivery := delivery.NewHealthDelivery(a.health)
+	DocsDelivery := delivery.NewDocsDelivery(apiInfo.Title, "/api/openapi.json", "/api/docs/")
+
+	r := chi.NewRouter()
+	r.Use(delivery.RequestID(a.log))
+	r.Use(tracing.Middleware)
+	if a.cfg.Logging.AccessLog {
+		r.Use(delivery.AccessLog(a.log))
+	}
+	if a.cfg.Metrics.Enabled {
+		r.Use(metrics.Middleware)
+	}
+	r.Use(delivery.Recoverer)
+	r.Use(delivery.ContentTypeSetter)
+	r.Use(delivery.StatementTimeout(r, a.cfg.Database.StatementTimeout, a.cfg.Database.RouteStatementTimeouts))
+	r.Use(AuthDelivery.Authenticate)
+
+	r.Get("/healthz", HealthDelivery.Live)
+	r.Get("/readyz", HealthDelivery.Ready)
+
+	if a.cfg.Metrics.Enabled {
+		r.Method(http.MethodGet, a.cfg.Metrics.Path, metrics.Handler())
+	}
+
+	r.Route("/api", func(r chi.Router) {
+		r.Get("/openapi.json", DocsDelivery.Spec)
+		r.Get("/docs", http.RedirectHandler("/api/docs/", http.StatusMovedPermanently).ServeHTTP)
+		r.Get("/docs/*", DocsDelivery.UI)
+
+		r.Route("/auth", func(r chi.Router) {
+			r.Post("/login", AuthDelivery.Login)
+			r.Post("/logout", AuthDelivery.Logout)
+			r.Get("/me", AuthDelivery.Me)
+		})
+
+		r.Route("/forum", func(r chi.Router) {
+			r.With(AuthDelivery.RequireUser).Post("/create", ForumDelivery.Create)
+			r.With(AuthDelivery.RequireUser).Post("/{slug}/create", ThreadDelivery.Create)
+			r.Get("/{slug}/details", ForumDelivery.Get)
+			r.Get("/{slug}/threads", ThreadDelivery.GetByForum)
+			r.Get("/{slug}/users", UserDelivery.GetByForum)
+			r.Get("/{slug}/moderators", ModerationDelivery.GetModerators)
+			r.Put("/{slug}/moderators/{nickname}", ModerationDelivery.AddModerator)
+			r.Delete("/{slug}/moderators/{nickname}", ModerationDelivery.RemoveModerator)
+			r.Get("/{slug}/bans", ModerationDelivery.GetBans)
+			r.Put("/{slug}/bans/{nickname}", ModerationDelivery.Ban)
+			r.Delete("/{slug}/bans/{nickname}", ModerationDelivery.Unban)
+			r.With(AuthDelivery.RequireUser).Get("/{slug}/subscription", ForumDelivery.GetSubscription)
+			r.With(AuthDelivery.RequireUser).Put("/{slug}/subscription", ForumDelivery.Subscribe)
+			r.With(AuthDelivery.RequireUser).Delete("/{slug}/subscription", ForumDelivery.Unsubscribe)
+		})
+
+		r.Route("/user", func(r chi.Router) {
+			r.Post("/{nickname}/create", UserDelivery.Create)
+			r.Get("/{nickname}/profile", UserDelivery.GetByNickName)
+			r.With(AuthDelivery.RequireUser).Post("/{nickname}/profile", UserDelivery.Update)
+			r.Post("/{nickname}/role", UserDelivery.SetRole)
+			r.With(AuthDelivery.RequireUser).Get("/{nickname}/notifications", NotificationDelivery.List)
+			r.With(AuthDelivery.RequireUser).Post("/{nickname}/notifications/read", NotificationDelivery.MarkAllRead)
+			r.With(AuthDelivery.RequireUser).Post("/{nickname}/notifications/{id}/read", NotificationDelivery.MarkRead)
+			r.With(AuthDelivery.RequireUser).Get("/{nickname}/digest", DigestDelivery.Get)
+			r.With(AuthDelivery.RequireUser).Put("/{nickname}/digest", DigestDelivery.Set)
+		})
+
+		r.Route("/thread", func(r chi.Router) {
+			r.Get("/{slugOrId}/details", ThreadDelivery.Get)
+			r.With(AuthDelivery.RequireUser).Post("/{slugOrId}/details", ThreadDelivery.Update)
+			r.With(AuthDelivery.RequireUser).Delete("/{slugOrId}/details", ThreadDelivery.Delete)
+			r.Post("/{slugOrId}/restore", ModerationDelivery.RestoreThread)
+			r.Get("/{slugOrId}/revisions", ThreadDelivery.GetRevisions)
+			r.Get("/{slugOrId}/diff", ThreadDelivery.Diff)
+			r.With(AuthDelivery.RequireUser).Post("/{slugOrId}/create", PostsDelivery.Create)
+			r.Get("/{slugOrId}/posts", PostsDelivery.GetByThread)
+			r.Get("/{slugOrId}/stream", StreamDelivery.Stream)
+			r.With(AuthDelivery.RequireUser).Post("/{slugOrId}/vote", VoteDelivery.Vote)
+			r.With(AuthDelivery.RequireUser).Get("/{slugOrId}/subscription", ThreadDelivery.GetSubscription)
+			r.With(AuthDelivery.RequireUser).Put("/{slugOrId}/subscription", ThreadDelivery.Subscribe)
+			r.With(AuthDelivery.RequireUser).Delete("/{slugOrId}/subscription", ThreadDelivery.Unsubscribe)
+			r.Put("/{slugOrId}/lock", ModerationDelivery.Lock)
+			r.Delete("/{slugOrId}/lock", ModerationDelivery.Unlock)
+		})
+
+		r.Route("/post", func(r chi.Router) {
+			r.Get("/{id}/details", PostsDelivery.Get)
+			r.With(AuthDelivery.RequireUser).Post("/{id}/details", PostsDelivery.Update)
+			r.With(AuthDelivery.RequireUser).Delete("/{id}/details", PostsDelivery.Delete)
+			r.Post("/{id}/restore", ModerationDelivery.RestorePost)
+			r.Get("/{id}/revisions", PostsDelivery.GetRevisions)
+			r.Get("/{id}/diff", PostsDelivery.Diff)
+			r.Put("/{id}/hidden", ModerationDelivery.Hide)
+			r.Delete("/{id}/hidden", ModerationDelivery.Unhide)
+		})
+
+		r.Route("/attachment", func(r chi.Router) {
+			r.With(AuthDelivery.RequireUser).Post("/create", AttachmentDelivery.Create)
+			r.Get("/{id}/details", AttachmentDelivery.Get)
+			r.Get("/{id}/content", AttachmentDelivery.Content)
+		})
+
+		r.Get("/search", SearchDelivery.Search)
+
+		r.Post("/webhooks", WebhookDelivery.Create)
+		r.Get("/webhooks", WebhookDelivery.List)
+		r.Get("/webhooks/{id}", WebhookDelivery.Get)
+		r.Delete("/webhooks/{id}", WebhookDelivery.Delete)
+		r.Get("/webhooks/{id}/deliveries", WebhookDelivery.GetDeliveries)
+
+		r.Get("/digest/unsubscribe", DigestDelivery.ConfirmUnsubscribe)
+		r.Post("/digest/unsubscribe", DigestDelivery.Unsubscribe)
+
+		r.Route("/service", func(r chi.Router) {
+			r.Post("/clear", ServiceDelivery.Clear)
+			r.Get("/status", ServiceDelivery.Status)
+			r.Get("/health", HealthDelivery.Report)
+		})
+	})
+
+	// A route missing from the spec is caught by the tests and the openapi
+	// command, the server only warns about it.
+	doc, err := openapi.Build(r, apiInfo, a.operations())
+	if doc == nil {
+		return nil, err
+	}
+	if err != nil {
+		a.log.Warn("the OpenAPI spec is out of date", "error", err)
+	}
+	security(doc)
+	a.spec, a.specErr = doc, err
+
+	if err = DocsDelivery.SetSpec(doc); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
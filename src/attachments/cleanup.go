@@ -0,0 +1,78 @@
+// Package attachments deletes the uploads no post has linked in time.
+package attachments
+
+import (
+	"context"
+	"log/slog"
+	"techno-forum/src/blobstore"
+	"techno-forum/src/config"
+	"techno-forum/src/repository"
+	"time"
+)
+
+// batchSize is how many attachments are deleted at once.
+const batchSize = 100
+
+// Cleaner deletes unlinked uploads older than the configured TTL, both the
+// rows and their contents. Every instance may run one.
+type Cleaner struct {
+	repo  repository.AttachmentRepository
+	blobs blobstore.Store
+	cfg   config.Attachments
+	log   *slog.Logger
+}
+
+func NewCleaner(repo repository.AttachmentRepository, blobs blobstore.Store, cfg config.Attachments, logger *slog.Logger) *Cleaner {
+	return &Cleaner{
+		repo:  repo,
+		blobs: blobs,
+		cfg:   cfg,
+		log:   logger.With("component", "attachments"),
+	}
+}
+
+// Run deletes expired uploads until ctx is done.
+func (c *Cleaner) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.cfg.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.Clean(ctx); err != nil && ctx.Err() == nil {
+			c.log.Warn("deleting unlinked attachments failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Clean deletes every unlinked upload older than the TTL. The rows go
+// first, so that contents that fail to be deleted are only left over, never
+// served.
+func (c *Cleaner) Clean(ctx context.Context) error {
+	before := time.Now().Add(-c.cfg.UnlinkedTTL)
+
+	for ctx.Err() == nil {
+		keys, err := c.repo.DeleteUnlinked(ctx, before, batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err = c.blobs.Delete(ctx, key); err != nil {
+				c.log.Warn("deleting attachment contents failed", "key", key, "error", err)
+			}
+		}
+
+		if len(keys) > 0 {
+			c.log.Debug("deleted unlinked attachments", "count", len(keys))
+		}
+		if len(keys) < batchSize {
+			break
+		}
+	}
+	return nil
+}
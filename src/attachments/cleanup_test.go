@@ -0,0 +1,70 @@
+package attachments
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"techno-forum/src/blobstore"
+	"techno-forum/src/config"
+	"techno-forum/src/models"
+	"techno-forum/src/repository/memory"
+	"testing"
+	"time"
+)
+
+func TestClean(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.NewRepositories(memory.NewStore())
+
+	user := &models.User{Nickname: "alice", Email: "alice@example.com"}
+	if _, err := repos.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+
+	blobs, err := blobstore.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	attachment := &models.Attachment{Uploader: user.Nickname, UploaderId: user.Id, Key: "ab/abc", Size: 4}
+	if err = blobs.Put(ctx, &blobstore.Object{Key: attachment.Key, Size: 4}, strings.NewReader("body")); err != nil {
+		t.Fatal(err)
+	}
+	if err = repos.Attachments.Create(ctx, attachment); err != nil {
+		t.Fatal(err)
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tests := []struct {
+		name string
+		ttl  time.Duration
+		gone bool
+	}{
+		{"not expired", time.Hour, false},
+		{"expired", time.Nanosecond, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cleaner := NewCleaner(repos.Attachments, blobs, config.Attachments{UnlinkedTTL: tt.ttl}, logger)
+			if err := cleaner.Clean(ctx); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := repos.Attachments.Get(ctx, attachment.Id)
+			if gone := errors.Is(err, models.ErrNotFound); gone != tt.gone {
+				t.Errorf("got row error %v, want gone %t", err, tt.gone)
+			}
+
+			body, err := blobs.Get(ctx, attachment.Key)
+			if err == nil {
+				body.Close()
+			}
+			if gone := errors.Is(err, blobstore.ErrNotExist); gone != tt.gone {
+				t.Errorf("got contents error %v, want gone %t", err, tt.gone)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"techno-forum/src/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	MinPasswordLength = 8
+	// bcrypt ignores everything past 72 bytes.
+	MaxPasswordLength = 72
+)
+
+var ErrInvalidCredentials = models.NewError(models.ErrUnauthorized, "invalid nickname or password")
+
+type ctxKey struct{}
+
+// WithUser stores the user the request was authenticated as.
+func WithUser(ctx context.Context, user *models.User) context.Context {
+	return context.WithValue(ctx, ctxKey{}, user)
+}
+
+// User returns the authenticated user, or nil for anonymous requests.
+func User(ctx context.Context) *models.User {
+	user, _ := ctx.Value(ctxKey{}).(*models.User)
+	return user
+}
+
+// Nickname returns the nickname of the authenticated user, so that it takes
+// precedence over the one claimed in a request body, or claimed otherwise.
+func Nickname(ctx context.Context, claimed string) string {
+	if user := User(ctx); user != nil {
+		return user.Nickname
+	}
+	return claimed
+}
+
+// ActAs checks that the request may act on behalf of user. An authenticated
+// request may only act as its own user. An anonymous one may act as users
+// without a password, which is how the forum worked before accounts had
+// credentials.
+func ActAs(ctx context.Context, user *models.User) error {
+	acting := User(ctx)
+
+	switch {
+	case acting != nil && acting.Id == user.Id:
+		return nil
+	case acting != nil:
+		return models.NewError(models.ErrForbidden, "signed in as %s, can't act as %s", acting.Nickname, user.Nickname)
+	case user.PasswordHash != "":
+		return models.NewError(models.ErrUnauthorized, "sign in to act as %s", user.Nickname)
+	}
+	return nil
+}
+
+func IsAdmin(user *models.User) bool {
+	return user != nil && user.Role == models.RoleAdmin
+}
+
+// RequireAdmin lets only admins through.
+func RequireAdmin(ctx context.Context) error {
+	acting := User(ctx)
+
+	switch {
+	case acting == nil:
+		return models.NewError(models.ErrUnauthorized, "sign in as an admin")
+	case !IsAdmin(acting):
+		return models.NewError(models.ErrForbidden, "only admins can do this")
+	}
+	return nil
+}
+
+// CanEdit checks that the request may edit a post or thread by author: the
+// author, a moderator of its forum and admins may. moderates reports whether
+// the acting user moderates the forum; it is only called when that matters.
+// Anonymous requests fall back to ActAs.
+func CanEdit(ctx context.Context, author *models.User, moderates func(user *models.User) (bool, error)) error {
+	acting := User(ctx)
+
+	if acting == nil {
+		return ActAs(ctx, author)
+	}
+
+	if acting.Id == author.Id || IsAdmin(acting) {
+		return nil
+	}
+
+	ok, err := moderates(acting)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewError(models.ErrForbidden, "only the author or a moderator of the forum can edit this")
+	}
+	return nil
+}
+
+// Moderate lets admins through, and the users for whom moderates is true.
+func Moderate(ctx context.Context, moderates func(user *models.User) (bool, error)) error {
+	acting := User(ctx)
+
+	switch {
+	case acting == nil:
+		return models.NewError(models.ErrUnauthorized, "sign in to moderate")
+	case IsAdmin(acting):
+		return nil
+	}
+
+	ok, err := moderates(acting)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return models.NewError(models.ErrForbidden, "%s can't moderate this forum", acting.Nickname)
+	}
+	return nil
+}
+
+// CanEditProfile lets users edit their own profile and admins edit any.
+func CanEditProfile(ctx context.Context, user *models.User) error {
+	if IsAdmin(User(ctx)) {
+		return nil
+	}
+	return ActAs(ctx, user)
+}
+
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return models.NewError(models.ErrValidation, "password must be at least %d characters long", MinPasswordLength)
+	}
+	if len(password) > MaxPasswordLength {
+		return models.NewError(models.ErrValidation, "password must be at most %d bytes long", MaxPasswordLength)
+	}
+	return nil
+}
+
+func HashPassword(password string, cost int) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	return string(hash), err
+}
+
+func CheckPassword(hash, password string) bool {
+	if hash == "" {
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewToken returns a random session token and the hash it is stored by, so
+// that a leaked sessions table can't be used to sign in.
+func NewToken() (string, []byte, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	return token, HashToken(token), nil
+}
+
+func HashToken(token string) []byte {
+	sum := sha256.Sum256([]byte(token))
+	return sum[:]
+}
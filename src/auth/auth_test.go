@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"techno-forum/src/models"
+	"testing"
+)
+
+var (
+	alice = &models.User{Id: 1, Nickname: "alice", PasswordHash: "hash"}
+	bob   = &models.User{Id: 2, Nickname: "bob"}
+	admin = &models.User{Id: 3, Nickname: "admin", PasswordHash: "hash", Role: models.RoleAdmin}
+)
+
+// as returns the context of a request signed in as user, or of an anonymous
+// one for nil. Anonymous requests only get this far when auth.required is
+// off, with it on every write needs a session.
+func as(user *models.User) context.Context {
+	if user == nil {
+		return context.Background()
+	}
+	return WithUser(context.Background(), user)
+}
+
+func checkKind(t *testing.T, err error, want error) {
+	t.Helper()
+	if want == nil && err != nil || want != nil && !errors.Is(err, want) {
+		t.Errorf("got %v, want %v", err, want)
+	}
+}
+
+func TestActAs(t *testing.T) {
+	tests := []struct {
+		name   string
+		acting *models.User
+		user   *models.User
+		want   error
+	}{
+		{"anonymous as a user without a password", nil, bob, nil},
+		{"anonymous as a user with a password", nil, alice, models.ErrUnauthorized},
+		{"as oneself", alice, alice, nil},
+		{"as another user", alice, bob, models.ErrForbidden},
+		{"admin as another user", admin, alice, models.ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkKind(t, ActAs(as(tt.acting), tt.user), tt.want)
+		})
+	}
+}
+
+func TestCanEdit(t *testing.T) {
+	errLookup := errors.New("lookup failed")
+
+	tests := []struct {
+		name      string
+		acting    *models.User
+		author    *models.User
+		moderates bool
+		lookupErr error
+		asked     bool
+		want      error
+	}{
+		{"anonymous, author without a password", nil, bob, false, nil, false, nil},
+		{"anonymous, author with a password", nil, alice, true, nil, false, models.ErrUnauthorized},
+		{"author", alice, alice, false, nil, false, nil},
+		{"admin", admin, alice, false, nil, false, nil},
+		{"moderator", bob, alice, true, nil, true, nil},
+		{"other user", bob, alice, false, nil, true, models.ErrForbidden},
+		{"moderators unknown", bob, alice, false, errLookup, true, errLookup},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asked := false
+			err := CanEdit(as(tt.acting), tt.author, func(user *models.User) (bool, error) {
+				asked = true
+				if user != tt.acting {
+					t.Errorf("asked whether %s moderates, want %s", user.Nickname, tt.acting.Nickname)
+				}
+				return tt.moderates, tt.lookupErr
+			})
+
+			checkKind(t, err, tt.want)
+			if asked != tt.asked {
+				t.Errorf("asked for moderators: %t, want %t", asked, tt.asked)
+			}
+		})
+	}
+}
+
+func TestRequireAdmin(t *testing.T) {
+	tests := []struct {
+		name   string
+		acting *models.User
+		want   error
+	}{
+		{"anonymous", nil, models.ErrUnauthorized},
+		{"user", alice, models.ErrForbidden},
+		{"admin", admin, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkKind(t, RequireAdmin(as(tt.acting)), tt.want)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Package blobstore keeps the contents of attachments, on the local file
+// system or in an S3 compatible object store.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"techno-forum/src/config"
+)
+
+var ErrNotExist = errors.New("blob doesn't exist")
+
+// Object describes a blob to store. SHA256 is the hex encoded hash of the
+// contents, which the S3 backend signs the upload with.
+type Object struct {
+	Key         string
+	ContentType string
+	Size        int64
+	SHA256      string
+}
+
+type Store interface {
+	// Put stores exactly obj.Size bytes of body under obj.Key.
+	Put(ctx context.Context, obj *Object, body io.Reader) error
+	// Get returns the contents stored under key, or ErrNotExist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key. Removing a missing key isn't an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the store cfg selects.
+func New(cfg config.Attachments) (Store, error) {
+	switch cfg.Storage {
+	case config.BlobsLocal:
+		return NewLocal(cfg.Dir)
+	case config.BlobsS3:
+		return NewS3(cfg.S3)
+	}
+	return nil, fmt.Errorf("unknown attachment storage %q", cfg.Storage)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"techno-forum/src/config"
+	"techno-forum/src/logging"
+	"techno-forum/src/models"
+	"techno-forum/src/repository/postgres"
+	"techno-forum/src/utils"
+)
+
+const adminUsage = "usage: %s admin grant|revoke <nickname> [flags]\n"
+
+// runAdmin gives or takes the admin role, so that the first admin is made by
+// whoever runs the server rather than by whoever registers a nickname.
+func runAdmin(name string, args []string) int {
+	if len(args) < 2 || (args[0] != "grant" && args[0] != "revoke") {
+		fmt.Fprintf(os.Stderr, adminUsage, name)
+		return 2
+	}
+
+	action, nickname := args[0], args[1]
+
+	cfg, err := config.Load(name+" admin "+action, args[2:])
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if cfg.Storage != config.StoragePostgres {
+		fmt.Fprintf(os.Stderr, "roles can only be changed in the %q storage, list admins in auth.admins instead\n", config.StoragePostgres)
+		return 2
+	}
+
+	ctx := context.Background()
+	logger := logging.New(cfg.Logging, os.Stderr)
+
+	dbpool, err := utils.InitPostgres(ctx, cfg.Database, logger, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer dbpool.Close()
+
+	role := models.RoleAdmin
+	if action == "revoke" {
+		role = models.RoleUser
+	}
+
+	if err = postgres.NewUserRepo(dbpool).SetRole(ctx, nickname, role); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Printf("role of %s set to %s\n", nickname, role)
+	return 0
+}
@@ -2,96 +2,63 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
-	"net/http"
+	"log/slog"
 	"os"
-	"techno-forum/src/delivery"
-	"techno-forum/src/repository"
-	"techno-forum/src/usecase"
-	"techno-forum/src/utils"
-
-	"github.com/go-chi/chi"
+	"os/signal"
+	"syscall"
+	"techno-forum/src/app"
+	"techno-forum/src/config"
+	"techno-forum/src/logging"
 )
 
-func ContentTypeSetter(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		next.ServeHTTP(w, r)
-	})
-}
-
 func main() {
-	dbpool, err := utils.InitPostgres()
-
-	if err != nil {
-		log.Fatal(err)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(os.Args[0], os.Args[2:]))
 	}
 
-	var greeting string
-
-	err = dbpool.QueryRow(context.Background(), "select 'Hello, PostgeSQL!'").Scan(&greeting)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "QueryRow failed: %v\n", err)
-		return
+	if len(os.Args) > 1 && os.Args[1] == "admin" {
+		os.Exit(runAdmin(os.Args[0], os.Args[2:]))
 	}
 
-	fmt.Println(greeting)
+	if len(os.Args) > 1 && os.Args[1] == "openapi" {
+		os.Exit(runOpenAPI(os.Args[2:]))
+	}
 
-	UserRepo := repository.NewUserRepo(dbpool)
-	ForumRepo := repository.NewForumRepository(dbpool)
-	ThreadRepo := repository.NewThreadRepository(dbpool)
-	PostsRepo := repository.NewPostRepo(dbpool)
-	ServiceRepo := repository.NewServiceRepo(dbpool)
-	VoteRepo := repository.NewVoteRepository(dbpool)
+	if len(os.Args) > 1 && os.Args[1] == "webhook-stub" {
+		os.Exit(runWebhookStub(os.Args[0], os.Args[2:]))
+	}
 
-	ForumUseCase := usecase.NewForumUseCase(ForumRepo, UserRepo)
-	ThreadUseCase := usecase.NewThreadUseCase(ThreadRepo, UserRepo, ForumRepo)
-	PostsUseCase := usecase.NewPostUseCase(PostsRepo, ForumRepo)
+	cfg, err := config.Load(os.Args[0], os.Args[1:])
 
-	UserDelivery := delivery.NewUserDelivery(UserRepo, ForumRepo)
-	ForumDelivery := delivery.NewForumDelivery(ForumUseCase)
-	ThreadDelivery := delivery.NewThreadDelivery(ThreadUseCase)
-	PostsDelivery := delivery.NewPostDelivery(PostsUseCase, ThreadUseCase, ForumUseCase, UserRepo)
-	ServiceDelivery := delivery.NewServiceDelivery(ServiceRepo)
-	VoteDelivery := delivery.NewVoteDelivery(VoteRepo, UserRepo, ThreadUseCase)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 
-	r := chi.NewRouter()
-	r.Use(ContentTypeSetter)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 
-	r.Route("/api", func(r chi.Router) {
-		r.Route("/forum", func(r chi.Router) {
-			r.Post("/create", ForumDelivery.Create)
-			r.Post("/{slug}/create", ThreadDelivery.Create)
-			r.Get("/{slug}/details", ForumDelivery.Get)
-			r.Get("/{slug}/threads", ThreadDelivery.GetByForum)
-			r.Get("/{slug}/users", UserDelivery.GetByForum)
-		})
+	logger := logging.New(cfg.Logging, os.Stdout)
+	slog.SetDefault(logger)
 
-		r.Route("/user", func(r chi.Router) {
-			r.Post("/{nickname}/create", UserDelivery.Create)
-			r.Get("/{nickname}/profile", UserDelivery.GetByNickName)
-			r.Post("/{nickname}/profile", UserDelivery.Update)
-		})
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-		r.Route("/thread", func(r chi.Router) {
-			r.Get("/{slugOrId}/details", ThreadDelivery.Get)
-			r.Post("/{slugOrId}/details", ThreadDelivery.Update)
-			r.Post("/{slugOrId}/create", PostsDelivery.Create)
-			r.Get("/{slugOrId}/posts", PostsDelivery.GetByThread)
-			r.Post("/{slugOrId}/vote", VoteDelivery.Vote)
-		})
+	application, err := app.New(ctx, cfg, logger)
 
-		r.Route("/post", func(r chi.Router) {
-			r.Get("/{id}/details", PostsDelivery.Get)
-			r.Post("/{id}/details", PostsDelivery.Update)
-		})
+	if err != nil {
+		logger.Error("failed to start", "error", err)
+		os.Exit(1)
+	}
 
-		r.Route("/service", func(r chi.Router) {
-			r.Post("/clear", ServiceDelivery.Clear)
-			r.Get("/status", ServiceDelivery.Status)
-		})
-	})
+	if err = application.Run(ctx); err != nil {
+		logger.Error("server failed", "error", err)
+		os.Exit(1)
+	}
 
-	log.Fatal(http.ListenAndServe(":5000", r))
+	logger.Info("stopped")
 }
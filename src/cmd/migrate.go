@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"techno-forum/src/config"
+	"techno-forum/src/logging"
+	"techno-forum/src/migrations"
+	"techno-forum/src/utils"
+)
+
+const migrateUsage = "usage: %s migrate up|down|status [flags]\n"
+
+func runMigrate(name string, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, migrateUsage, name)
+		return 2
+	}
+
+	action := args[0]
+	if action != "up" && action != "down" && action != "status" {
+		fmt.Fprintf(os.Stderr, migrateUsage, name)
+		return 2
+	}
+
+	cfg, err := config.Load(name+" migrate "+action, args[1:])
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	if cfg.Storage != config.StoragePostgres {
+		fmt.Fprintf(os.Stderr, "migrations only apply to the %q storage\n", config.StoragePostgres)
+		return 2
+	}
+
+	ctx := context.Background()
+
+	dbpool, err := utils.InitPostgres(ctx, cfg.Database, logging.New(cfg.Logging, os.Stderr), nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer dbpool.Close()
+
+	migrator := migrations.NewMigrator(dbpool)
+
+	switch action {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, m := range done {
+			fmt.Printf("applied %03d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if len(done) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		m, err := migrator.Down(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if m == nil {
+			fmt.Println("no migrations to revert")
+		} else {
+			fmt.Printf("reverted %03d_%s\n", m.Version, m.Name)
+		}
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		for _, s := range statuses {
+			applied := "pending"
+			if s.AppliedAt != nil {
+				applied = "applied " + s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%03d_%-30s %s\n", s.Version, s.Name, applied)
+		}
+	}
+
+	return 0
+}
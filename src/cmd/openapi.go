@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"techno-forum/src/app"
+	"techno-forum/src/config"
+)
+
+// runOpenAPI prints the specification of the router. It builds the App over
+// the in-memory storage, so it needs no database, and fails when a route has
+// no spec entry. CI runs it to keep the two in sync.
+func runOpenAPI(args []string) int {
+	if len(args) > 0 {
+		fmt.Fprintln(os.Stderr, "the openapi command takes no arguments")
+		return 2
+	}
+
+	cfg := config.Default()
+	cfg.Storage = config.StorageMemory
+
+	a, err := app.New(context.Background(), cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	doc, err := a.OpenAPI()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+
+	if err = enc.Encode(doc); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"techno-forum/src/webhooks"
+)
+
+// runWebhookStub serves a webhook receiver that logs every delivery and
+// checks its signature, to try webhooks out locally. -status makes it answer
+// with another status, to watch deliveries being retried.
+func runWebhookStub(name string, args []string) int {
+	fs := flag.NewFlagSet(name+" webhook-stub", flag.ContinueOnError)
+	listen := fs.String("listen", ":5080", "address to listen on")
+	secret := fs.String("secret", "", "webhook secret to verify signatures with, empty skips the check")
+	status := fs.Int("status", http.StatusNoContent, "status to answer deliveries with")
+
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return 0
+		}
+		return 2
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		signature := "unchecked"
+		if *secret != "" {
+			signature = "valid"
+			if !hmac.Equal([]byte(r.Header.Get(webhooks.SignatureHeader)), []byte(webhooks.Sign(*secret, body))) {
+				signature = "invalid"
+			}
+		}
+
+		logger.Info("delivery",
+			"event", r.Header.Get(webhooks.EventHeader),
+			"delivery", r.Header.Get(webhooks.DeliveryHeader),
+			"signature", signature,
+			"status", *status,
+			"body", string(body))
+
+		if signature == "invalid" {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(*status)
+	})
+
+	server := &http.Server{Addr: *listen, Handler: handler}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	logger.Info("listening", "addr", *listen)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,488 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const EnvPrefix = "FORUM_"
+
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+const (
+	TraceExporterNone   = "none"
+	TraceExporterOTLP   = "otlp"
+	TraceExporterStdout = "stdout"
+	TraceExporterFile   = "file"
+)
+
+const (
+	MailTransportSMTP    = "smtp"
+	MailTransportMaildir = "maildir"
+)
+
+const (
+	BlobsLocal = "local"
+	BlobsS3    = "s3"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+type Server struct {
+	Listen            string        `yaml:"listen" flag:"listen" usage:"address the HTTP server listens on"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" flag:"read-timeout" usage:"maximum duration for reading a request"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" flag:"read-header-timeout" usage:"maximum duration for reading request headers"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" flag:"write-timeout" usage:"maximum duration for writing a response"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" flag:"idle-timeout" usage:"keep-alive idle timeout"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay" flag:"shutdown-delay" usage:"how long to report not-ready before draining on shutdown"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" flag:"shutdown-timeout" usage:"how long to wait for in-flight requests on shutdown"`
+	StreamHeartbeat   time.Duration `yaml:"stream_heartbeat" flag:"stream-heartbeat" usage:"interval of keep-alive comments on event streams"`
+}
+
+type Database struct {
+	DSN           string        `yaml:"dsn" flag:"db-dsn" usage:"postgres connection string"`
+	MaxConns      int           `yaml:"max_conns" flag:"db-max-conns" usage:"maximum size of the connection pool"`
+	MinConns      int           `yaml:"min_conns" flag:"db-min-conns" usage:"minimum size of the connection pool"`
+	ConnectRetry  time.Duration `yaml:"connect_retry" flag:"db-connect-retry" usage:"how long to keep retrying the initial connection"`
+	RetryInterval time.Duration `yaml:"retry_interval" flag:"db-retry-interval" usage:"pause between connection attempts"`
+
+	StatementTimeout time.Duration `yaml:"statement_timeout" flag:"db-statement-timeout" usage:"deadline for the database work of one request, 0 disables it"`
+	// RouteStatementTimeouts overrides StatementTimeout for single routes.
+	// Keys are chi route patterns, optionally prefixed with a method:
+	// "GET /api/thread/{slugOrId}/posts" or "/api/service/status". Zero
+	// disables the deadline, which long-lived streams need.
+	RouteStatementTimeouts map[string]time.Duration `yaml:"route_statement_timeouts"`
+}
+
+type Pagination struct {
+	DefaultLimit int `yaml:"default_limit" flag:"default-limit" usage:"page size used when a request has no limit"`
+	MaxLimit     int `yaml:"max_limit" flag:"max-limit" usage:"largest page size a request may ask for"`
+}
+
+type Logging struct {
+	Level     string `yaml:"level" flag:"log-level" usage:"minimum log level: debug, info, warn or error"`
+	Format    string `yaml:"format" flag:"log-format" usage:"log format: text or json"`
+	AccessLog bool   `yaml:"access_log" flag:"access-log" usage:"log every HTTP request"`
+}
+
+type Metrics struct {
+	Enabled bool   `yaml:"enabled" flag:"metrics" usage:"expose Prometheus metrics"`
+	Path    string `yaml:"path" flag:"metrics-path" usage:"path the Prometheus metrics are served on"`
+}
+
+type Tracing struct {
+	Exporter    string  `yaml:"exporter" flag:"trace-exporter" usage:"span exporter: none, otlp, stdout or file"`
+	Endpoint    string  `yaml:"endpoint" flag:"trace-endpoint" usage:"OTLP/HTTP collector host:port, empty uses OTEL_EXPORTER_OTLP_ENDPOINT"`
+	Insecure    bool    `yaml:"insecure" flag:"trace-insecure" usage:"send OTLP spans over plain HTTP"`
+	File        string  `yaml:"file" flag:"trace-file" usage:"file the file exporter appends spans to"`
+	ServiceName string  `yaml:"service_name" flag:"trace-service-name" usage:"service.name resource attribute"`
+	SampleRatio float64 `yaml:"sample_ratio" flag:"trace-sample-ratio" usage:"fraction of new traces to record, from 0 to 1"`
+}
+
+type Auth struct {
+	// Required makes every write endpoint demand a session token. When it is
+	// off, requests without a token may still act as any user that has no
+	// password, which is what the tech-db-forum test suite expects.
+	Required   bool          `yaml:"required" flag:"auth-required" usage:"reject writes without a session token"`
+	SessionTTL time.Duration `yaml:"session_ttl" flag:"session-ttl" usage:"lifetime of a session token"`
+	BcryptCost int           `yaml:"bcrypt_cost" flag:"bcrypt-cost" usage:"bcrypt cost factor for password hashes"`
+	// Admins are admins whatever their stored role, which is the only way
+	// to have admins in the memory storage.
+	Admins string `yaml:"admins" flag:"admins" usage:"comma separated nicknames that are always admins"`
+}
+
+type Webhooks struct {
+	// Enabled runs the delivery worker. Events are queued in the outbox for
+	// the registered webhooks either way, so one instance may deliver for
+	// all of them.
+	Enabled      bool          `yaml:"enabled" flag:"webhooks" usage:"deliver queued webhook events from this instance"`
+	PollInterval time.Duration `yaml:"poll_interval" flag:"webhooks-poll-interval" usage:"how often the outbox is checked for events to deliver"`
+	Timeout      time.Duration `yaml:"timeout" flag:"webhooks-timeout" usage:"deadline of one delivery attempt"`
+	MaxAttempts  int           `yaml:"max_attempts" flag:"webhooks-max-attempts" usage:"attempts before a delivery is given up"`
+	BackoffBase  time.Duration `yaml:"backoff_base" flag:"webhooks-backoff-base" usage:"pause after the first failed attempt, doubled after each further one"`
+	BackoffMax   time.Duration `yaml:"backoff_max" flag:"webhooks-backoff-max" usage:"longest pause between attempts"`
+	BatchSize    int           `yaml:"batch_size" flag:"webhooks-batch-size" usage:"deliveries attempted at once"`
+}
+
+type SMTP struct {
+	Addr     string `yaml:"addr" flag:"smtp-addr" usage:"host:port of the SMTP server"`
+	Username string `yaml:"username" flag:"smtp-username" usage:"SMTP user, empty sends without authentication"`
+	Password string `yaml:"password" flag:"smtp-password" usage:"SMTP password"`
+}
+
+type Mail struct {
+	Transport string `yaml:"transport" flag:"mail-transport" usage:"how email is sent: smtp or maildir"`
+	From      string `yaml:"from" flag:"mail-from" usage:"sender address of outgoing email"`
+	SMTP      SMTP   `yaml:"smtp"`
+	// Maildir keeps every message as a file instead of sending it, for
+	// local testing.
+	Maildir string `yaml:"maildir" flag:"mail-maildir" usage:"directory the maildir transport delivers to"`
+}
+
+type Digests struct {
+	// Enabled runs the digest sender. Users may choose a frequency either
+	// way, so one instance may send for all of them.
+	Enabled      bool          `yaml:"enabled" flag:"digests" usage:"send due email digests from this instance"`
+	PollInterval time.Duration `yaml:"poll_interval" flag:"digests-poll-interval" usage:"how often due digests are looked for"`
+	Timeout      time.Duration `yaml:"timeout" flag:"digests-timeout" usage:"deadline of building and sending one digest"`
+	BatchSize    int           `yaml:"batch_size" flag:"digests-batch-size" usage:"digests claimed at once"`
+	MaxPosts     int           `yaml:"max_posts" flag:"digests-max-posts" usage:"most posts listed in one digest"`
+	// BaseURL is where recipients reach the API, for the unsubscribe link.
+	BaseURL string `yaml:"base_url" flag:"digests-base-url" usage:"public URL of the service used in email links"`
+}
+
+type S3 struct {
+	// Endpoint is the URL of the service, such as
+	// https://s3.eu-central-1.amazonaws.com or a local http://localhost:9000.
+	Endpoint  string `yaml:"endpoint" flag:"s3-endpoint" usage:"URL of the S3 compatible object store"`
+	Region    string `yaml:"region" flag:"s3-region" usage:"region the requests are signed for"`
+	Bucket    string `yaml:"bucket" flag:"s3-bucket" usage:"bucket attachments are stored in"`
+	AccessKey string `yaml:"access_key" flag:"s3-access-key" usage:"S3 access key id"`
+	SecretKey string `yaml:"secret_key" flag:"s3-secret-key" usage:"S3 secret access key"`
+	// PathStyle puts the bucket in the path instead of the host name, which
+	// MinIO and most other stand-ins expect.
+	PathStyle bool `yaml:"path_style" flag:"s3-path-style" usage:"address the bucket as endpoint/bucket instead of bucket.endpoint"`
+}
+
+type Attachments struct {
+	Storage string `yaml:"storage" flag:"attachments-storage" usage:"where attachment contents are kept: local or s3"`
+	Dir     string `yaml:"dir" flag:"attachments-dir" usage:"directory the local storage keeps attachments in"`
+	S3      S3     `yaml:"s3"`
+	MaxSize int    `yaml:"max_size" flag:"attachments-max-size" usage:"largest attachment in bytes"`
+	// Types lists the allowed media types as detected from the contents,
+	// "image/*" allows every image type.
+	Types      string `yaml:"types" flag:"attachments-types" usage:"comma separated media types that may be uploaded"`
+	MaxPerPost int    `yaml:"max_per_post" flag:"attachments-max-per-post" usage:"most attachments one post may link"`
+	// MaxAge is also how long an attachment of a post that is hidden or
+	// deleted later may still be served from caches.
+	MaxAge time.Duration `yaml:"max_age" flag:"attachments-max-age" usage:"how long clients and proxies may cache an attachment"`
+	// UnlinkedTTL is how long an upload may wait for a post to link it
+	// before it is deleted.
+	UnlinkedTTL     time.Duration `yaml:"unlinked_ttl" flag:"attachments-unlinked-ttl" usage:"how long uploads no post links are kept"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" flag:"attachments-cleanup-interval" usage:"how often expired unlinked uploads are deleted"`
+}
+
+type Config struct {
+	Storage     string      `yaml:"storage" flag:"storage" usage:"storage backend: postgres or memory"`
+	Server      Server      `yaml:"server"`
+	Database    Database    `yaml:"database"`
+	Pagination  Pagination  `yaml:"pagination"`
+	Logging     Logging     `yaml:"logging"`
+	Metrics     Metrics     `yaml:"metrics"`
+	Tracing     Tracing     `yaml:"tracing"`
+	Auth        Auth        `yaml:"auth"`
+	Webhooks    Webhooks    `yaml:"webhooks"`
+	Mail        Mail        `yaml:"mail"`
+	Digests     Digests     `yaml:"digests"`
+	Attachments Attachments `yaml:"attachments"`
+}
+
+func Default() *Config {
+	return &Config{
+		Storage: StoragePostgres,
+		Server: Server{
+			Listen:            ":5000",
+			ReadTimeout:       10 * time.Second,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownDelay:     0,
+			ShutdownTimeout:   15 * time.Second,
+			StreamHeartbeat:   15 * time.Second,
+		},
+		Database: Database{
+			DSN:           "user=postgres dbname=forum password=12345 host=localhost port=5432 sslmode=disable",
+			MaxConns:      20,
+			MinConns:      0,
+			ConnectRetry:  10 * time.Second,
+			RetryInterval: time.Second,
+
+			StatementTimeout: 10 * time.Second,
+			RouteStatementTimeouts: map[string]time.Duration{
+				"GET /api/thread/{slugOrId}/stream": 0,
+			},
+		},
+		Pagination: Pagination{
+			DefaultLimit: 100,
+			MaxLimit:     10000,
+		},
+		Logging: Logging{
+			Level:     "info",
+			Format:    LogFormatText,
+			AccessLog: true,
+		},
+		Metrics: Metrics{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: Tracing{
+			Exporter:    TraceExporterNone,
+			File:        "traces.jsonl",
+			ServiceName: "techno-forum",
+			SampleRatio: 1,
+		},
+		Auth: Auth{
+			Required:   false,
+			SessionTTL: 30 * 24 * time.Hour,
+			BcryptCost: 10,
+		},
+		Webhooks: Webhooks{
+			Enabled:      true,
+			PollInterval: time.Second,
+			Timeout:      10 * time.Second,
+			MaxAttempts:  8,
+			BackoffBase:  10 * time.Second,
+			BackoffMax:   time.Hour,
+			BatchSize:    20,
+		},
+		Mail: Mail{
+			Transport: MailTransportMaildir,
+			From:      "forum@localhost",
+			SMTP: SMTP{
+				Addr: "localhost:25",
+			},
+			Maildir: "mail",
+		},
+		Digests: Digests{
+			Enabled:      false,
+			PollInterval: time.Minute,
+			Timeout:      30 * time.Second,
+			BatchSize:    20,
+			MaxPosts:     50,
+			BaseURL:      "http://localhost:5000",
+		},
+		Attachments: Attachments{
+			Storage: BlobsLocal,
+			Dir:     "attachments",
+			S3: S3{
+				Region: "us-east-1",
+			},
+			MaxSize:         10 << 20,
+			Types:           "image/png,image/jpeg,image/gif,image/webp,text/plain,application/pdf",
+			MaxPerPost:      10,
+			MaxAge:          24 * time.Hour,
+			UnlinkedTTL:     24 * time.Hour,
+			CleanupInterval: 10 * time.Minute,
+		},
+	}
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// built-in defaults, the YAML file given by -config or FORUM_CONFIG,
+// FORUM_* environment variables and command line flags.
+func Load(name string, args []string) (*Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	path := fs.String("config", os.Getenv(EnvPrefix+"CONFIG"), "path to a YAML config file")
+
+	opts := options(cfg)
+	flagValues := make(map[string]*flagValue, len(opts))
+	for _, opt := range opts {
+		flagValues[opt.flag] = opt.flagValue()
+		fs.Var(flagValues[opt.flag], opt.flag, fmt.Sprintf("%s (env %s, default %q)", opt.usage, opt.env, opt.String()))
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *path != "" {
+		if err := loadFile(cfg, *path); err != nil {
+			return nil, err
+		}
+	}
+
+	var errs []error
+
+	for _, opt := range opts {
+		value, ok := os.LookupEnv(opt.env)
+		if !ok {
+			continue
+		}
+		if err := opt.Set(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", opt.env, err))
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			return
+		}
+		if err := optionByFlag(opts, f.Name).Set(flagValues[f.Name].value); err != nil {
+			errs = append(errs, fmt.Errorf("-%s: %w", f.Name, err))
+		}
+	})
+
+	var validationErr *Error
+	if errors.As(cfg.Validate(), &validationErr) {
+		errs = append(errs, validationErr.Problems...)
+	}
+
+	if len(errs) > 0 {
+		return nil, &Error{Problems: errs}
+	}
+
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (cfg *Config) Validate() error {
+	var errs []error
+
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(cfg.Storage == StoragePostgres || cfg.Storage == StorageMemory,
+		"storage must be %q or %q, got %q", StoragePostgres, StorageMemory, cfg.Storage)
+
+	check(cfg.Server.Listen != "", "server.listen must not be empty")
+	check(cfg.Server.ReadTimeout >= 0, "server.read_timeout must not be negative")
+	check(cfg.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout must not be negative")
+	check(cfg.Server.WriteTimeout >= 0, "server.write_timeout must not be negative")
+	check(cfg.Server.IdleTimeout >= 0, "server.idle_timeout must not be negative")
+	check(cfg.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative")
+	check(cfg.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+	check(cfg.Server.StreamHeartbeat > 0, "server.stream_heartbeat must be positive")
+
+	check(cfg.Database.DSN != "", "database.dsn must not be empty")
+	check(cfg.Database.MaxConns > 0, "database.max_conns must be positive, got %d", cfg.Database.MaxConns)
+	check(cfg.Database.MinConns >= 0, "database.min_conns must not be negative, got %d", cfg.Database.MinConns)
+	check(cfg.Database.MinConns <= cfg.Database.MaxConns,
+		"database.min_conns (%d) must not exceed database.max_conns (%d)", cfg.Database.MinConns, cfg.Database.MaxConns)
+	check(cfg.Database.ConnectRetry >= 0, "database.connect_retry must not be negative")
+	check(cfg.Database.RetryInterval > 0, "database.retry_interval must be positive")
+	check(cfg.Database.StatementTimeout >= 0, "database.statement_timeout must not be negative")
+	for route, timeout := range cfg.Database.RouteStatementTimeouts {
+		check(strings.Contains(route, "/"), "database.route_statement_timeouts: %q is not a route pattern", route)
+		check(timeout >= 0, "database.route_statement_timeouts[%q] must not be negative", route)
+	}
+
+	check(cfg.Pagination.DefaultLimit > 0, "pagination.default_limit must be positive, got %d", cfg.Pagination.DefaultLimit)
+	check(cfg.Pagination.MaxLimit >= cfg.Pagination.DefaultLimit,
+		"pagination.max_limit (%d) must not be below pagination.default_limit (%d)",
+		cfg.Pagination.MaxLimit, cfg.Pagination.DefaultLimit)
+
+	switch strings.ToLower(cfg.Logging.Level) {
+	case "debug", "info", "warn", "error":
+	default:
+		check(false, "logging.level must be one of debug, info, warn, error, got %q", cfg.Logging.Level)
+	}
+	check(cfg.Logging.Format == LogFormatText || cfg.Logging.Format == LogFormatJSON,
+		"logging.format must be %q or %q, got %q", LogFormatText, LogFormatJSON, cfg.Logging.Format)
+
+	check(!cfg.Metrics.Enabled || strings.HasPrefix(cfg.Metrics.Path, "/"),
+		"metrics.path must start with /, got %q", cfg.Metrics.Path)
+
+	switch cfg.Tracing.Exporter {
+	case TraceExporterNone, TraceExporterOTLP, TraceExporterStdout, TraceExporterFile:
+	default:
+		check(false, "tracing.exporter must be one of none, otlp, stdout, file, got %q", cfg.Tracing.Exporter)
+	}
+	check(cfg.Tracing.Exporter != TraceExporterFile || cfg.Tracing.File != "",
+		"tracing.file must not be empty with the file exporter")
+	check(cfg.Tracing.SampleRatio >= 0 && cfg.Tracing.SampleRatio <= 1,
+		"tracing.sample_ratio must be between 0 and 1, got %v", cfg.Tracing.SampleRatio)
+
+	check(cfg.Auth.SessionTTL > 0, "auth.session_ttl must be positive")
+	check(cfg.Auth.BcryptCost >= 4 && cfg.Auth.BcryptCost <= 31,
+		"auth.bcrypt_cost must be between 4 and 31, got %d", cfg.Auth.BcryptCost)
+
+	check(cfg.Webhooks.PollInterval > 0, "webhooks.poll_interval must be positive")
+	check(cfg.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	check(cfg.Webhooks.MaxAttempts > 0, "webhooks.max_attempts must be positive, got %d", cfg.Webhooks.MaxAttempts)
+	check(cfg.Webhooks.BackoffBase > 0, "webhooks.backoff_base must be positive")
+	check(cfg.Webhooks.BackoffMax >= cfg.Webhooks.BackoffBase, "webhooks.backoff_max must not be below webhooks.backoff_base")
+	check(cfg.Webhooks.BatchSize > 0, "webhooks.batch_size must be positive, got %d", cfg.Webhooks.BatchSize)
+
+	switch cfg.Mail.Transport {
+	case MailTransportSMTP:
+		check(cfg.Mail.SMTP.Addr != "", "mail.smtp.addr must not be empty with the smtp transport")
+	case MailTransportMaildir:
+		check(cfg.Mail.Maildir != "", "mail.maildir must not be empty with the maildir transport")
+	default:
+		check(false, "mail.transport must be %q or %q, got %q", MailTransportSMTP, MailTransportMaildir, cfg.Mail.Transport)
+	}
+	check(cfg.Mail.From != "", "mail.from must not be empty")
+
+	check(cfg.Digests.PollInterval > 0, "digests.poll_interval must be positive")
+	check(cfg.Digests.Timeout > 0, "digests.timeout must be positive")
+	check(cfg.Digests.BatchSize > 0, "digests.batch_size must be positive, got %d", cfg.Digests.BatchSize)
+	check(cfg.Digests.MaxPosts > 0, "digests.max_posts must be positive, got %d", cfg.Digests.MaxPosts)
+	check(strings.HasPrefix(cfg.Digests.BaseURL, "http://") || strings.HasPrefix(cfg.Digests.BaseURL, "https://"),
+		"digests.base_url must be an http or https URL, got %q", cfg.Digests.BaseURL)
+
+	switch cfg.Attachments.Storage {
+	case BlobsLocal:
+		check(cfg.Attachments.Dir != "", "attachments.dir must not be empty with the local storage")
+	case BlobsS3:
+		s3 := cfg.Attachments.S3
+		check(strings.HasPrefix(s3.Endpoint, "http://") || strings.HasPrefix(s3.Endpoint, "https://"),
+			"attachments.s3.endpoint must be an http or https URL, got %q", s3.Endpoint)
+		check(s3.Region != "", "attachments.s3.region must not be empty")
+		check(s3.Bucket != "", "attachments.s3.bucket must not be empty")
+		check(s3.AccessKey != "" && s3.SecretKey != "", "attachments.s3.access_key and secret_key must not be empty")
+	default:
+		check(false, "attachments.storage must be %q or %q, got %q", BlobsLocal, BlobsS3, cfg.Attachments.Storage)
+	}
+	check(cfg.Attachments.MaxSize > 0, "attachments.max_size must be positive, got %d", cfg.Attachments.MaxSize)
+	for _, typ := range strings.Split(cfg.Attachments.Types, ",") {
+		typ = strings.TrimSpace(typ)
+		check(strings.Count(typ, "/") == 1 && !strings.HasPrefix(typ, "/") && !strings.HasSuffix(typ, "/"),
+			"attachments.types: %q is not a media type", typ)
+	}
+	check(cfg.Attachments.MaxPerPost >= 0, "attachments.max_per_post must not be negative, got %d", cfg.Attachments.MaxPerPost)
+	check(cfg.Attachments.MaxAge >= 0, "attachments.max_age must not be negative")
+	check(cfg.Attachments.UnlinkedTTL > 0, "attachments.unlinked_ttl must be positive")
+	check(cfg.Attachments.CleanupInterval > 0, "attachments.cleanup_interval must be positive")
+
+	if len(errs) > 0 {
+		return &Error{Problems: errs}
+	}
+	return nil
+}
+
+type Error struct {
+	Problems []error
+}
+
+func (e *Error) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid configuration:")
+	for _, p := range e.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(p.Error())
+	}
+	return b.String()
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadBoolPrecedence(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  string
+		args []string
+		want bool
+	}{
+		{"default", "", "", nil, false},
+		{"bare flag", "", "", []string{"-auth-required"}, true},
+		{"flag value", "", "", []string{"-auth-required=true"}, true},
+		{"file", "true", "", nil, true},
+		{"env over file", "true", "false", nil, false},
+		{"bare flag over env", "", "false", []string{"-auth-required"}, true},
+		{"flag over env", "", "true", []string{"-auth-required=false"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvPrefix+"CONFIG", "")
+			if tt.env != "" {
+				t.Setenv(EnvPrefix+"AUTH_REQUIRED", tt.env)
+			}
+
+			args := []string{"-storage", StorageMemory}
+			if tt.file != "" {
+				path := filepath.Join(t.TempDir(), "config.yaml")
+				if err := os.WriteFile(path, []byte("auth:\n  required: "+tt.file+"\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+				args = append(args, "-config", path)
+			}
+
+			cfg, err := Load("forum", append(args, tt.args...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cfg.Auth.Required != tt.want {
+				t.Errorf("got auth.required %t, want %t", cfg.Auth.Required, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadInvalidBool(t *testing.T) {
+	if _, err := Load("forum", []string{"-storage", StorageMemory, "-auth-required=maybe"}); err == nil {
+		t.Error("got no error for -auth-required=maybe")
+	}
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// option is a single scalar setting that can be overridden from the
+// environment or the command line. Options are discovered from the `flag`
+// struct tags, the environment variable name is derived from the yaml path.
+type option struct {
+	flag  string
+	env   string
+	usage string
+	value reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func options(cfg *Config) []*option {
+	var res []*option
+	collectOptions(reflect.ValueOf(cfg).Elem(), nil, &res)
+	return res
+}
+
+func collectOptions(v reflect.Value, path []string, res *[]*option) {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		fieldPath := append(append([]string{}, path...), name)
+
+		if field.Type.Kind() == reflect.Struct {
+			collectOptions(v.Field(i), fieldPath, res)
+			continue
+		}
+
+		flagName := field.Tag.Get("flag")
+		if flagName == "" {
+			continue
+		}
+
+		*res = append(*res, &option{
+			flag:  flagName,
+			env:   EnvPrefix + strings.ToUpper(strings.Join(fieldPath, "_")),
+			usage: field.Tag.Get("usage"),
+			value: v.Field(i),
+		})
+	}
+}
+
+// flagValue holds the command line value of an option until the file and
+// the environment are applied. Boolean options may be given as a bare -flag.
+type flagValue struct {
+	value  string
+	isBool bool
+}
+
+func (f *flagValue) String() string {
+	return f.value
+}
+
+func (f *flagValue) Set(s string) error {
+	f.value = s
+	return nil
+}
+
+func (f *flagValue) IsBoolFlag() bool {
+	return f.isBool
+}
+
+func (opt *option) flagValue() *flagValue {
+	return &flagValue{isBool: opt.value.Kind() == reflect.Bool}
+}
+
+func optionByFlag(opts []*option, name string) *option {
+	for _, opt := range opts {
+		if opt.flag == name {
+			return opt
+		}
+	}
+	return nil
+}
+
+func (opt *option) String() string {
+	if opt.value.Type() == durationType {
+		return time.Duration(opt.value.Int()).String()
+	}
+	return fmt.Sprint(opt.value.Interface())
+}
+
+func (opt *option) Set(s string) error {
+	switch {
+	case opt.value.Type() == durationType:
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", s)
+		}
+		opt.value.SetInt(int64(d))
+	case opt.value.Kind() == reflect.String:
+		opt.value.SetString(s)
+	case opt.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", s)
+		}
+		opt.value.SetInt(int64(n))
+	case opt.value.Kind() == reflect.Float64:
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", s)
+		}
+		opt.value.SetFloat(f)
+	case opt.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", s)
+		}
+		opt.value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported option type %s", opt.value.Type())
+	}
+	return nil
+}
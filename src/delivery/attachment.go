@@ -0,0 +1,199 @@
+package delivery
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"techno-forum/src/config"
+	"techno-forum/src/logging"
+	"techno-forum/src/models"
+	"techno-forum/src/usecase"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+// multipartOverhead is how much an upload may exceed the largest attachment
+// for the boundaries, part headers and other form fields.
+const multipartOverhead = 64 << 10
+
+type AttachmentDelivery struct {
+	usecase *usecase.AttachmentUseCase
+	cfg     config.Attachments
+}
+
+func NewAttachmentDelivery(usecase *usecase.AttachmentUseCase, cfg config.Attachments) *AttachmentDelivery {
+	return &AttachmentDelivery{
+		usecase: usecase,
+		cfg:     cfg,
+	}
+}
+
+func parseAttachmentId(r *http.Request) (int64, error) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+
+	if err != nil {
+		return 0, models.NewError(models.ErrBadRequest, "invalid attachment id %q", idStr)
+	}
+	return id, nil
+}
+
+// Create stores the "file" part of a multipart/form-data upload. Other parts
+// are skipped.
+func (delivery *AttachmentDelivery) Create(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, int64(delivery.cfg.MaxSize)+multipartOverhead)
+
+	reader, err := r.MultipartReader()
+
+	if err != nil {
+		writeError(w, r, models.NewError(models.ErrBadRequest, "expected a multipart/form-data body: %v", err))
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+
+		if err == io.EOF {
+			writeError(w, r, models.NewError(models.ErrBadRequest, "the file part is missing"))
+			return
+		}
+
+		if err != nil {
+			writeError(w, r, uploadError(err))
+			return
+		}
+
+		if part.FormName() != "file" {
+			continue
+		}
+
+		attachment, err := delivery.usecase.Upload(r.Context(), r.URL.Query().Get("nickname"), part.FileName(), part)
+
+		if err != nil {
+			writeError(w, r, uploadError(err))
+			return
+		}
+
+		writeJSON(w, r, http.StatusCreated, attachment)
+		return
+	}
+}
+
+// uploadError turns the errors of reading a truncated or oversized body into
+// request errors.
+func uploadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		return models.NewError(models.ErrTooLarge, "request body too large")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return models.NewError(models.ErrBadRequest, "malformed multipart body: %v", err)
+	}
+	return err
+}
+
+func (delivery *AttachmentDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := parseAttachmentId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	attachment, err := delivery.usecase.Get(r.Context(), id)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, attachment)
+}
+
+// Content serves the file. The contents of an attachment never change, so
+// its hash is a strong ETag and conditional requests are answered without
+// touching the storage.
+func (delivery *AttachmentDelivery) Content(w http.ResponseWriter, r *http.Request) {
+	id, err := parseAttachmentId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	attachment, err := delivery.usecase.Get(r.Context(), id)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	etag := `"` + attachment.SHA256 + `"`
+	// Uploads no post links yet are only served to the uploader.
+	cacheScope := "public"
+	if attachment.Post == nil {
+		cacheScope = "private"
+	}
+	cacheHeaders := func() {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Last-Modified", attachment.Created.UTC().Format(http.TimeFormat))
+		w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", cacheScope, int(delivery.cfg.MaxAge.Seconds())))
+	}
+
+	if notModified(r, etag, attachment.Created) {
+		cacheHeaders()
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, err := delivery.usecase.Open(r.Context(), attachment)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer body.Close()
+
+	// Only images and plain text are shown in the browser. Nothing served
+	// here may run scripts or be sniffed as another type.
+	disposition := "attachment"
+	if strings.HasPrefix(attachment.ContentType, "image/") || strings.HasPrefix(attachment.ContentType, "text/plain") {
+		disposition = "inline"
+	}
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Name}); value != "" {
+		disposition = value
+	}
+
+	cacheHeaders()
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", disposition)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'; sandbox")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err = io.Copy(w, body); err != nil {
+		logging.FromContext(r.Context()).WarnContext(r.Context(), "serving attachment", "attachment", id, "error", err)
+	}
+}
+
+// notModified evaluates If-None-Match or, without it, If-Modified-Since.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if match := r.Header.Get("If-None-Match"); match != "" {
+		for _, candidate := range strings.Split(match, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == etag || candidate == "*" {
+				return true
+			}
+		}
+		return false
+	}
+
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	return err == nil && !modified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,102 @@
+package delivery
+
+import (
+	"net/http"
+	"strings"
+	"techno-forum/src/auth"
+	"techno-forum/src/models"
+	"techno-forum/src/usecase"
+)
+
+type AuthDelivery struct {
+	usecase *usecase.AuthUseCase
+}
+
+func NewAuthDelivery(usecase *usecase.AuthUseCase) *AuthDelivery {
+	return &AuthDelivery{
+		usecase: usecase,
+	}
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(header[len("Bearer "):])
+	}
+	return ""
+}
+
+// Authenticate resolves the session token of the request, if any, and
+// stores its user in the context. A request with a bad token is rejected
+// rather than treated as anonymous.
+func (delivery *AuthDelivery) Authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := bearerToken(r)
+		if token == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		session, err := delivery.usecase.Authenticate(r.Context(), token)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), session.User)))
+	})
+}
+
+// RequireUser rejects anonymous requests when auth.required is on.
+func (delivery *AuthDelivery) RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if delivery.usecase.Required() && auth.User(r.Context()) == nil {
+			writeError(w, r, models.NewError(models.ErrUnauthorized, "sign in first"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (delivery *AuthDelivery) Login(w http.ResponseWriter, r *http.Request) {
+	var creds models.Credentials
+
+	if err := readJSON(r, &creds); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	session, err := delivery.usecase.Login(r.Context(), &creds)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, session)
+}
+
+func (delivery *AuthDelivery) Logout(w http.ResponseWriter, r *http.Request) {
+	token := bearerToken(r)
+	if token == "" {
+		writeError(w, r, models.NewError(models.ErrUnauthorized, "no session token"))
+		return
+	}
+
+	if err := delivery.usecase.Logout(r.Context(), token); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (delivery *AuthDelivery) Me(w http.ResponseWriter, r *http.Request) {
+	user := auth.User(r.Context())
+	if user == nil {
+		writeError(w, r, models.NewError(models.ErrUnauthorized, "not signed in"))
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, models.Account{User: user, Role: user.Role})
+}
@@ -0,0 +1,45 @@
+package delivery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"techno-forum/src/auth"
+	"techno-forum/src/config"
+	"techno-forum/src/models"
+	"techno-forum/src/usecase"
+	"testing"
+)
+
+func TestRequireUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		required bool
+		user     *models.User
+		want     int
+	}{
+		{"not required, anonymous", false, nil, http.StatusNoContent},
+		{"not required, signed in", false, &models.User{Nickname: "alice"}, http.StatusNoContent},
+		{"required, anonymous", true, nil, http.StatusUnauthorized},
+		{"required, signed in", true, &models.User{Nickname: "alice"}, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delivery := NewAuthDelivery(usecase.NewAuthUseCase(nil, nil, config.Auth{Required: tt.required}))
+			handler := delivery.RequireUser(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			}))
+
+			r := httptest.NewRequest(http.MethodPost, "/api/forum/create", nil)
+			if tt.user != nil {
+				r = r.WithContext(auth.WithUser(r.Context(), tt.user))
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("got status %d, want %d", w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,146 @@
 package delivery
 
-import "fmt"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"techno-forum/src/config"
+	"techno-forum/src/logging"
+	"techno-forum/src/models"
+)
 
 type ErrorMsg struct {
 	Message string `json:"message"`
 }
 
-func MakeErrorMsg(msg string) string {
-	return fmt.Sprintf("{\"message\": \"%v\"}", msg)
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, context.Canceled), errors.Is(err, models.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, models.ErrBadRequest), errors.Is(err, models.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, models.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, models.ErrAlreadyExists),
+		errors.Is(err, models.ErrNoParent),
+		errors.Is(err, models.ErrInvalidParent):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrValidation):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, models.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, models.ErrMediaType):
+		return http.StatusUnsupportedMediaType
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	res, err := json.Marshal(v)
+
+	if err != nil {
+		logging.FromContext(r.Context()).ErrorContext(r.Context(), "encoding response", "error", err)
+		status = http.StatusInternalServerError
+		res, _ = json.Marshal(ErrorMsg{Message: http.StatusText(status)})
+	}
+
+	w.WriteHeader(status)
+
+	if _, err = w.Write(res); err != nil {
+		logging.FromContext(r.Context()).DebugContext(r.Context(), "writing response", "error", err)
+	}
+}
+
+// writeError maps err to a status code and writes it as an ErrorMsg.
+// Details of unexpected errors are logged rather than sent to the client.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	status := errorStatus(err)
+
+	msg := err.Error()
+	switch {
+	case status == http.StatusInternalServerError:
+		logging.FromContext(r.Context()).ErrorContext(r.Context(), "internal error", "error", err)
+		msg = http.StatusText(status)
+	case errors.Is(err, context.DeadlineExceeded):
+		msg = "request took too long"
+	case errors.Is(err, context.Canceled):
+		msg = "request canceled"
+	case status == http.StatusUnauthorized:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+	}
+
+	writeJSON(w, r, status, ErrorMsg{Message: msg})
+}
+
+func readJSON(r *http.Request, v interface{}) error {
+	reqBody, err := io.ReadAll(r.Body)
+
+	if err != nil {
+		return models.NewError(models.ErrBadRequest, "reading request body: %v", err)
+	}
+
+	if err = json.Unmarshal(reqBody, v); err != nil {
+		return models.NewError(models.ErrBadRequest, "malformed JSON: %v", err)
+	}
+
+	return nil
+}
+
+func parseLimit(limitStr string, limits config.Pagination) (int, error) {
+	if limitStr == "" {
+		return limits.DefaultLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit <= 0 {
+		return 0, models.NewError(models.ErrBadRequest, "invalid limit %q", limitStr)
+	}
+
+	if limit > limits.MaxLimit {
+		limit = limits.MaxLimit
+	}
+	return limit, nil
+}
+
+// parseRender tells whether the request asks for rendered messages with
+// render=html.
+func parseRender(r *http.Request) (bool, error) {
+	switch render := r.URL.Query().Get("render"); render {
+	case "":
+		return false, nil
+	case "html":
+		return true, nil
+	default:
+		return false, models.NewError(models.ErrBadRequest, "render must be html, got %q", render)
+	}
+}
+
+// parseRevisions reads the from and to query parameters of a diff. Missing
+// ones are zero and left for the use case to default.
+func parseRevisions(r *http.Request) (from int, to int, err error) {
+	for _, param := range []struct {
+		name string
+		dest *int
+	}{{"from", &from}, {"to", &to}} {
+		value := r.URL.Query().Get(param.name)
+		if value == "" {
+			continue
+		}
+
+		*param.dest, err = strconv.Atoi(value)
+		if err != nil || *param.dest <= 0 {
+			return 0, 0, models.NewError(models.ErrBadRequest, "invalid %s %q", param.name, value)
+		}
+	}
+	return from, to, nil
 }
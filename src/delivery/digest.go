@@ -0,0 +1,105 @@
+package delivery
+
+import (
+	"html/template"
+	"net/http"
+	"strings"
+	"techno-forum/src/models"
+	"techno-forum/src/usecase"
+
+	"github.com/go-chi/chi"
+)
+
+// unsubscribePage is what the link in the email opens. Following a link
+// must not change anything, mail scanners follow them all, so it only asks
+// to confirm with a POST to the same URL.
+var unsubscribePage = template.Must(template.New("unsubscribe").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><meta name="robots" content="noindex"><title>Unsubscribe</title></head>
+<body>
+{{if .Done}}<p>You won't get email digests anymore.</p>
+{{else}}<form method="post">
+<p>Stop getting email digests?</p>
+<input type="hidden" name="List-Unsubscribe" value="One-Click">
+<button type="submit">Unsubscribe</button>
+</form>
+{{end}}</body>
+</html>
+`))
+
+func writeUnsubscribePage(w http.ResponseWriter, done bool) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.WriteHeader(http.StatusOK)
+	unsubscribePage.Execute(w, struct{ Done bool }{done})
+}
+
+type DigestDelivery struct {
+	usecase *usecase.DigestUseCase
+}
+
+func NewDigestDelivery(usecase *usecase.DigestUseCase) *DigestDelivery {
+	return &DigestDelivery{
+		usecase: usecase,
+	}
+}
+
+func (delivery *DigestDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	settings, err := delivery.usecase.GetSettings(r.Context(), chi.URLParam(r, "nickname"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, settings)
+}
+
+func (delivery *DigestDelivery) Set(w http.ResponseWriter, r *http.Request) {
+	var req models.DigestSettingsRequest
+
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	settings, err := delivery.usecase.SetSettings(r.Context(), chi.URLParam(r, "nickname"), &req)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, settings)
+}
+
+// ConfirmUnsubscribe serves the link in the email, which leaves the
+// settings alone and asks to confirm.
+func (delivery *DigestDelivery) ConfirmUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("token") == "" {
+		writeError(w, r, models.NewError(models.ErrBadRequest, "token is required"))
+		return
+	}
+
+	writeUnsubscribePage(w, false)
+}
+
+// Unsubscribe serves both the confirmation form and the one-click POST mail
+// clients send for List-Unsubscribe-Post, whose body is ignored. Browsers
+// get a page back, anything else the settings.
+func (delivery *DigestDelivery) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	settings, err := delivery.usecase.Unsubscribe(r.Context(), r.URL.Query().Get("token"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/html") {
+		writeUnsubscribePage(w, true)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, settings)
+}
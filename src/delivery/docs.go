@@ -0,0 +1,42 @@
+package delivery
+
+import (
+	"encoding/json"
+	"net/http"
+	"techno-forum/src/openapi"
+
+	"github.com/swaggest/swgui/v5emb"
+)
+
+type DocsDelivery struct {
+	spec []byte
+	ui   http.Handler
+}
+
+// NewDocsDelivery serves the Swagger UI bundled into the binary under
+// basePath, pointed at the spec served from specPath.
+func NewDocsDelivery(title, specPath, basePath string) *DocsDelivery {
+	return &DocsDelivery{
+		ui: v5emb.New(title, specPath, basePath),
+	}
+}
+
+func (delivery *DocsDelivery) SetSpec(doc *openapi.Document) error {
+	spec, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	delivery.spec = spec
+	return nil
+}
+
+func (delivery *DocsDelivery) Spec(w http.ResponseWriter, r *http.Request) {
+	w.Write(delivery.spec)
+}
+
+func (delivery *DocsDelivery) UI(w http.ResponseWriter, r *http.Request) {
+	// Let the UI handler pick the type of its assets.
+	w.Header().Del("Content-Type")
+	delivery.ui.ServeHTTP(w, r)
+}
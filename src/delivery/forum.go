@@ -1,11 +1,9 @@
 package delivery
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"log"
+	"errors"
 	"net/http"
+	"techno-forum/src/auth"
 	"techno-forum/src/models"
 	"techno-forum/src/usecase"
 
@@ -25,98 +23,69 @@ func NewForumDelivery(usecase *usecase.ForumUseCase) *ForumDelivery {
 func (delivery *ForumDelivery) Create(w http.ResponseWriter, r *http.Request) {
 	var forum models.Forum
 
-	reqBody, err := io.ReadAll(r.Body)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := readJSON(r, &forum); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	fmt.Println(string(reqBody))
-
-	err = json.Unmarshal(reqBody, &forum)
-
-	fmt.Println(forum)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
-	}
+	forum.Author = auth.Nickname(r.Context(), forum.Author)
 
-	err = delivery.usecase.Create(&forum)
+	err := delivery.usecase.Create(r.Context(), &forum)
 
 	if err == nil {
-		res, err := json.Marshal(forum)
-
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		w.WriteHeader(201)
-		status, err := w.Write(res)
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+		writeJSON(w, r, http.StatusCreated, forum)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("user not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if errors.Is(err, models.ErrAlreadyExists) {
+		writeJSON(w, r, http.StatusConflict, forum)
 		return
 	}
 
-	if err == models.ErrAlreadyExists {
-		w.WriteHeader(409)
-		res, err := json.Marshal(forum)
-
-		if err != nil {
-			log.Fatal(err)
-		}
+	writeError(w, r, err)
+}
 
-		status, err := w.Write(res)
+func (delivery *ForumDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	forum, err := delivery.usecase.Get(r.Context(), slug)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Fatal(err)
+	writeJSON(w, r, http.StatusOK, forum)
 }
 
-func (delivery *ForumDelivery) Get(w http.ResponseWriter, r *http.Request) {
-	slug := chi.URLParam(r, "slug")
-	forum, err := delivery.usecase.Get(slug)
+func (delivery *ForumDelivery) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, err := delivery.usecase.GetSubscription(r.Context(), chi.URLParam(r, "slug"), r.URL.Query().Get("nickname"))
 
-	if err == nil {
-		res, err := json.Marshal(forum)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	writeJSON(w, r, http.StatusOK, sub)
+}
 
-		status, err := w.Write(res)
+func (delivery *ForumDelivery) Subscribe(w http.ResponseWriter, r *http.Request) {
+	sub, err := delivery.usecase.Subscribe(r.Context(), chi.URLParam(r, "slug"), r.URL.Query().Get("nickname"))
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("forum not found")))
+	writeJSON(w, r, http.StatusOK, sub)
+}
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+func (delivery *ForumDelivery) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	err := delivery.usecase.Unsubscribe(r.Context(), chi.URLParam(r, "slug"), r.URL.Query().Get("nickname"))
+
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
-	log.Fatal(err)
+
+	w.WriteHeader(http.StatusNoContent)
 }
@@ -0,0 +1,37 @@
+package delivery
+
+import (
+	"net/http"
+	"techno-forum/src/health"
+	"techno-forum/src/models"
+)
+
+type HealthDelivery struct {
+	checker *health.Checker
+}
+
+func NewHealthDelivery(checker *health.Checker) *HealthDelivery {
+	return &HealthDelivery{
+		checker: checker,
+	}
+}
+
+// Live answers as long as the process is able to serve HTTP at all.
+func (delivery *HealthDelivery) Live(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, models.HealthCheck{Status: models.HealthOK})
+}
+
+func (delivery *HealthDelivery) Ready(w http.ResponseWriter, r *http.Request) {
+	res := delivery.checker.Readiness(r.Context())
+
+	status := http.StatusOK
+	if res.Status != models.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, r, status, res)
+}
+
+func (delivery *HealthDelivery) Report(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, r, http.StatusOK, delivery.checker.Report(r.Context()))
+}
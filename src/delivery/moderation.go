@@ -0,0 +1,166 @@
+package delivery
+
+import (
+	"net/http"
+	"techno-forum/src/models"
+	"techno-forum/src/usecase"
+
+	"github.com/go-chi/chi"
+)
+
+type ModerationDelivery struct {
+	usecase *usecase.ModerationUseCase
+}
+
+func NewModerationDelivery(usecase *usecase.ModerationUseCase) *ModerationDelivery {
+	return &ModerationDelivery{
+		usecase: usecase,
+	}
+}
+
+func (delivery *ModerationDelivery) GetModerators(w http.ResponseWriter, r *http.Request) {
+	users, err := delivery.usecase.GetModerators(r.Context(), chi.URLParam(r, "slug"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, users)
+}
+
+func (delivery *ModerationDelivery) AddModerator(w http.ResponseWriter, r *http.Request) {
+	user, err := delivery.usecase.AddModerator(r.Context(), chi.URLParam(r, "slug"), chi.URLParam(r, "nickname"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, user)
+}
+
+func (delivery *ModerationDelivery) RemoveModerator(w http.ResponseWriter, r *http.Request) {
+	err := delivery.usecase.RemoveModerator(r.Context(), chi.URLParam(r, "slug"), chi.URLParam(r, "nickname"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (delivery *ModerationDelivery) lock(w http.ResponseWriter, r *http.Request, locked bool) {
+	thread, err := delivery.usecase.SetLocked(r.Context(), chi.URLParam(r, "slugOrId"), locked)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, thread)
+}
+
+func (delivery *ModerationDelivery) Lock(w http.ResponseWriter, r *http.Request) {
+	delivery.lock(w, r, true)
+}
+
+func (delivery *ModerationDelivery) Unlock(w http.ResponseWriter, r *http.Request) {
+	delivery.lock(w, r, false)
+}
+
+func (delivery *ModerationDelivery) hide(w http.ResponseWriter, r *http.Request, hidden bool) {
+	id, err := parsePostId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	post, err := delivery.usecase.SetHidden(r.Context(), id, hidden)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, post)
+}
+
+func (delivery *ModerationDelivery) Hide(w http.ResponseWriter, r *http.Request) {
+	delivery.hide(w, r, true)
+}
+
+func (delivery *ModerationDelivery) Unhide(w http.ResponseWriter, r *http.Request) {
+	delivery.hide(w, r, false)
+}
+
+func (delivery *ModerationDelivery) GetBans(w http.ResponseWriter, r *http.Request) {
+	bans, err := delivery.usecase.GetBans(r.Context(), chi.URLParam(r, "slug"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, bans)
+}
+
+func (delivery *ModerationDelivery) Ban(w http.ResponseWriter, r *http.Request) {
+	var req models.BanRequest
+
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	ban, err := delivery.usecase.Ban(r.Context(), chi.URLParam(r, "slug"), chi.URLParam(r, "nickname"), &req)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, ban)
+}
+
+func (delivery *ModerationDelivery) Unban(w http.ResponseWriter, r *http.Request) {
+	err := delivery.usecase.Unban(r.Context(), chi.URLParam(r, "slug"), chi.URLParam(r, "nickname"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (delivery *ModerationDelivery) RestoreThread(w http.ResponseWriter, r *http.Request) {
+	thread, err := delivery.usecase.RestoreThread(r.Context(), chi.URLParam(r, "slugOrId"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, thread)
+}
+
+func (delivery *ModerationDelivery) RestorePost(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePostId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	post, err := delivery.usecase.RestorePost(r.Context(), id)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, post)
+}
@@ -0,0 +1,97 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"techno-forum/src/config"
+	"techno-forum/src/models"
+	"techno-forum/src/usecase"
+
+	"github.com/go-chi/chi"
+)
+
+type NotificationDelivery struct {
+	usecase *usecase.NotificationUseCase
+	limits  config.Pagination
+}
+
+func NewNotificationDelivery(usecase *usecase.NotificationUseCase, limits config.Pagination) *NotificationDelivery {
+	return &NotificationDelivery{
+		usecase: usecase,
+		limits:  limits,
+	}
+}
+
+func (delivery *NotificationDelivery) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := &models.NotificationListParams{
+		Unread: query.Get("unread") == "true",
+	}
+
+	var err error
+	params.Limit, err = parseLimit(query.Get("limit"), delivery.limits)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if before := query.Get("before"); before != "" {
+		params.Before, err = strconv.ParseInt(before, 10, 64)
+
+		if err != nil || params.Before <= 0 {
+			writeError(w, r, models.NewError(models.ErrBadRequest, "invalid before %q", before))
+			return
+		}
+	}
+
+	notifications, err := delivery.usecase.List(r.Context(), chi.URLParam(r, "nickname"), params)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, notifications)
+}
+
+func (delivery *NotificationDelivery) MarkRead(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+
+	if err != nil {
+		writeError(w, r, models.NewError(models.ErrBadRequest, "invalid notification id %q", idStr))
+		return
+	}
+
+	notification, err := delivery.usecase.MarkRead(r.Context(), chi.URLParam(r, "nickname"), id)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, notification)
+}
+
+func (delivery *NotificationDelivery) MarkAllRead(w http.ResponseWriter, r *http.Request) {
+	var req models.NotificationsReadRequest
+
+	// The body is optional: without one every notification is marked.
+	if r.ContentLength != 0 {
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+
+	res, err := delivery.usecase.MarkAllRead(r.Context(), chi.URLParam(r, "nickname"), &req)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, res)
+}
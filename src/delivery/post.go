@@ -1,13 +1,12 @@
 package delivery
 
 import (
-	"encoding/json"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
+	"techno-forum/src/auth"
+	"techno-forum/src/config"
 	"techno-forum/src/models"
 	"techno-forum/src/repository"
 	"techno-forum/src/usecase"
@@ -19,134 +18,106 @@ type PostDelivery struct {
 	posts   *usecase.PostUseCase
 	threads *usecase.ThreadUseCase
 	forums  *usecase.ForumUseCase
-	users   *repository.UserRepository
+	users   repository.UserRepository
+	limits  config.Pagination
 }
 
 func NewPostDelivery(posts *usecase.PostUseCase,
 	threads *usecase.ThreadUseCase,
 	forums *usecase.ForumUseCase,
-	users *repository.UserRepository) *PostDelivery {
+	users repository.UserRepository,
+	limits config.Pagination) *PostDelivery {
 	return &PostDelivery{
 		posts:   posts,
 		threads: threads,
 		forums:  forums,
 		users:   users,
+		limits:  limits,
 	}
 }
 
+func parsePostId(r *http.Request) (int64, error) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+
+	if err != nil {
+		return 0, models.NewError(models.ErrBadRequest, "invalid post id %q", idStr)
+	}
+	return id, nil
+}
+
 func (delivery *PostDelivery) Create(w http.ResponseWriter, r *http.Request) {
 	var posts []*models.Post
 
-	reqBody, err := io.ReadAll(r.Body)
+	render, err := parseRender(r)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	err = json.Unmarshal(reqBody, &posts)
-
-	if err != nil {
-		log.Fatal(err)
+	if err = readJSON(r, &posts); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	slugOrId := chi.URLParam(r, "slugOrId")
 
-	thread, err := delivery.threads.Get(slugOrId)
-
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("thread not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
-		return
-	}
+	thread, err := delivery.threads.Get(r.Context(), slugOrId)
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
 	if len(posts) == 0 {
-		w.WriteHeader(201)
-		status, err := w.Write([]byte("[]"))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+		writeJSON(w, r, http.StatusCreated, []*models.Post{})
 		return
 	}
 
-	err = delivery.posts.AddPosts(thread, posts)
-
-	if err == nil {
-		res, err := json.Marshal(posts)
-
-		if err != nil {
-			log.Fatal(err)
-		}
+	for _, post := range posts {
+		post.Author = auth.Nickname(r.Context(), post.Author)
+	}
 
-		w.WriteHeader(201)
-		status, err := w.Write(res)
+	err = delivery.posts.AddPosts(r.Context(), thread, posts)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err = delivery.posts.RenderHTML(r.Context(), render, posts...); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrAlreadyExists {
-		w.WriteHeader(409)
-		status, err := w.Write([]byte(MakeErrorMsg("conflict")))
+	writeJSON(w, r, http.StatusCreated, posts)
+}
+
+func (delivery *PostDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePostId(r)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrInvalidParent || err == models.ErrNoParent {
-		w.WriteHeader(409)
-		status, err := w.Write([]byte(MakeErrorMsg("conflict")))
+	render, err := parseRender(r)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Fatal(err)
-}
-
-func (delivery *PostDelivery) Get(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	post, err := delivery.posts.GetPost(r.Context(), id)
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
-	post, err := delivery.posts.GetPost(id)
-
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("post not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err = delivery.posts.RenderHTML(r.Context(), render, post); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
@@ -159,106 +130,72 @@ func (delivery *PostDelivery) Get(w http.ResponseWriter, r *http.Request) {
 	for _, el := range related {
 		switch el {
 		case "user":
-			user, err := delivery.users.GetByNickName(post.Author)
-			if err != nil {
-				// log.Fatal(err)
-			}
-			fullPost.Author = user
+			fullPost.Author, err = delivery.users.GetByNickName(r.Context(), post.Author)
 		case "forum":
-			forum, err := delivery.forums.Get(post.Forum)
-			if err != nil {
-				// log.Fatal(err)
-			}
-			fullPost.Forum = forum
+			fullPost.Forum, err = delivery.forums.Get(r.Context(), post.Forum)
 		case "thread":
-			thread, err := delivery.threads.ThreadRepo.GetById(fmt.Sprint(post.Thread))
-			if err != nil {
-				// log.Fatal(err)
+			fullPost.Thread, err = delivery.threads.Get(r.Context(), fmt.Sprint(post.Thread))
+			if err == nil {
+				err = delivery.threads.RenderHTML(r.Context(), render, fullPost.Thread)
 			}
-			fullPost.Thread = thread
 		}
-	}
-
-	res, err := json.Marshal(fullPost)
 
-	if err != nil {
-		log.Fatal(err)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
 	}
 
-	w.WriteHeader(200)
-	status, err := w.Write(res)
-
-	if err != nil {
-		log.Fatal(status, err)
-	}
+	writeJSON(w, r, http.StatusOK, fullPost)
 }
 
 func (delivery *PostDelivery) Update(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := strconv.ParseInt(idStr, 10, 64)
+	id, err := parsePostId(r)
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
-	reqBody, err := io.ReadAll(r.Body)
+	render, err := parseRender(r)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
 	var post models.Post
 
-	err = json.Unmarshal(reqBody, &post)
-	post.Id = id
-
-	err = delivery.posts.Update(&post)
-
-	if err == nil {
-		res, err := json.Marshal(post)
+	if err = readJSON(r, &post); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	post.Id = id
 
-		w.WriteHeader(200)
-		status, err := w.Write(res)
+	err = delivery.posts.Update(r.Context(), &post)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("post not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err = delivery.posts.RenderHTML(r.Context(), render, &post); err != nil {
+		writeError(w, r, err)
 		return
 	}
+
+	writeJSON(w, r, http.StatusOK, post)
 }
 
 func (delivery *PostDelivery) GetByThread(w http.ResponseWriter, r *http.Request) {
 	slugOrId := chi.URLParam(r, "slugOrId")
 
-	thread, err := delivery.threads.Get(slugOrId)
-
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("thread not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
-		return
-	}
+	thread, err := delivery.threads.Get(r.Context(), slugOrId)
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
 	var params models.PostListParams
@@ -268,32 +205,22 @@ func (delivery *PostDelivery) GetByThread(w http.ResponseWriter, r *http.Request
 	sort := r.URL.Query().Get("sort")
 	descStr := r.URL.Query().Get("desc")
 
-	var limit int
-	if limitStr == "" {
-		limit = 100
-	} else {
-		limit, err = strconv.Atoi(limitStr)
+	params.Limit, err = parseLimit(limitStr, delivery.limits)
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	params.Limit = limit
-
-	var since int
-	if sinceStr == "" {
-		since = 0
-	} else {
-		since, err = strconv.Atoi(sinceStr)
+	if sinceStr != "" {
+		params.Since, err = strconv.Atoi(sinceStr)
 
 		if err != nil {
-			log.Fatal(err)
+			writeError(w, r, models.NewError(models.ErrBadRequest, "invalid since %q", sinceStr))
+			return
 		}
 	}
 
-	params.Since = since
-
 	params.Desc = descStr == "true"
 
 	switch sort {
@@ -307,21 +234,83 @@ func (delivery *PostDelivery) GetByThread(w http.ResponseWriter, r *http.Request
 
 	params.ThreadId = thread.Id
 
-	posts, err := delivery.posts.GetPosts(thread, &params)
+	render, err := parseRender(r)
 
-	if err == nil {
-		res, err := json.Marshal(posts)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	posts, err := delivery.posts.GetPosts(r.Context(), thread, &params)
 
-		w.WriteHeader(200)
-		status, err := w.Write(res)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err = delivery.posts.RenderHTML(r.Context(), render, posts...); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, posts)
+}
+
+func (delivery *PostDelivery) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePostId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err = delivery.posts.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (delivery *PostDelivery) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePostId(r)
+
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
+
+	revs, err := delivery.posts.GetRevisions(r.Context(), id)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, revs)
+}
+
+func (delivery *PostDelivery) Diff(w http.ResponseWriter, r *http.Request) {
+	id, err := parsePostId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	from, to, err := parseRevisions(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	diff, err := delivery.posts.Diff(r.Context(), id, from, to)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, diff)
 }
@@ -0,0 +1,95 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"techno-forum/src/config"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/usecase"
+	"time"
+)
+
+type SearchDelivery struct {
+	usecase *usecase.SearchUseCase
+	limits  config.Pagination
+}
+
+func NewSearchDelivery(usecase *usecase.SearchUseCase, limits config.Pagination) *SearchDelivery {
+	return &SearchDelivery{
+		usecase: usecase,
+		limits:  limits,
+	}
+}
+
+func (delivery *SearchDelivery) Search(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	params := &models.SearchParams{
+		Query:  query.Get("q"),
+		Forum:  query.Get("forum"),
+		Author: query.Get("author"),
+		Type:   query.Get("type"),
+		Desc:   query.Get("desc") == "true",
+	}
+
+	var err error
+	params.Limit, err = parseLimit(query.Get("limit"), delivery.limits)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if since := query.Get("since"); since != "" {
+		params.Since, err = time.Parse(time.RFC3339, since)
+
+		if err != nil {
+			writeError(w, r, repository.InvalidTimestamp(since))
+			return
+		}
+		params.Since = params.Since.UTC()
+	}
+
+	if offset := query.Get("offset"); offset != "" {
+		params.Offset, err = strconv.Atoi(offset)
+
+		if err != nil || params.Offset < 0 {
+			writeError(w, r, models.NewError(models.ErrBadRequest, "invalid offset %q", offset))
+			return
+		}
+	}
+
+	switch params.Type {
+	case "", models.SearchPost, models.SearchThread:
+	default:
+		writeError(w, r, models.NewError(models.ErrBadRequest, "invalid type %q", params.Type))
+		return
+	}
+
+	switch sort := query.Get("sort"); sort {
+	case "", "rank":
+		params.Sort = models.SearchByRank
+	case "created":
+		params.Sort = models.SearchByCreated
+	default:
+		writeError(w, r, models.NewError(models.ErrBadRequest, "invalid sort %q", sort))
+		return
+	}
+
+	// The rank of a hit says nothing about its neighbours in time, so since
+	// can only page results in creation order.
+	if !params.Since.IsZero() && params.Sort != models.SearchByCreated {
+		writeError(w, r, models.NewError(models.ErrBadRequest, "since needs sort=created, page by rank with offset"))
+		return
+	}
+
+	hits, err := delivery.usecase.Search(r.Context(), params)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, hits)
+}
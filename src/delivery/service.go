@@ -1,49 +1,38 @@
 package delivery
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
 	"techno-forum/src/repository"
 )
 
 type ServiceDelivery struct {
-	repo *repository.ServiceRepository
+	repo repository.ServiceRepository
 }
 
-func NewServiceDelivery(repo *repository.ServiceRepository) *ServiceDelivery {
+func NewServiceDelivery(repo repository.ServiceRepository) *ServiceDelivery {
 	return &ServiceDelivery{
 		repo: repo,
 	}
 }
 
 func (delivery *ServiceDelivery) Status(w http.ResponseWriter, r *http.Request) {
-	info, err := delivery.repo.Status()
+	info, err := delivery.repo.Status(r.Context())
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
-	res, err := json.Marshal(info)
-
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	w.WriteHeader(200)
-	status, err := w.Write(res)
-
-	if err != nil {
-		log.Fatal(status, err)
-	}
+	writeJSON(w, r, http.StatusOK, info)
 }
 
 func (delivery *ServiceDelivery) Clear(w http.ResponseWriter, r *http.Request) {
-	err := delivery.repo.Clear()
+	err := delivery.repo.Clear(r.Context())
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
-	w.WriteHeader(200)
+	w.WriteHeader(http.StatusOK)
 }
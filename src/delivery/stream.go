@@ -0,0 +1,172 @@
+package delivery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"techno-forum/src/config"
+	"techno-forum/src/events"
+	"techno-forum/src/logging"
+	"techno-forum/src/models"
+	"techno-forum/src/usecase"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+type StreamDelivery struct {
+	posts     *usecase.PostUseCase
+	threads   *usecase.ThreadUseCase
+	bus       *events.Bus
+	heartbeat time.Duration
+	limits    config.Pagination
+}
+
+func NewStreamDelivery(posts *usecase.PostUseCase, threads *usecase.ThreadUseCase, bus *events.Bus,
+	heartbeat time.Duration, limits config.Pagination) *StreamDelivery {
+	return &StreamDelivery{
+		posts:     posts,
+		threads:   threads,
+		bus:       bus,
+		heartbeat: heartbeat,
+		limits:    limits,
+	}
+}
+
+// writeEvent writes one Server-Sent Event. Only new posts carry an id, so
+// that Last-Event-ID always names the last post the client has seen.
+func writeEvent(w io.Writer, id int64, event string, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if id != 0 {
+		if _, err = fmt.Fprintf(w, "id: %d\n", id); err != nil {
+			return err
+		}
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+	return err
+}
+
+// Stream sends the changes of a thread as Server-Sent Events. A client
+// resuming with Last-Event-ID first gets the posts it missed.
+func (delivery *StreamDelivery) Stream(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	thread, err := delivery.threads.Get(ctx, chi.URLParam(r, "slugOrId"))
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	lastIdStr := r.Header.Get("Last-Event-ID")
+	if lastIdStr == "" {
+		lastIdStr = r.URL.Query().Get("lastEventId")
+	}
+
+	var lastId int64
+	if lastIdStr != "" {
+		lastId, err = strconv.ParseInt(lastIdStr, 10, 64)
+
+		if err != nil || lastId < 0 {
+			writeError(w, r, models.NewError(models.ErrBadRequest, "invalid Last-Event-ID %q", lastIdStr))
+			return
+		}
+	}
+
+	// Subscribe before catching up so that nothing falls in between.
+	sub := delivery.bus.Subscribe(thread.Id)
+	defer sub.Close()
+
+	// The server timeouts are meant for ordinary requests.
+	rc := http.NewResponseController(w)
+	rc.SetReadDeadline(time.Time{})
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	log := logging.FromContext(ctx)
+
+	if lastId != 0 {
+		params := &models.PostListParams{
+			ThreadId: thread.Id,
+			Limit:    delivery.limits.MaxLimit,
+			Sort:     models.SortFlat,
+		}
+
+		for {
+			params.Since = int(lastId)
+			posts, err := delivery.posts.GetPosts(ctx, thread, params)
+			if err != nil {
+				log.WarnContext(ctx, "replaying thread stream", "thread", thread.Id, "error", err)
+				return
+			}
+
+			for _, post := range posts {
+				if err = writeEvent(w, post.Id, events.PostCreated, post); err != nil {
+					return
+				}
+				lastId = post.Id
+			}
+
+			if len(posts) < params.Limit {
+				break
+			}
+		}
+	}
+
+	if err = rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(delivery.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err = io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+
+		case event, ok := <-sub.C:
+			if !ok {
+				// Dropped for lagging behind or shutting down. The client
+				// reconnects with Last-Event-ID and catches up.
+				return
+			}
+
+			switch event.Type {
+			case events.PostCreated:
+				if event.Post.Id <= lastId {
+					continue
+				}
+				err = writeEvent(w, event.Post.Id, event.Type, event.Post)
+				lastId = event.Post.Id
+			case events.PostUpdated:
+				err = writeEvent(w, 0, event.Type, event.Post)
+			case events.ThreadUpdated, events.ThreadVoted:
+				err = writeEvent(w, 0, event.Type, event.Thread)
+			}
+
+			if err != nil {
+				return
+			}
+		}
+
+		if err = rc.Flush(); err != nil {
+			return
+		}
+	}
+}
@@ -1,12 +1,10 @@
 package delivery
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"log"
+	"errors"
 	"net/http"
-	"strconv"
+	"techno-forum/src/auth"
+	"techno-forum/src/config"
 	"techno-forum/src/models"
 	"techno-forum/src/usecase"
 
@@ -15,215 +13,227 @@ import (
 
 type ThreadDelivery struct {
 	usecase *usecase.ThreadUseCase
+	limits  config.Pagination
 }
 
-func NewThreadDelivery(usecase *usecase.ThreadUseCase) *ThreadDelivery {
+func NewThreadDelivery(usecase *usecase.ThreadUseCase, limits config.Pagination) *ThreadDelivery {
 	return &ThreadDelivery{
 		usecase: usecase,
+		limits:  limits,
 	}
 }
 
 func (delivery *ThreadDelivery) Create(w http.ResponseWriter, r *http.Request) {
 	var thread models.Thread
 
-	reqBody, err := io.ReadAll(r.Body)
+	render, err := parseRender(r)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	err = json.Unmarshal(reqBody, &thread)
-
-	if err != nil {
-		log.Fatal(err)
+	if err = readJSON(r, &thread); err != nil {
+		writeError(w, r, err)
+		return
 	}
 
 	forumSlug := chi.URLParam(r, "slug")
+	thread.Author = auth.Nickname(r.Context(), thread.Author)
 
-	err = delivery.usecase.Create(&thread, forumSlug)
-
-	if err == nil {
-		res, err := json.Marshal(thread)
-
-		if err != nil {
-			log.Fatal(err)
-		}
+	err = delivery.usecase.Create(r.Context(), &thread, forumSlug)
 
-		w.WriteHeader(201)
-		status, err := w.Write(res)
+	status := http.StatusCreated
+	if errors.Is(err, models.ErrAlreadyExists) {
+		status = http.StatusConflict
+	} else if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err = delivery.usecase.RenderHTML(r.Context(), render, &thread); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("not found")))
+	writeJSON(w, r, status, thread)
+}
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+func (delivery *ThreadDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	slugOrId := chi.URLParam(r, "slugOrId")
+
+	render, err := parseRender(r)
+
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrAlreadyExists {
-		w.WriteHeader(409)
-		res, err := json.Marshal(thread)
+	thread, err := delivery.usecase.Get(r.Context(), slugOrId)
 
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		w.WriteHeader(201)
-		status, err := w.Write(res)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err = delivery.usecase.RenderHTML(r.Context(), render, thread); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Fatal(err)
+	writeJSON(w, r, http.StatusOK, thread)
 }
 
-func (delivery *ThreadDelivery) Get(w http.ResponseWriter, r *http.Request) {
-	SlugOrId := chi.URLParam(r, "slugOrId")
+func (delivery *ThreadDelivery) GetByForum(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
 
-	thread, err := delivery.usecase.Get(SlugOrId)
+	since := r.URL.Query().Get("since")
+	descStr := r.URL.Query().Get("desc")
+	limitStr := r.URL.Query().Get("limit")
 
-	if err == nil {
-		res, err := json.Marshal(thread)
+	limit, err := parseLimit(limitStr, delivery.limits)
 
-		fmt.Println("thread:", thread)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	desc := descStr != "" && descStr != "false"
 
-		w.WriteHeader(200)
-		status, err := w.Write(res)
+	render, err := parseRender(r)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("thread not found")))
+	threads, err := delivery.usecase.GetByForum(r.Context(), slug, since, desc, limit)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Fatal(err)
+	if err = delivery.usecase.RenderHTML(r.Context(), render, threads...); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, threads)
 }
 
-func (delivery *ThreadDelivery) GetByForum(w http.ResponseWriter, r *http.Request) {
-	slug := chi.URLParam(r, "slug")
+func (delivery *ThreadDelivery) Update(w http.ResponseWriter, r *http.Request) {
+	var thread models.Thread
 
-	since := r.URL.Query().Get("since")
-	descStr := r.URL.Query().Get("desc")
-	limitStr := r.URL.Query().Get("limit")
+	render, err := parseRender(r)
 
-	var limit int
-	if limitStr == "" {
-		limit = 100
-	} else {
-		var err error
-		limit, err = strconv.Atoi(limitStr)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	if err = readJSON(r, &thread); err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	desc := descStr != "" && descStr != "false"
+	slugOrId := chi.URLParam(r, "slugOrId")
 
-	thread, err := delivery.usecase.GetByForum(slug, since, desc, limit)
+	err = delivery.usecase.Update(r.Context(), &thread, slugOrId)
 
-	if err == nil {
-		res, err := json.Marshal(thread)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	if err = delivery.usecase.RenderHTML(r.Context(), render, &thread); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		w.WriteHeader(200)
-		status, err := w.Write(res)
+	writeJSON(w, r, http.StatusOK, thread)
+}
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+func (delivery *ThreadDelivery) Delete(w http.ResponseWriter, r *http.Request) {
+	err := delivery.usecase.Delete(r.Context(), chi.URLParam(r, "slugOrId"))
+
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("no threads found")))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (delivery *ThreadDelivery) GetRevisions(w http.ResponseWriter, r *http.Request) {
+	slugOrId := chi.URLParam(r, "slugOrId")
+
+	revs, err := delivery.usecase.GetRevisions(r.Context(), slugOrId)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Fatal(err)
+	writeJSON(w, r, http.StatusOK, revs)
 }
 
-func (delivery *ThreadDelivery) Update(w http.ResponseWriter, r *http.Request) {
-	var thread models.Thread
+func (delivery *ThreadDelivery) Diff(w http.ResponseWriter, r *http.Request) {
+	slugOrId := chi.URLParam(r, "slugOrId")
 
-	reqBody, err := io.ReadAll(r.Body)
+	from, to, err := parseRevisions(r)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	fmt.Println(string(reqBody))
+	diff, err := delivery.usecase.Diff(r.Context(), slugOrId, from, to)
 
-	err = json.Unmarshal(reqBody, &thread)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	fmt.Println(thread)
+	writeJSON(w, r, http.StatusOK, diff)
+}
 
-	slugOrId := chi.URLParam(r, "slugOrId")
+func (delivery *ThreadDelivery) GetSubscription(w http.ResponseWriter, r *http.Request) {
+	sub, err := delivery.usecase.GetSubscription(r.Context(), chi.URLParam(r, "slugOrId"), r.URL.Query().Get("nickname"))
 
-	err = delivery.usecase.Update(&thread, slugOrId)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	fmt.Println("THREAD", thread, err)
+	writeJSON(w, r, http.StatusOK, sub)
+}
 
-	if err == nil {
-		res, err := json.Marshal(thread)
+func (delivery *ThreadDelivery) Subscribe(w http.ResponseWriter, r *http.Request) {
+	var req models.SubscriptionRequest
 
-		if err != nil {
-			log.Fatal(err)
-		}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-		w.WriteHeader(200)
-		status, err := w.Write(res)
+	sub, err := delivery.usecase.Subscribe(r.Context(), chi.URLParam(r, "slugOrId"), &req)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("thread not found")))
+	writeJSON(w, r, http.StatusOK, sub)
+}
+
+func (delivery *ThreadDelivery) Unsubscribe(w http.ResponseWriter, r *http.Request) {
+	err := delivery.usecase.Unsubscribe(r.Context(), chi.URLParam(r, "slugOrId"), r.URL.Query().Get("nickname"))
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	log.Fatal(err)
+	w.WriteHeader(http.StatusNoContent)
 }
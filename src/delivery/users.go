@@ -1,235 +1,157 @@
 package delivery
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
-	"log"
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/go-chi/chi"
 
+	"techno-forum/src/config"
 	"techno-forum/src/models"
 	"techno-forum/src/repository"
+	"techno-forum/src/usecase"
 )
 
 type UserDelivery struct {
-	repo      *repository.UserRepository
-	ForumRepo *repository.ForumRepository
+	repo      repository.UserRepository
+	ForumRepo repository.ForumRepository
+	auth      *usecase.AuthUseCase
+	users     *usecase.UserUseCase
+	limits    config.Pagination
 }
 
-func NewUserDelivery(repo *repository.UserRepository, ForumRepo *repository.ForumRepository) *UserDelivery {
+func NewUserDelivery(repo repository.UserRepository, ForumRepo repository.ForumRepository, auth *usecase.AuthUseCase,
+	users *usecase.UserUseCase, limits config.Pagination) *UserDelivery {
 	return &UserDelivery{
 		repo:      repo,
 		ForumRepo: ForumRepo,
+		auth:      auth,
+		users:     users,
+		limits:    limits,
 	}
 }
 
 func (delivery *UserDelivery) Create(w http.ResponseWriter, r *http.Request) {
 	var p models.User
 
-	reqBody, err := io.ReadAll(r.Body)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := readJSON(r, &p); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	err = json.Unmarshal(reqBody, &p)
+	p.Nickname = chi.URLParam(r, "nickname")
 
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if delivery.auth.Required() && p.Password == "" {
+		writeError(w, r, models.NewError(models.ErrValidation, "password is required"))
 		return
 	}
 
-	p.Nickname = chi.URLParam(r, "nickname")
+	if err := delivery.auth.SetPassword(&p); err != nil {
+		writeError(w, r, err)
+		return
+	}
 
-	users, err := delivery.repo.Create(&p)
+	users, err := delivery.repo.Create(r.Context(), &p)
 
 	if err == nil {
-		res, err := json.Marshal(p)
-
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		w.WriteHeader(201)
-		status, err := w.Write(res)
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+		writeJSON(w, r, http.StatusCreated, p)
 		return
 	}
 
-	if users != nil {
-		res, err := json.Marshal(users)
-
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		w.WriteHeader(409)
-		status, err := w.Write(res)
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if errors.Is(err, models.ErrAlreadyExists) && users != nil {
+		writeJSON(w, r, http.StatusConflict, users)
 		return
 	}
 
-	log.Fatal(err)
+	writeError(w, r, err)
 }
 
 func (delivery *UserDelivery) GetByNickName(w http.ResponseWriter, r *http.Request) {
 	nickname := chi.URLParam(r, "nickname")
 
-	user, err := delivery.repo.GetByNickName(nickname)
+	user, err := delivery.repo.GetByNickName(r.Context(), nickname)
 
-	if err == nil {
-		res, err := json.Marshal(user)
-
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		w.WriteHeader(200)
-		status, err := w.Write(res)
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("user not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
-		return
-	}
+	writeJSON(w, r, http.StatusOK, user)
 }
 
 func (delivery *UserDelivery) GetByForum(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
-	forum, err := delivery.ForumRepo.Get(slug)
-
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("forum not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
-		return
-	}
+	forum, err := delivery.ForumRepo.Get(r.Context(), slug)
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
 	limitStr := r.URL.Query().Get("limit")
 	since := r.URL.Query().Get("since")
 	descStr := r.URL.Query().Get("desc")
 
-	var limit int
-
-	if limitStr == "" {
-		limit = 100
-	} else {
-		limit, err = strconv.Atoi(limitStr)
-	}
+	limit, err := parseLimit(limitStr, delivery.limits)
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
 	desc := descStr == "true"
 
-	users, err := delivery.repo.GetByForum(forum.Id, limit, since, desc)
+	users, err := delivery.repo.GetByForum(r.Context(), forum.Id, limit, since, desc)
 
 	if err != nil {
-		log.Fatal(err)
-	}
-
-	res, err := json.Marshal(users)
-
-	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
-	w.WriteHeader(200)
-	status, err := w.Write(res)
-
-	if err != nil {
-		log.Fatal(status, err)
-	}
-	return
+	writeJSON(w, r, http.StatusOK, users)
 }
 
 func (delivery *UserDelivery) Update(w http.ResponseWriter, r *http.Request) {
-	fmt.Println("update")
 	var p models.User
 
-	reqBody, err := io.ReadAll(r.Body)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := readJSON(r, &p); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	fmt.Println(string(reqBody))
-
-	err = json.Unmarshal(reqBody, &p)
+	p.Nickname = chi.URLParam(r, "nickname")
 
-	fmt.Println(p)
+	err := delivery.users.Update(r.Context(), &p)
 
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		writeError(w, r, err)
 		return
 	}
 
-	p.Nickname = chi.URLParam(r, "nickname")
-
-	err = delivery.repo.Update(&p)
-
-	if err == nil {
-		res, err := json.Marshal(p)
-
-		if err != nil {
-			log.Fatal(err)
-		}
+	writeJSON(w, r, http.StatusOK, p)
+}
 
-		w.WriteHeader(200)
-		status, err := w.Write(res)
+func (delivery *UserDelivery) SetRole(w http.ResponseWriter, r *http.Request) {
+	var req models.RoleRequest
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("user not found")))
+	nickname := chi.URLParam(r, "nickname")
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err := delivery.auth.SetRole(r.Context(), nickname, req.Role); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	if err == models.ErrAlreadyExists {
-		w.WriteHeader(409)
-		status, err := w.Write([]byte(MakeErrorMsg("such user already exists")))
+	user, err := delivery.repo.GetByNickName(r.Context(), nickname)
 
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
+
+	writeJSON(w, r, http.StatusOK, models.Account{User: user, Role: user.Role})
 }
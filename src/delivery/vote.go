@@ -1,10 +1,8 @@
 package delivery
 
 import (
-	"encoding/json"
-	"io"
-	"log"
 	"net/http"
+	"techno-forum/src/auth"
 	"techno-forum/src/models"
 	"techno-forum/src/repository"
 	"techno-forum/src/usecase"
@@ -13,16 +11,13 @@ import (
 )
 
 type VoteDelivery struct {
-	VoteRepo      *repository.VoteRepository
-	UserRepo      *repository.UserRepository
+	UserRepo      repository.UserRepository
 	ThreadUseCase *usecase.ThreadUseCase
 }
 
-func NewVoteDelivery(VoteRepo *repository.VoteRepository,
-	UserRepo *repository.UserRepository,
+func NewVoteDelivery(UserRepo repository.UserRepository,
 	ThreadUseCase *usecase.ThreadUseCase) *VoteDelivery {
 	return &VoteDelivery{
-		VoteRepo:      VoteRepo,
 		UserRepo:      UserRepo,
 		ThreadUseCase: ThreadUseCase,
 	}
@@ -31,80 +26,43 @@ func NewVoteDelivery(VoteRepo *repository.VoteRepository,
 func (delivery *VoteDelivery) Vote(w http.ResponseWriter, r *http.Request) {
 	slugOrId := chi.URLParam(r, "slugOrId")
 
-	thread, err := delivery.ThreadUseCase.Get(slugOrId)
+	thread, err := delivery.ThreadUseCase.Get(r.Context(), slugOrId)
 
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("thread not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 
 	var voteRequest models.VoteRequest
 
-	reqBody, err := io.ReadAll(r.Body)
-
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err = readJSON(r, &voteRequest); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	err = json.Unmarshal(reqBody, &voteRequest)
-
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	user, err := delivery.UserRepo.GetByNickName(voteRequest.Nickname)
-
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("user not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if voteRequest.Voice != 1 && voteRequest.Voice != -1 {
+		writeError(w, r, models.NewError(models.ErrValidation, "voice must be 1 or -1, got %d", voteRequest.Voice))
 		return
 	}
 
-	if err != nil {
-		log.Fatal(err)
-	}
+	user, err := delivery.UserRepo.GetByNickName(r.Context(), auth.Nickname(r.Context(), voteRequest.Nickname))
 
-	vote := models.Vote{
-		UserId:   user.Id,
-		ThreadId: thread.Id,
-		Value:    voteRequest.Voice,
+	if err != nil {
+		writeError(w, r, err)
+		return
 	}
 
-	err = delivery.VoteRepo.Vote(&vote)
-
-	if err == models.ErrNotFound {
-		w.WriteHeader(404)
-		status, err := w.Write([]byte(MakeErrorMsg("not found")))
-
-		if err != nil {
-			log.Fatal(status, err)
-		}
+	if err = auth.ActAs(r.Context(), user); err != nil {
+		writeError(w, r, err)
 		return
 	}
 
-	thread, err = delivery.ThreadUseCase.Get(slugOrId)
-
-	res, err := json.Marshal(thread)
+	thread, err = delivery.ThreadUseCase.Vote(r.Context(), thread, user, voteRequest.Voice)
 
 	if err != nil {
-		log.Fatal(err)
+		writeError(w, r, err)
+		return
 	}
 
-	w.WriteHeader(200)
-	status, err := w.Write(res)
-
-	if err != nil {
-		log.Fatal(status, err)
-	}
-	return
+	writeJSON(w, r, http.StatusOK, thread)
 }
@@ -0,0 +1,121 @@
+package delivery
+
+import (
+	"net/http"
+	"strconv"
+	"techno-forum/src/config"
+	"techno-forum/src/models"
+	"techno-forum/src/usecase"
+
+	"github.com/go-chi/chi"
+)
+
+type WebhookDelivery struct {
+	usecase *usecase.WebhookUseCase
+	limits  config.Pagination
+}
+
+func NewWebhookDelivery(usecase *usecase.WebhookUseCase, limits config.Pagination) *WebhookDelivery {
+	return &WebhookDelivery{
+		usecase: usecase,
+		limits:  limits,
+	}
+}
+
+func parseWebhookId(r *http.Request) (int, error) {
+	idStr := chi.URLParam(r, "id")
+	id, err := strconv.Atoi(idStr)
+
+	if err != nil {
+		return 0, models.NewError(models.ErrBadRequest, "invalid webhook id %q", idStr)
+	}
+	return id, nil
+}
+
+func (delivery *WebhookDelivery) Create(w http.ResponseWriter, r *http.Request) {
+	var req models.WebhookRequest
+
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	hook, err := delivery.usecase.Create(r.Context(), &req)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusCreated, hook)
+}
+
+func (delivery *WebhookDelivery) List(w http.ResponseWriter, r *http.Request) {
+	hooks, err := delivery.usecase.List(r.Context())
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, hooks)
+}
+
+func (delivery *WebhookDelivery) Get(w http.ResponseWriter, r *http.Request) {
+	id, err := parseWebhookId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	hook, err := delivery.usecase.Get(r.Context(), id)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, hook)
+}
+
+func (delivery *WebhookDelivery) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := parseWebhookId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	if err = delivery.usecase.Delete(r.Context(), id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (delivery *WebhookDelivery) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := parseWebhookId(r)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	limit, err := parseLimit(r.URL.Query().Get("limit"), delivery.limits)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	deliveries, err := delivery.usecase.GetDeliveries(r.Context(), id, limit)
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, deliveries)
+}
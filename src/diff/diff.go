@@ -0,0 +1,211 @@
+// Package diff produces unified diffs of short texts such as post messages.
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around each change.
+const Context = 3
+
+// maxEdits bounds the work spent looking for a minimal diff, which grows
+// with the square of the edits. Texts further apart than that are shown as
+// fully replaced.
+const maxEdits = 300
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// Unified returns the unified diff turning a into b, with fromName and toName
+// as file names. It is empty when the texts are equal.
+func Unified(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	ops := edits(strings.Split(a, "\n"), strings.Split(b, "\n"))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	// aLine and bLine count the lines of a and b before ops[i].
+	aLines := make([]int, len(ops)+1)
+	bLines := make([]int, len(ops)+1)
+	for i, o := range ops {
+		aLines[i+1], bLines[i+1] = aLines[i], bLines[i]
+		if o.kind != '+' {
+			aLines[i+1]++
+		}
+		if o.kind != '-' {
+			bLines[i+1]++
+		}
+	}
+
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+
+		start := max(i-Context, 0)
+
+		// Extend the hunk over changes separated by at most 2*Context
+		// unchanged lines.
+		end, same := i, 0
+		for j := i; j < len(ops) && same <= 2*Context; j++ {
+			if ops[j].kind == ' ' {
+				same++
+				continue
+			}
+			end, same = j, 0
+		}
+		end = min(end+Context+1, len(ops))
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n",
+			hunkRange(aLines[start], aLines[end]-aLines[start]),
+			hunkRange(bLines[start], bLines[end]-bLines[start]))
+		for _, o := range ops[start:end] {
+			sb.WriteByte(o.kind)
+			sb.WriteString(o.line)
+			sb.WriteByte('\n')
+		}
+
+		i = end
+	}
+
+	return sb.String()
+}
+
+func hunkRange(before, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, length)
+}
+
+// edits finds a shortest edit script. The lines a and b start and end with
+// are kept as they are, Myers' algorithm diffs the rest.
+func edits(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	res := make([]op, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		res = append(res, op{' ', line})
+	}
+	res = append(res, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		res = append(res, op{' ', line})
+	}
+	return res
+}
+
+// myers finds a shortest edit script with Myers' algorithm, or replaces a
+// with b when that takes more than maxEdits.
+func myers(a, b []string) []op {
+	n, m := len(a), len(b)
+	limit := min(n+m, maxEdits)
+
+	// v holds the furthest x on diagonal k at index k+offset, and trace[d]
+	// the part of it for diagonals [-d, d] before step d.
+	offset := limit + 1
+	v := make([]int, 2*limit+3)
+	var trace [][]int
+
+	found := false
+	for d := 0; d <= limit && !found; d++ {
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				found = true
+				break
+			}
+		}
+	}
+
+	if !found {
+		return replace(a, b)
+	}
+
+	var res []op
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		snapshot := trace[d]
+		at := func(k int) int { return snapshot[k+d] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			res = append(res, op{' ', a[x]})
+		}
+
+		if x == prevX {
+			y--
+			res = append(res, op{'+', b[y]})
+		} else {
+			x--
+			res = append(res, op{'-', a[x]})
+		}
+	}
+
+	for x > 0 && y > 0 {
+		x--
+		y--
+		res = append(res, op{' ', a[x]})
+	}
+
+	for i, j := 0, len(res)-1; i < j; i, j = i+1, j-1 {
+		res[i], res[j] = res[j], res[i]
+	}
+	return res
+}
+
+func replace(a, b []string) []op {
+	res := make([]op, 0, len(a)+len(b))
+	for _, line := range a {
+		res = append(res, op{'-', line})
+	}
+	for _, line := range b {
+		res = append(res, op{'+', line})
+	}
+	return res
+}
@@ -0,0 +1,92 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func lines(from, to int) string {
+	var res []string
+	for i := from; i <= to; i++ {
+		res = append(res, fmt.Sprintf("line %d", i))
+	}
+	return strings.Join(res, "\n")
+}
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want string
+	}{
+		{"equal", "same\ntext", "same\ntext", ""},
+		{
+			"insert only",
+			"a\nc",
+			"a\nb\nc",
+			"--- old\n+++ new\n@@ -1,2 +1,3 @@\n a\n+b\n c\n",
+		},
+		{
+			"delete only",
+			"a\nb\nc",
+			"a\nc",
+			"--- old\n+++ new\n@@ -1,3 +1,2 @@\n a\n-b\n c\n",
+		},
+		{
+			"into empty",
+			"a",
+			"",
+			"--- old\n+++ new\n@@ -1 +1 @@\n-a\n+\n",
+		},
+		{
+			"context",
+			lines(1, 10),
+			strings.Replace(lines(1, 10), "line 5", "five", 1),
+			"--- old\n+++ new\n@@ -2,7 +2,7 @@\n line 2\n line 3\n line 4\n-line 5\n+five\n line 6\n line 7\n line 8\n",
+		},
+		{
+			"separate hunks",
+			lines(1, 20),
+			strings.NewReplacer("line 2\n", "two\n", "line 19\n", "nineteen\n").Replace(lines(1, 20)),
+			"--- old\n+++ new\n" +
+				"@@ -1,5 +1,5 @@\n line 1\n-line 2\n+two\n line 3\n line 4\n line 5\n" +
+				"@@ -16,5 +16,5 @@\n line 16\n line 17\n line 18\n-line 19\n+nineteen\n line 20\n",
+		},
+		{
+			"joined hunks",
+			lines(1, 10),
+			strings.NewReplacer("line 2\n", "two\n", "line 8\n", "eight\n").Replace(lines(1, 10)),
+			"--- old\n+++ new\n@@ -1,10 +1,10 @@\n line 1\n-line 2\n+two\n line 3\n line 4\n line 5\n line 6\n line 7\n-line 8\n+eight\n line 9\n line 10\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Unified("old", "new", tt.a, tt.b); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUnifiedTooManyEdits diffs texts that share no line, which takes more
+// than maxEdits edits and is shown as a whole replace.
+func TestUnifiedTooManyEdits(t *testing.T) {
+	a := lines(1, 2000)
+	b := strings.ReplaceAll(a, "line", "other")
+
+	start := time.Now()
+	got := Unified("old", "new", a, b)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("took %s", elapsed)
+	}
+
+	if !strings.HasPrefix(got, "--- old\n+++ new\n@@ -1,2000 +1,2000 @@\n-line 1\n-line 2\n") {
+		t.Errorf("got a diff starting with\n%.100s", got)
+	}
+	if strings.Count(got, "\n-") != 2000 || strings.Count(got, "\n+") != 2001 {
+		t.Errorf("want every line removed and added")
+	}
+}
@@ -0,0 +1,183 @@
+// Package digests sends users email digests of the new posts in the
+// threads and forums they follow.
+package digests
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log/slog"
+	"net/url"
+	"strings"
+	"techno-forum/src/config"
+	"techno-forum/src/mail"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	texttemplate "text/template"
+	"time"
+)
+
+// UnsubscribePath is the route the unsubscribe links point to.
+const UnsubscribePath = "/api/digest/unsubscribe"
+
+//go:embed templates
+var templateFS embed.FS
+
+var funcs = map[string]any{
+	"date": func(created string) string {
+		t, err := time.Parse(time.RFC3339, created)
+		if err != nil {
+			return created
+		}
+		return t.Format("Jan 2, 15:04 MST")
+	},
+}
+
+var (
+	textTemplate = texttemplate.Must(texttemplate.New("digest.txt").Funcs(funcs).ParseFS(templateFS, "templates/digest.txt"))
+	htmlTemplate = htmltemplate.Must(htmltemplate.New("digest.html").Funcs(funcs).ParseFS(templateFS, "templates/digest.html"))
+)
+
+type digest struct {
+	Subject        string
+	Nickname       string
+	Frequency      string
+	Threads        []*models.DigestThread
+	More           int
+	UnsubscribeURL string
+}
+
+// Worker sends the digests that are due. Digests are claimed with a lease,
+// so several workers may share one database, and a digest that couldn't be
+// sent is tried again once its lease expires.
+type Worker struct {
+	repo      repository.DigestRepository
+	transport mail.Transport
+	cfg       config.Digests
+	from      string
+	log       *slog.Logger
+}
+
+func NewWorker(repo repository.DigestRepository, transport mail.Transport, cfg config.Digests, from string, logger *slog.Logger) *Worker {
+	return &Worker{
+		repo:      repo,
+		transport: transport,
+		cfg:       cfg,
+		from:      from,
+		log:       logger.With("component", "digests"),
+	}
+}
+
+// Run sends digests until ctx is done.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.poll(ctx); err != nil && ctx.Err() == nil {
+			w.log.Warn("sending digests failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) poll(ctx context.Context) error {
+	for ctx.Err() == nil {
+		due, err := w.repo.ClaimDue(ctx, w.cfg.BatchSize, 2*w.cfg.Timeout)
+		if err != nil {
+			return err
+		}
+
+		for _, recipient := range due {
+			outcome, err := w.send(ctx, recipient)
+			if err != nil {
+				outcome = "failed"
+				w.log.Warn("sending digest failed", "user", recipient.Nickname, "error", err)
+			}
+			metrics.Digests.WithLabelValues(outcome).Inc()
+		}
+
+		if len(due) < w.cfg.BatchSize {
+			break
+		}
+	}
+	return nil
+}
+
+// send sends the digest of recipient, or just moves on to the next period
+// when nothing happened in this one.
+func (w *Worker) send(ctx context.Context, recipient *models.DigestRecipient) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, w.cfg.Timeout)
+	defer cancel()
+
+	threads, total, err := w.repo.Activity(ctx, recipient, w.cfg.MaxPosts)
+	if err != nil {
+		return "", err
+	}
+
+	outcome := "empty"
+	if total > 0 {
+		msg, err := w.compose(recipient, threads, total)
+		if err != nil {
+			return "", err
+		}
+
+		if err = w.transport.Send(ctx, msg); err != nil {
+			return "", err
+		}
+		outcome = "sent"
+	}
+
+	w.log.Debug("digest", "user", recipient.Nickname, "posts", total, "outcome", outcome)
+	return outcome, w.repo.MarkSent(ctx, recipient)
+}
+
+func (w *Worker) compose(recipient *models.DigestRecipient, threads []*models.DigestThread, total int) (*mail.Message, error) {
+	shown := 0
+	for _, thread := range threads {
+		shown += len(thread.Posts)
+	}
+
+	noun := "posts"
+	if total == 1 {
+		noun = "post"
+	}
+
+	unsubscribe := strings.TrimSuffix(w.cfg.BaseURL, "/") + UnsubscribePath + "?token=" + url.QueryEscape(recipient.Token)
+	d := &digest{
+		Subject:        fmt.Sprintf("Your %s forum digest: %d new %s", recipient.Frequency, total, noun),
+		Nickname:       recipient.Nickname,
+		Frequency:      recipient.Frequency,
+		Threads:        threads,
+		More:           total - shown,
+		UnsubscribeURL: unsubscribe,
+	}
+
+	var text, html bytes.Buffer
+	if err := textTemplate.Execute(&text, d); err != nil {
+		return nil, err
+	}
+	if err := htmlTemplate.Execute(&html, d); err != nil {
+		return nil, err
+	}
+
+	return &mail.Message{
+		From:    w.from,
+		To:      mail.Address(recipient.Nickname, recipient.Email),
+		Subject: d.Subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+		Headers: map[string]string{
+			"List-Unsubscribe":      "<" + unsubscribe + ">",
+			"List-Unsubscribe-Post": "List-Unsubscribe=One-Click",
+		},
+	}, nil
+}
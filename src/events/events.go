@@ -0,0 +1,181 @@
+// Package events carries domain events from the use cases that cause them
+// to the subscribers interested in them, on every instance of the service.
+package events
+
+import (
+	"context"
+	"sync"
+	"techno-forum/src/logging"
+	"techno-forum/src/models"
+)
+
+const (
+	PostCreated   = "post.created"
+	PostUpdated   = "post.updated"
+	ThreadCreated = "thread.created"
+	ThreadUpdated = "thread.updated"
+	ThreadVoted   = "thread.voted"
+)
+
+// bufferSize is how many events a subscriber may lag behind before it is
+// dropped.
+const bufferSize = 64
+
+// Event is a change in the thread ThreadId. Post is set for post events and
+// Thread for thread ones.
+type Event struct {
+	Type     string         `json:"type"`
+	ThreadId int            `json:"threadId,omitempty"`
+	Post     *models.Post   `json:"post,omitempty"`
+	Thread   *models.Thread `json:"thread,omitempty"`
+}
+
+// Transport carries published events to the buses of every instance, this
+// one included, which get them through Bus.Deliver.
+type Transport interface {
+	Publish(ctx context.Context, events []*Event) error
+}
+
+// Subscription receives the events of one thread on C. C is closed when the
+// subscriber falls too far behind or the bus shuts down.
+type Subscription struct {
+	C <-chan *Event
+
+	c        chan *Event
+	threadId int
+	bus      *Bus
+}
+
+func (sub *Subscription) Close() {
+	sub.bus.unsubscribe(sub)
+}
+
+// Bus delivers events to the subscribers of their thread. Delivering never
+// blocks: a subscriber whose buffer is full loses its subscription.
+type Bus struct {
+	mu        sync.Mutex
+	subs      map[int]map[*Subscription]struct{}
+	closed    bool
+	transport Transport
+}
+
+// NewBus returns a bus that delivers events straight to the local
+// subscribers until a transport is set.
+func NewBus() *Bus {
+	return &Bus{
+		subs: map[int]map[*Subscription]struct{}{},
+	}
+}
+
+// SetTransport makes the bus publish through transport, which is then
+// responsible for calling Deliver.
+func (bus *Bus) SetTransport(transport Transport) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.transport = transport
+}
+
+func (bus *Bus) Subscribe(threadId int) *Subscription {
+	c := make(chan *Event, bufferSize)
+	sub := &Subscription{C: c, c: c, threadId: threadId, bus: bus}
+
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	if bus.closed {
+		close(c)
+		return sub
+	}
+
+	subs, ok := bus.subs[threadId]
+	if !ok {
+		subs = map[*Subscription]struct{}{}
+		bus.subs[threadId] = subs
+	}
+	subs[sub] = struct{}{}
+
+	return sub
+}
+
+// Publish sends events to the subscribers of every instance. The change
+// they describe has already happened, so a transport failure is only
+// logged; streams recover through their Last-Event-ID.
+func (bus *Bus) Publish(ctx context.Context, events ...*Event) {
+	bus.mu.Lock()
+	transport := bus.transport
+	bus.mu.Unlock()
+
+	if transport == nil {
+		bus.Deliver(events...)
+		return
+	}
+
+	if err := transport.Publish(ctx, events); err != nil {
+		logging.FromContext(ctx).ErrorContext(ctx, "publishing events", "count", len(events), "error", err)
+	}
+}
+
+// Deliver hands events to the local subscribers of their threads.
+func (bus *Bus) Deliver(events ...*Event) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	for _, event := range events {
+		for sub := range bus.subs[event.ThreadId] {
+			select {
+			case sub.c <- event:
+			default:
+				bus.drop(sub)
+			}
+		}
+	}
+}
+
+// Resync ends every subscription without closing the bus, for when events
+// may have been lost. Streams reconnect and catch up.
+func (bus *Bus) Resync() {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.dropAll()
+}
+
+// Close ends every subscription, so that streams finish on shutdown.
+func (bus *Bus) Close() {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.closed = true
+	bus.dropAll()
+}
+
+func (bus *Bus) unsubscribe(sub *Subscription) {
+	bus.mu.Lock()
+	defer bus.mu.Unlock()
+
+	bus.drop(sub)
+}
+
+// drop closes sub unless it is already gone. bus.mu must be held.
+func (bus *Bus) drop(sub *Subscription) {
+	subs := bus.subs[sub.threadId]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(bus.subs, sub.threadId)
+	}
+	close(sub.c)
+}
+
+// dropAll closes every subscription. bus.mu must be held.
+func (bus *Bus) dropAll() {
+	for _, subs := range bus.subs {
+		for sub := range subs {
+			bus.drop(sub)
+		}
+	}
+}
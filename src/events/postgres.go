@@ -0,0 +1,135 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// channel is the notification channel events travel on.
+const channel = "forum_events"
+
+// maxPayload keeps notifications under the 8000 byte limit of NOTIFY.
+// Larger events are stored in EventPayloads and notified as "@id".
+const maxPayload = 7900
+
+// payloadTTL is how long stored payloads are kept for slow listeners.
+const payloadTTL = time.Minute
+
+// PgTransport carries events between instances sharing a database with
+// LISTEN/NOTIFY.
+type PgTransport struct {
+	dbpool *pgxpool.Pool
+	bus    *Bus
+	log    *slog.Logger
+	retry  time.Duration
+}
+
+func NewPgTransport(dbpool *pgxpool.Pool, bus *Bus, logger *slog.Logger, retry time.Duration) *PgTransport {
+	return &PgTransport{
+		dbpool: dbpool,
+		bus:    bus,
+		log:    logger,
+		retry:  retry,
+	}
+}
+
+func (t *PgTransport) Publish(ctx context.Context, events []*Event) error {
+	payloads := make([]string, 0, len(events))
+
+	for _, event := range events {
+		body, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		if len(body) <= maxPayload {
+			payloads = append(payloads, string(body))
+			continue
+		}
+
+		var id int64
+		err = t.dbpool.QueryRow(ctx,
+			`WITH expired AS (DELETE FROM EventPayloads WHERE created_at < now() - make_interval(secs => $2))
+			 INSERT INTO EventPayloads (payload) VALUES ($1) RETURNING id`, string(body), payloadTTL.Seconds()).Scan(&id)
+		if err != nil {
+			return err
+		}
+		payloads = append(payloads, "@"+strconv.FormatInt(id, 10))
+	}
+
+	_, err := t.dbpool.Exec(ctx,
+		`SELECT pg_notify($1, payload) FROM unnest($2::text[]) WITH ORDINALITY AS p(payload, n) ORDER BY n`,
+		channel, payloads)
+	return err
+}
+
+// Listen hands the notifications to the bus until ctx is done, reconnecting
+// after failures. Events sent while the listener was away are lost, so the
+// bus is resynced after every reconnection.
+func (t *PgTransport) Listen(ctx context.Context) {
+	for reconnect := false; ; reconnect = true {
+		err := t.listen(ctx, reconnect)
+		if ctx.Err() != nil {
+			return
+		}
+
+		t.log.Warn("listening for events failed", "error", err, "retry", t.retry)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(t.retry):
+		}
+	}
+}
+
+func (t *PgTransport) listen(ctx context.Context, resync bool) error {
+	pooled, err := t.dbpool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The connection keeps listening until it is closed, so it must not go
+	// back to the pool.
+	conn := pooled.Hijack()
+	defer conn.Close(context.Background())
+
+	if _, err = conn.Exec(ctx, "LISTEN "+channel); err != nil {
+		return err
+	}
+
+	if resync {
+		t.bus.Resync()
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		payload := notification.Payload
+		if ref, ok := strings.CutPrefix(payload, "@"); ok {
+			id, _ := strconv.ParseInt(ref, 10, 64)
+			err = conn.QueryRow(ctx, "SELECT payload FROM EventPayloads WHERE id = $1", id).Scan(&payload)
+			if err != nil {
+				t.log.Warn("loading event payload", "id", id, "error", err)
+				continue
+			}
+		}
+
+		var event Event
+		if err = json.Unmarshal([]byte(payload), &event); err != nil {
+			t.log.Warn("decoding event", "error", err)
+			continue
+		}
+
+		t.bus.Deliver(&event)
+	}
+}
@@ -0,0 +1,188 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"techno-forum/src/migrations"
+	"techno-forum/src/models"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const checkTimeout = 2 * time.Second
+
+// ErrorTracker remembers the last unexpected database error. It observes
+// queries as a pgx tracer; missing rows and constraint violations are part of
+// normal operation and are not recorded.
+type ErrorTracker struct {
+	mu  sync.Mutex
+	err error
+	at  time.Time
+}
+
+func (t *ErrorTracker) Record(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.err = err
+	t.at = time.Now()
+}
+
+func (t *ErrorTracker) Last() (time.Time, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.at, t.err
+}
+
+func (t *ErrorTracker) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	return ctx
+}
+
+func (t *ErrorTracker) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	if data.Err == nil || errors.Is(data.Err, pgx.ErrNoRows) || errors.Is(data.Err, context.Canceled) {
+		return
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(data.Err, &pgErr) && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
+		return
+	}
+
+	t.Record(data.Err)
+}
+
+// Checker answers liveness and readiness probes. dbpool and errors are nil
+// for the in-memory storage.
+type Checker struct {
+	storage string
+	dbpool  *pgxpool.Pool
+	errors  *ErrorTracker
+	ready   func() bool
+	started time.Time
+}
+
+func NewChecker(storage string, dbpool *pgxpool.Pool, errors *ErrorTracker, ready func() bool) *Checker {
+	return &Checker{
+		storage: storage,
+		dbpool:  dbpool,
+		errors:  errors,
+		ready:   ready,
+		started: time.Now(),
+	}
+}
+
+// Readiness reports whether the instance should receive traffic: it is not
+// shutting down, the database answers and its schema is at least at the
+// version this build expects. A newer schema is only a warning, migrations
+// run ahead of the rollout and the old replicas must keep serving until
+// they are replaced.
+func (c *Checker) Readiness(ctx context.Context) *models.Readiness {
+	res, _ := c.readiness(ctx)
+	return res
+}
+
+func (c *Checker) readiness(ctx context.Context) (*models.Readiness, *models.MigrationHealth) {
+	res := &models.Readiness{
+		Status: models.HealthOK,
+		Checks: map[string]*models.HealthCheck{},
+	}
+
+	set := func(name string, err error) {
+		if err != nil {
+			res.Status = models.HealthFail
+			res.Checks[name] = &models.HealthCheck{Status: models.HealthFail, Error: err.Error()}
+			return
+		}
+		res.Checks[name] = &models.HealthCheck{Status: models.HealthOK}
+	}
+
+	if c.ready() {
+		set("shutdown", nil)
+	} else {
+		set("shutdown", errors.New("shutting down"))
+	}
+
+	if c.dbpool == nil {
+		res.Checks["database"] = &models.HealthCheck{Status: models.HealthSkipped}
+		res.Checks["migrations"] = &models.HealthCheck{Status: models.HealthSkipped}
+		return res, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	err := c.dbpool.Ping(ctx)
+	if err != nil {
+		c.errors.Record(err)
+		set("database", err)
+		set("migrations", errors.New("database is unreachable"))
+		return res, nil
+	}
+	set("database", nil)
+
+	versions := &models.MigrationHealth{}
+	versions.Expected, err = migrations.Latest()
+	if err == nil {
+		versions.Current, err = migrations.NewMigrator(c.dbpool).Version(ctx)
+	}
+
+	switch {
+	case err != nil:
+		set("migrations", err)
+		return res, nil
+	case versions.Current < versions.Expected:
+		set("migrations", fmt.Errorf("schema is at version %d, expected %d", versions.Current, versions.Expected))
+	case versions.Current > versions.Expected:
+		res.Checks["migrations"] = &models.HealthCheck{
+			Status:  models.HealthWarn,
+			Warning: fmt.Sprintf("schema is at version %d, newer than the expected %d", versions.Current, versions.Expected),
+		}
+	default:
+		set("migrations", nil)
+	}
+
+	return res, versions
+}
+
+// Report extends Readiness with pool saturation and the last database error.
+func (c *Checker) Report(ctx context.Context) *models.HealthReport {
+	readiness, versions := c.readiness(ctx)
+
+	report := &models.HealthReport{
+		Readiness:  *readiness,
+		Storage:    c.storage,
+		Uptime:     time.Since(c.started).Round(time.Second).String(),
+		Migrations: versions,
+	}
+
+	if c.dbpool == nil {
+		return report
+	}
+
+	stat := c.dbpool.Stat()
+	report.Pool = &models.PoolHealth{
+		MaxConns:      stat.MaxConns(),
+		TotalConns:    stat.TotalConns(),
+		AcquiredConns: stat.AcquiredConns(),
+		IdleConns:     stat.IdleConns(),
+		Saturation:    float64(stat.AcquiredConns()) / float64(stat.MaxConns()),
+		EmptyAcquires: stat.EmptyAcquireCount(),
+		AcquireWaitMs: stat.AcquireDuration().Milliseconds(),
+	}
+
+	if at, err := c.errors.Last(); err != nil {
+		report.LastDBError = &models.DBError{
+			Message: err.Error(),
+			At:      at.UTC().Format(time.RFC3339),
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,135 @@
+// Package mail composes email messages and sends them through SMTP or
+// stores them in a maildir.
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"techno-forum/src/config"
+	"time"
+)
+
+type Message struct {
+	From    string
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are added to the standard ones, e.g. List-Unsubscribe.
+	Headers map[string]string
+}
+
+type Transport interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// New returns the transport cfg selects.
+func New(cfg config.Mail) (Transport, error) {
+	switch cfg.Transport {
+	case config.MailTransportSMTP:
+		return NewSMTP(cfg.SMTP), nil
+	case config.MailTransportMaildir:
+		return NewMaildir(cfg.Maildir)
+	}
+	return nil, fmt.Errorf("unknown mail transport %q", cfg.Transport)
+}
+
+// Address formats an address with a display name, encoding the name when
+// it isn't plain ASCII.
+func Address(name, address string) string {
+	return (&mail.Address{Name: name, Address: address}).String()
+}
+
+// envelope returns the bare address of a From or To header value.
+func envelope(header string) (string, error) {
+	addr, err := mail.ParseAddress(header)
+	if err != nil {
+		return "", err
+	}
+	return addr.Address, nil
+}
+
+// Bytes renders the message as a multipart/alternative MIME message with
+// quoted-printable text and HTML parts.
+func (msg *Message) Bytes() ([]byte, error) {
+	from, err := envelope(msg.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sender %q: %w", msg.From, err)
+	}
+
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+
+	header := map[string]string{
+		"From":         msg.From,
+		"To":           msg.To,
+		"Subject":      mime.QEncoding.Encode("utf-8", msg.Subject),
+		"Date":         time.Now().Format(time.RFC1123Z),
+		"Message-ID":   messageId(from),
+		"MIME-Version": "1.0",
+		"Content-Type": "multipart/alternative; boundary=" + parts.Boundary(),
+	}
+	for k, v := range msg.Headers {
+		header[k] = v
+	}
+
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var head bytes.Buffer
+	for _, k := range keys {
+		fmt.Fprintf(&head, "%s: %s\r\n", k, header[k])
+	}
+	head.WriteString("\r\n")
+
+	for _, part := range []struct{ typ, body string }{{"text/plain", msg.Text}, {"text/html", msg.HTML}} {
+		if part.body == "" {
+			continue
+		}
+
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.typ + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(w)
+		if _, err = qp.Write([]byte(strings.ReplaceAll(part.body, "\n", "\r\n"))); err != nil {
+			return nil, err
+		}
+		if err = qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = parts.Close(); err != nil {
+		return nil, err
+	}
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+func messageId(from string) string {
+	domain := "localhost"
+	if at := strings.LastIndexByte(from, '@'); at >= 0 {
+		domain = from[at+1:]
+	}
+
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
@@ -0,0 +1,54 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Maildir stores every message as a file in a maildir, the way a local
+// delivery agent would, so that sent email can be read without a server.
+type Maildir struct {
+	dir      string
+	hostname string
+	seq      atomic.Int64
+}
+
+func NewMaildir(dir string) (*Maildir, error) {
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	return &Maildir{dir: dir, hostname: hostname}, nil
+}
+
+// Send writes the message to tmp and moves it to new once it is complete.
+func (t *Maildir) Send(ctx context.Context, msg *Message) error {
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d.M%dP%dQ%d.%s", time.Now().Unix(), time.Now().Nanosecond()/1000,
+		os.Getpid(), t.seq.Add(1), t.hostname)
+
+	tmp := filepath.Join(t.dir, "tmp", name)
+	if err = os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+
+	if err = os.Rename(tmp, filepath.Join(t.dir, "new", name)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"techno-forum/src/config"
+)
+
+// SMTP sends messages through an SMTP server, upgrading the connection
+// with STARTTLS when the server offers it.
+type SMTP struct {
+	addr string
+	host string
+	auth smtp.Auth
+}
+
+func NewSMTP(cfg config.SMTP) *SMTP {
+	host, _, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		host = cfg.Addr
+	}
+
+	t := &SMTP{addr: cfg.Addr, host: host}
+	if cfg.Username != "" {
+		t.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	return t
+}
+
+func (t *SMTP) Send(ctx context.Context, msg *Message) error {
+	from, err := envelope(msg.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", msg.From, err)
+	}
+
+	to, err := envelope(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+
+	body, err := msg.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", t.addr)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err = c.StartTLS(&tls.Config{ServerName: t.host}); err != nil {
+			return err
+		}
+	}
+
+	if t.auth != nil {
+		if err = c.Auth(t.auth); err != nil {
+			return err
+		}
+	}
+
+	if err = c.Mail(from); err != nil {
+		return err
+	}
+	if err = c.Rcpt(to); err != nil {
+		return err
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(body); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
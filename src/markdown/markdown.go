@@ -0,0 +1,52 @@
+// Package markdown renders messages written in CommonMark, with the GitHub
+// extensions, to HTML that is safe to embed in a page.
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	highlighting "github.com/yuin/goldmark-highlighting/v2"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Code blocks are highlighted with inline styles, so that clients don't need
+// a stylesheet to show them.
+var converter = goldmark.New(
+	goldmark.WithExtensions(
+		extension.GFM,
+		highlighting.NewHighlighting(
+			highlighting.WithStyle("github"),
+			highlighting.WithFormatOptions(chromahtml.TabWidth(4)),
+		),
+	),
+)
+
+var policy = newPolicy()
+
+// newPolicy allows what users may write in posts plus the styles of
+// highlighted code. Raw HTML in messages is dropped before it gets here,
+// the policy is what keeps a renderer bug from becoming an XSS hole.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowStyles("color", "background-color", "font-weight", "font-style", "text-decoration").
+		OnElements("span", "pre")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// Render returns the sanitized HTML of message.
+func Render(message string) string {
+	var buf bytes.Buffer
+	if err := converter.Convert([]byte(message), &buf); err != nil {
+		return "<p>" + html.EscapeString(message) + "</p>"
+	}
+	return policy.Sanitize(buf.String())
+}
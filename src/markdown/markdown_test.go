@@ -0,0 +1,40 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderSanitizes(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		banned  []string
+		want    string
+	}{
+		{"script tag", "hi <script>alert(1)</script>", []string{"<script", "alert(1)</script>"}, "hi"},
+		{"script block", "<script>\nalert(1)\n</script>", []string{"<script"}, ""},
+		{"javascript link", "[click](javascript:alert(1))", []string{"javascript:"}, "click"},
+		{"javascript autolink", "<javascript:alert(1)>", []string{`href="javascript:`}, ""},
+		{"javascript link in html", `<a href="javascript:alert(1)">click</a>`, []string{"javascript:"}, ""},
+		{"event handler", `<img src="x.png" onerror="alert(1)">`, []string{"onerror"}, ""},
+		{"event handler in a block", "<div onclick=\"alert(1)\">\ntext\n</div>", []string{"onclick"}, ""},
+		{"iframe", `<iframe src="https://example.com"></iframe>`, []string{"<iframe"}, ""},
+		{"markdown stays", "**bold** and [a link](https://example.com)", nil,
+			`<strong>bold</strong> and <a href="https://example.com" rel="nofollow noopener" target="_blank">a link</a>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Render(tt.message)
+			for _, banned := range tt.banned {
+				if strings.Contains(strings.ToLower(got), banned) {
+					t.Errorf("got %q, which contains %q", got, banned)
+				}
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
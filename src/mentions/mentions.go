@@ -0,0 +1,42 @@
+// Package mentions finds the users a message mentions as @nickname.
+package mentions
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Max is the most mentions taken from one message, so that a post can't
+// notify the whole forum.
+const Max = 20
+
+// pattern matches @nickname at the start of the message or after a
+// character that can't be part of an email address or another mention.
+var pattern = regexp.MustCompile(`(?:^|[^\w.@])@([\w.]+)`)
+
+// Parse returns the nicknames mentioned in message, lower cased, without
+// duplicates, in order of appearance. A mention at the end of a sentence
+// also yields the nickname without the trailing dots, since nicknames may
+// end in a dot.
+func Parse(message string) []string {
+	var res []string
+	seen := map[string]bool{}
+
+	add := func(nickname string) {
+		nickname = strings.ToLower(nickname)
+		if nickname == "" || seen[nickname] || len(res) == Max {
+			return
+		}
+		seen[nickname] = true
+		res = append(res, nickname)
+	}
+
+	for _, m := range pattern.FindAllStringSubmatch(message, -1) {
+		add(m[1])
+		add(strings.TrimRight(m[1], "."))
+		if len(res) == Max {
+			break
+		}
+	}
+	return res
+}
@@ -0,0 +1,42 @@
+package mentions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	var many, manyDotted []string
+	for i := 0; i < Max+5; i++ {
+		many = append(many, fmt.Sprintf("@user%d", i))
+		manyDotted = append(manyDotted, fmt.Sprintf("@user%d.", i))
+	}
+
+	tests := []struct {
+		name    string
+		message string
+		want    int
+		first   []string
+	}{
+		{"none", "mail me at a@b.c", 0, nil},
+		{"duplicates", "@Bob and @bob, @alice", 2, []string{"bob", "alice"}},
+		{"trailing dot", "thanks @j.r.", 2, []string{"j.r.", "j.r"}},
+		{"capped", strings.Join(many, " "), Max, []string{"user0", "user1"}},
+		{"capped with dots", strings.Join(manyDotted, " "), Max, []string{"user0.", "user0"}},
+		{"capped after duplicates", strings.Repeat("@bob ", Max) + strings.Join(many, " "), Max, []string{"bob", "user0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Parse(tt.message)
+			if len(got) != tt.want {
+				t.Errorf("got %d nicknames %v, want %d", len(got), got, tt.want)
+			}
+			if len(got) >= len(tt.first) && !slices.Equal(got[:len(tt.first)], tt.first) {
+				t.Errorf("got nicknames starting with %v, want %v", got[:len(tt.first)], tt.first)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// QueryTracer times every query and attributes it to the repository method
+// named in its context by WithMethod. Queries made outside the repositories
+// (migrations, pings) are reported as "other".
+type QueryTracer struct{}
+
+type queryKey struct{}
+
+type methodKey struct{}
+
+type queryStart struct {
+	method string
+	at     time.Time
+}
+
+// WithMethod names the repository method, e.g. "PostRepository.AddPosts",
+// that the queries made with ctx are attributed to. A method called by
+// another one keeps the name of the caller, the method the use case called.
+func WithMethod(ctx context.Context, method string) context.Context {
+	if _, ok := ctx.Value(methodKey{}).(string); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, methodKey{}, method)
+}
+
+func (QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, _ pgx.TraceQueryStartData) context.Context {
+	method, ok := ctx.Value(methodKey{}).(string)
+	if !ok {
+		method = "other"
+	}
+	return context.WithValue(ctx, queryKey{}, queryStart{method: method, at: time.Now()})
+}
+
+func (QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	outcome := "ok"
+	if data.Err != nil {
+		outcome = "error"
+	}
+
+	queryDuration.WithLabelValues(start.method, outcome).Observe(time.Since(start.at).Seconds())
+}
+
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquired         *prometheus.Desc
+	idle             *prometheus.Desc
+	total            *prometheus.Desc
+	max              *prometheus.Desc
+	acquires         *prometheus.Desc
+	acquireWait      *prometheus.Desc
+	emptyAcquires    *prometheus.Desc
+	canceledAcquires *prometheus.Desc
+}
+
+func poolDesc(name, help string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+}
+
+// RegisterPool exports the connection pool statistics.
+func RegisterPool(pool *pgxpool.Pool) error {
+	return Registry.Register(&poolCollector{
+		pool:             pool,
+		acquired:         poolDesc("acquired_conns", "Connections currently in use."),
+		idle:             poolDesc("idle_conns", "Idle connections in the pool."),
+		total:            poolDesc("total_conns", "Open connections, in use, idle or being established."),
+		max:              poolDesc("max_conns", "Maximum size of the pool."),
+		acquires:         poolDesc("acquires_total", "Connections acquired from the pool."),
+		acquireWait:      poolDesc("acquire_wait_seconds_total", "Time spent waiting for a connection."),
+		emptyAcquires:    poolDesc("empty_acquires_total", "Acquires that had to wait because the pool was empty."),
+		canceledAcquires: poolDesc("canceled_acquires_total", "Acquires cancelled by their context."),
+	})
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquired
+	ch <- c.idle
+	ch <- c.total
+	ch <- c.max
+	ch <- c.acquires
+	ch <- c.acquireWait
+	ch <- c.emptyAcquires
+	ch <- c.canceledAcquires
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquired, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idle, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.total, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.max, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquires, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireWait, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquires, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquires, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,300 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// lockKey identifies the advisory lock that serializes concurrent migration
+// runs, e.g. several replicas starting at once.
+const lockKey = 7_311_402_215
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int
+	Name      string
+	AppliedAt *time.Time
+}
+
+// All returns the embedded migrations ordered by version.
+func All() ([]*Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file name %q", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+
+		body, err := files.ReadFile(path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	res := make([]*Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		res = append(res, m)
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Version < res[j].Version })
+	return res, nil
+}
+
+// Latest returns the version of the newest embedded migration.
+func Latest() (int, error) {
+	all, err := All()
+	if err != nil {
+		return 0, err
+	}
+	if len(all) == 0 {
+		return 0, nil
+	}
+	return all[len(all)-1].Version, nil
+}
+
+type Migrator struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewMigrator(dbpool *pgxpool.Pool) *Migrator {
+	return &Migrator{
+		dbpool: dbpool,
+	}
+}
+
+// withLock runs fn on a dedicated connection holding the migration advisory
+// lock, after making sure the bookkeeping table exists.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := m.dbpool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", int64(lockKey)); err != nil {
+		return err
+	}
+	defer conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", int64(lockKey))
+
+	_, err = conn.Exec(ctx,
+		`CREATE TABLE IF NOT EXISTS schema_migrations (
+			version integer primary key,
+			name varchar not null,
+			applied_at timestamptz not null default now()
+		)`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func applied(ctx context.Context, conn *pgxpool.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := map[int]time.Time{}
+	for rows.Next() {
+		var version int
+		var at time.Time
+		if err = rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		res[version] = at
+	}
+
+	return res, rows.Err()
+}
+
+func runInTx(ctx context.Context, conn *pgxpool.Conn, fn func(tx pgx.Tx) error) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err = fn(tx); err != nil {
+		tx.Rollback(ctx)
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Up applies every pending migration in order and returns the ones applied.
+func (m *Migrator) Up(ctx context.Context) ([]*Migration, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var done []*Migration
+
+	err = m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range all {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+
+			err = runInTx(ctx, conn, func(tx pgx.Tx) error {
+				if _, err := tx.Exec(ctx, migration.Up); err != nil {
+					return err
+				}
+				_, err := tx.Exec(ctx,
+					"INSERT INTO schema_migrations (version, name) VALUES ($1, $2)",
+					migration.Version, migration.Name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("applying migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts the most recently applied migration. It returns nil when
+// there is nothing to revert.
+func (m *Migrator) Down(ctx context.Context) (*Migration, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var reverted *Migration
+
+	err = m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(all) - 1; i >= 0; i-- {
+			if _, ok := versions[all[i].Version]; ok {
+				reverted = all[i]
+				break
+			}
+		}
+
+		if reverted == nil {
+			return nil
+		}
+
+		if reverted.Down == "" {
+			return fmt.Errorf("migration %d_%s has no down script", reverted.Version, reverted.Name)
+		}
+
+		err = runInTx(ctx, conn, func(tx pgx.Tx) error {
+			if _, err := tx.Exec(ctx, reverted.Down); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", reverted.Version)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("reverting migration %d_%s: %w", reverted.Version, reverted.Name, err)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status lists every embedded migration together with the time it was
+// applied, if it was.
+func (m *Migrator) Status(ctx context.Context) ([]*Status, error) {
+	all, err := All()
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*Status
+
+	err = m.withLock(ctx, func(conn *pgxpool.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range all {
+			status := &Status{Version: migration.Version, Name: migration.Name}
+			if at, ok := versions[migration.Version]; ok {
+				status.AppliedAt = &at
+			}
+			res = append(res, status)
+		}
+
+		return nil
+	})
+
+	return res, err
+}
+
+// Version returns the highest applied migration version, 0 if none.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var version int
+	err := m.dbpool.QueryRow(ctx,
+		`SELECT COALESCE(max(version), 0) FROM schema_migrations`).Scan(&version)
+	return version, err
+}
@@ -0,0 +1,102 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testDSN names the environment variable with a database the tests may
+// create schemas in. Without it the tests that need one are skipped.
+const testDSN = "FORUM_TEST_DATABASE_DSN"
+
+func migration(t *testing.T, version int) *Migration {
+	t.Helper()
+
+	all, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range all {
+		if m.Version == version {
+			return m
+		}
+	}
+	t.Fatalf("no migration %d", version)
+	return nil
+}
+
+// TestInitRerunnable checks that 001 only creates what doesn't exist yet, so
+// that it can adopt databases set up from the old db.sql.
+func TestInitRerunnable(t *testing.T) {
+	up := migration(t, 1).Up
+
+	createTrigger := regexp.MustCompile(`(?i)create trigger (\w+)`)
+	for _, m := range createTrigger.FindAllStringSubmatch(up, -1) {
+		if !regexp.MustCompile(`(?i)drop trigger if exists ` + m[1] + ` `).MatchString(up) {
+			t.Errorf("trigger %s isn't dropped before it is created", m[1])
+		}
+	}
+
+	createIndex := regexp.MustCompile(`(?i)create (unique )?index (.*) on`)
+	for _, m := range createIndex.FindAllStringSubmatch(up, -1) {
+		if !strings.HasPrefix(strings.ToLower(m[2]), "if not exists ") {
+			t.Errorf("index %q is created unconditionally", m[0])
+		}
+	}
+}
+
+func TestInitTwice(t *testing.T) {
+	dsn := os.Getenv(testDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set", testDSN)
+	}
+	ctx := context.Background()
+
+	conf, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin, err := pgxpool.NewWithConfig(ctx, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Close()
+
+	schema := fmt.Sprintf("migrations_test_%d", time.Now().UnixNano())
+	if _, err = admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatal(err)
+	}
+	defer admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE")
+
+	conf.ConnConfig.RuntimeParams["search_path"] = schema
+	dbpool, err := pgxpool.NewWithConfig(ctx, conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbpool.Close()
+
+	up := migration(t, 1).Up
+	for run := 1; run <= 2; run++ {
+		if _, err = dbpool.Exec(ctx, up); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+
+	var indexes int
+	err = dbpool.QueryRow(ctx, "SELECT count(*) FROM pg_indexes WHERE schemaname = $1 AND indexname LIKE '%\\_idx%'", schema).
+		Scan(&indexes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if indexes != 7 {
+		t.Errorf("got %d indexes after two runs, want 7", indexes)
+	}
+}
@@ -0,0 +1,16 @@
+drop trigger if exists link_user_to_forum_on_thread on Threads;
+drop trigger if exists before_post_insert on Posts;
+drop trigger if exists on_thread on Threads;
+drop trigger if exists on_vote on Vote;
+
+drop function if exists link_user_to_forum();
+drop function if exists on_post_insert();
+drop function if exists update_thread_cnt();
+drop function if exists update_votes_cnt();
+
+drop table if exists Vote;
+drop table if exists Posts;
+drop table if exists Threads;
+drop table if exists ForumUserLinks;
+drop table if exists Forums;
+drop table if exists Users;
@@ -110,29 +110,34 @@ create or replace function link_user_to_forum() returns trigger as $$
 $$ language plpgsql;
 
 
+-- Databases set up from the old db.sql already have the triggers and the
+-- indexes, which got the names Postgres picks for unnamed ones, so this
+-- script can run over them.
+drop trigger if exists on_vote on Vote;
 create trigger on_vote
 after insert or update or delete on Vote
     for each row execute procedure update_votes_cnt();
 
+drop trigger if exists on_thread on Threads;
 create trigger on_thread
 after insert or delete on Threads
     for each row execute procedure update_thread_cnt();
-   
+
+drop trigger if exists before_post_insert on Posts;
 create trigger before_post_insert
 before insert on Posts
     for each row execute procedure on_post_insert();
 
+drop trigger if exists link_user_to_forum_on_thread on Threads;
 create trigger link_user_to_forum_on_thread
 after insert on Threads
     for each row execute procedure link_user_to_forum();
 
-create unique index on Users (lower(nickname));
-create unique index on Users (lower(email));
-create unique index on Forums (lower(slug));
-create unique index on Threads (lower(slug));
-
-create unique index on Forums (lower(slug));
+create unique index if not exists users_lower_idx on Users (lower(nickname));
+create unique index if not exists users_lower_idx1 on Users (lower(email));
+create unique index if not exists forums_lower_idx on Forums (lower(slug));
+create unique index if not exists threads_lower_idx on Threads (lower(slug));
 
-create index on posts (thread_id);
-create index on posts ((path[1]));
-create index on posts ((path[2:]));
+create index if not exists posts_thread_id_idx on posts (thread_id);
+create index if not exists posts_path_idx on posts ((path[1]));
+create index if not exists posts_path_idx1 on posts ((path[2:]));
@@ -0,0 +1,3 @@
+drop table if exists Sessions;
+
+alter table Users drop column if exists password_hash;
@@ -0,0 +1,11 @@
+alter table Users add column if not exists password_hash varchar;
+
+create table if not exists Sessions (
+	token_hash bytea primary key,
+	user_id integer references Users on delete cascade not null,
+	created_at timestamptz not null default now(),
+	expires_at timestamptz not null
+);
+
+create index if not exists sessions_user_id on Sessions (user_id);
+create index if not exists sessions_expires_at on Sessions (expires_at);
@@ -0,0 +1,3 @@
+drop table if exists ForumModerators;
+
+alter table Users drop column if exists role;
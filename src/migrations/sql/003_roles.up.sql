@@ -0,0 +1,10 @@
+alter table Users add column if not exists role varchar not null default 'user'
+	check (role in ('user', 'admin'));
+
+create table if not exists ForumModerators (
+	forum_id integer references Forums on delete cascade not null,
+	user_id integer references Users on delete cascade not null,
+	primary key (forum_id, user_id)
+);
+
+create index if not exists forum_moderators_user_id on ForumModerators (user_id);
@@ -0,0 +1,5 @@
+drop table if exists ForumBans;
+
+alter table Posts drop column if exists hidden;
+
+alter table Threads drop column if exists locked;
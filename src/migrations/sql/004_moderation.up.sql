@@ -0,0 +1,13 @@
+alter table Threads add column if not exists locked bool not null default false;
+
+alter table Posts add column if not exists hidden bool not null default false;
+
+create table if not exists ForumBans (
+	forum_id integer references Forums on delete cascade not null,
+	user_id integer references Users on delete cascade not null,
+	banned_by integer references Users on delete set null,
+	reason varchar not null default '',
+	created_at timestamptz not null default now(),
+	expires_at timestamptz,
+	primary key (forum_id, user_id)
+);
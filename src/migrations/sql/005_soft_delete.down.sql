@@ -0,0 +1,3 @@
+alter table Posts drop column if exists deleted_at;
+
+alter table Threads drop column if exists deleted_at;
@@ -0,0 +1,3 @@
+alter table Threads add column if not exists deleted_at timestamptz;
+
+alter table Posts add column if not exists deleted_at timestamptz;
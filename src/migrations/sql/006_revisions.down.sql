@@ -0,0 +1,3 @@
+drop table if exists ThreadRevisions;
+
+drop table if exists PostRevisions;
@@ -0,0 +1,18 @@
+create table if not exists PostRevisions (
+	post_id integer references Posts on delete cascade not null,
+	number integer not null,
+	editor_id integer references Users on delete set null,
+	message varchar not null,
+	created_at timestamptz not null default now(),
+	primary key (post_id, number)
+);
+
+create table if not exists ThreadRevisions (
+	thread_id integer references Threads on delete cascade not null,
+	number integer not null,
+	editor_id integer references Users on delete set null,
+	title varchar not null,
+	message varchar not null,
+	created_at timestamptz not null default now(),
+	primary key (thread_id, number)
+);
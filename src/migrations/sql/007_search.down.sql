@@ -0,0 +1,12 @@
+drop index if exists threads_search_idx;
+drop index if exists posts_search_idx;
+
+drop trigger if exists threads_search on Threads;
+drop trigger if exists posts_search on Posts;
+
+drop function if exists threads_search();
+drop function if exists posts_search();
+
+alter table Threads drop column if exists search;
+
+alter table Posts drop column if exists search;
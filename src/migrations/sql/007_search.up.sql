@@ -0,0 +1,39 @@
+-- Posts and threads are indexed with both the Russian and the English
+-- configurations, so that either language is stemmed.
+alter table Posts add column if not exists search tsvector;
+
+alter table Threads add column if not exists search tsvector;
+
+create or replace function posts_search() returns trigger as $$
+begin
+	new.search := to_tsvector('russian', new.message) || to_tsvector('english', new.message);
+	return new;
+end
+$$ language plpgsql;
+
+create or replace function threads_search() returns trigger as $$
+begin
+	new.search :=
+		setweight(to_tsvector('russian', new.title), 'A') || setweight(to_tsvector('english', new.title), 'A') ||
+		setweight(to_tsvector('russian', new.message), 'B') || setweight(to_tsvector('english', new.message), 'B');
+	return new;
+end
+$$ language plpgsql;
+
+drop trigger if exists posts_search on Posts;
+create trigger posts_search before insert or update of message on Posts
+	for each row execute function posts_search();
+
+drop trigger if exists threads_search on Threads;
+create trigger threads_search before insert or update of title, message on Threads
+	for each row execute function threads_search();
+
+update Posts set search = to_tsvector('russian', message) || to_tsvector('english', message);
+
+update Threads set search =
+	setweight(to_tsvector('russian', title), 'A') || setweight(to_tsvector('english', title), 'A') ||
+	setweight(to_tsvector('russian', message), 'B') || setweight(to_tsvector('english', message), 'B');
+
+create index if not exists posts_search_idx on Posts using gin (search);
+
+create index if not exists threads_search_idx on Threads using gin (search);
@@ -0,0 +1,7 @@
+-- Events too large for a NOTIFY payload wait here for the listeners of every
+-- instance to read them.
+create unlogged table if not exists EventPayloads (
+	id bigint primary key generated always as identity,
+	payload text not null,
+	created_at timestamptz not null default now()
+);
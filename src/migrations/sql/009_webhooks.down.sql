@@ -0,0 +1,5 @@
+drop table if exists WebhookDeliveries;
+
+drop table if exists Outbox;
+
+drop table if exists Webhooks;
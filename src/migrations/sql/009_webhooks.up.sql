@@ -0,0 +1,39 @@
+create table if not exists Webhooks (
+	id integer primary key generated always as identity,
+	-- null for webhooks that receive the events of every forum
+	forum_id integer references Forums on delete cascade,
+	url varchar not null,
+	-- empty for every event type
+	events varchar[] not null default '{}',
+	secret varchar not null,
+	created_by integer references Users on delete set null,
+	created_at timestamptz not null default now()
+);
+
+-- Events are queued here in the transaction that causes them, and moved to
+-- WebhookDeliveries by the delivery worker.
+create table if not exists Outbox (
+	id bigint primary key generated always as identity,
+	type varchar not null,
+	forum_id integer references Forums on delete cascade not null,
+	payload jsonb not null,
+	created_at timestamptz not null default now()
+);
+
+create table if not exists WebhookDeliveries (
+	id bigint primary key generated always as identity,
+	webhook_id integer references Webhooks on delete cascade not null,
+	type varchar not null,
+	payload jsonb not null,
+	status varchar not null default 'pending' check (status in ('pending', 'delivered', 'failed')),
+	attempts integer not null default 0,
+	next_attempt_at timestamptz not null default now(),
+	last_status integer,
+	last_error varchar not null default '',
+	created_at timestamptz not null default now(),
+	delivered_at timestamptz
+);
+
+create index if not exists webhook_deliveries_due on WebhookDeliveries (next_attempt_at) where status = 'pending';
+
+create index if not exists webhook_deliveries_webhook on WebhookDeliveries (webhook_id, id);
@@ -0,0 +1,14 @@
+create table if not exists Notifications (
+	id bigint primary key generated always as identity,
+	user_id integer references Users on delete cascade not null,
+	type varchar not null,
+	post_id bigint references Posts on delete cascade not null,
+	created_at timestamptz not null default now(),
+	read_at timestamptz,
+	-- a post notifies each user once, for the strongest reason
+	unique (user_id, post_id)
+);
+
+create index if not exists notifications_user on Notifications (user_id, id);
+
+create index if not exists notifications_unread on Notifications (user_id, id) where read_at is null;
@@ -0,0 +1,9 @@
+create table if not exists ThreadSubscriptions (
+	user_id integer references Users on delete cascade not null,
+	thread_id integer references Threads on delete cascade not null,
+	level varchar not null check (level in ('watching', 'tracking', 'muted')),
+	created_at timestamptz not null default now(),
+	primary key (thread_id, user_id)
+);
+
+create index if not exists thread_subscriptions_user on ThreadSubscriptions (user_id);
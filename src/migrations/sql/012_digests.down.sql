@@ -0,0 +1,2 @@
+drop table if exists Digests;
+drop table if exists ForumSubscriptions;
@@ -0,0 +1,20 @@
+create table if not exists ForumSubscriptions (
+	user_id integer references Users on delete cascade not null,
+	forum_id integer references Forums on delete cascade not null,
+	created_at timestamptz not null default now(),
+	primary key (forum_id, user_id)
+);
+
+create index if not exists forum_subscriptions_user on ForumSubscriptions (user_id);
+
+-- last_post_id is the newest post the previous digest covered, token lets
+-- the recipient unsubscribe from the link in the email.
+create table if not exists Digests (
+	user_id integer primary key references Users on delete cascade,
+	frequency varchar not null check (frequency in ('off', 'daily', 'weekly')),
+	token varchar not null unique,
+	next_at timestamptz not null,
+	last_post_id bigint not null default 0
+);
+
+create index if not exists digests_due on Digests (next_at) where frequency <> 'off';
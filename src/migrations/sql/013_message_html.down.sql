@@ -0,0 +1,2 @@
+alter table Threads drop column if exists message_html;
+alter table Posts drop column if exists message_html;
@@ -0,0 +1,4 @@
+-- message_html caches the rendered message. It is null for messages written
+-- before rendering existed, which are rendered when they are read.
+alter table Posts add column if not exists message_html text;
+alter table Threads add column if not exists message_html text;
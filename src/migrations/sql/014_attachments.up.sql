@@ -0,0 +1,15 @@
+-- post_id stays null until a post links the attachment. storage_key names
+-- the contents in the blob store, sha256 doubles as the ETag.
+create table if not exists Attachments (
+	id bigint primary key generated always as identity,
+	post_id bigint references Posts on delete cascade,
+	uploader_id integer references Users on delete cascade not null,
+	name varchar not null,
+	content_type varchar not null,
+	size bigint not null,
+	sha256 varchar not null,
+	storage_key varchar not null unique,
+	created_at timestamptz not null default now()
+);
+
+create index if not exists attachments_post on Attachments (post_id);
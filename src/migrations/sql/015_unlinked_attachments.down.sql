@@ -0,0 +1 @@
+drop index if exists attachments_unlinked;
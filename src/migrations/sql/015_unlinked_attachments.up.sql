@@ -0,0 +1,3 @@
+-- Uploads no post links are deleted once they are older than
+-- attachments.unlinked_ttl.
+create index if not exists attachments_unlinked on Attachments (created_at) where post_id is null;
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// Attachment is an uploaded file. Post is nil until a post links it.
+type Attachment struct {
+	Id          int64     `json:"id"`
+	Post        *int64    `json:"post,omitempty"`
+	Uploader    string    `json:"uploader"`
+	UploaderId  int       `json:"-"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	Created     time.Time `json:"created"`
+	Key         string    `json:"-"`
+}
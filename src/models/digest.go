@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+// Digest frequencies.
+const (
+	DigestOff    = "off"
+	DigestDaily  = "daily"
+	DigestWeekly = "weekly"
+)
+
+// DigestPeriod returns the time between two digests of frequency, or zero
+// when it is off or unknown.
+func DigestPeriod(frequency string) time.Duration {
+	switch frequency {
+	case DigestDaily:
+		return 24 * time.Hour
+	case DigestWeekly:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+type DigestSettings struct {
+	User      string     `json:"user"`
+	UserId    int        `json:"-"`
+	Frequency string     `json:"frequency"`
+	Next      *time.Time `json:"next,omitempty"`
+	Token     string     `json:"-"`
+}
+
+type DigestSettingsRequest struct {
+	Frequency string `json:"frequency"`
+}
+
+// DigestRecipient is a user whose digest is due. It covers the posts with
+// ids in (After, Until].
+type DigestRecipient struct {
+	UserId    int
+	Nickname  string
+	Email     string
+	Frequency string
+	Token     string
+	After     int64
+	Until     int64
+}
+
+// DigestThread is a thread with its new posts, oldest first.
+type DigestThread struct {
+	Id    int
+	Title string
+	Forum string
+	Posts []*Post
+}
+
+type ForumSubscription struct {
+	User    string    `json:"user"`
+	UserId  int       `json:"-"`
+	Forum   string    `json:"forum"`
+	ForumId int       `json:"-"`
+	Created time.Time `json:"created"`
+}
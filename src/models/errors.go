@@ -1,6 +1,9 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrAlreadyExists   = errors.New("such object already exists")
@@ -8,4 +11,34 @@ var (
 	ErrNoParent        = errors.New("no parent found")
 	ErrInvalidParent   = errors.New("invalid parent")
 	ErrInvalidArgument = errors.New("invalid argument")
+	ErrBadRequest      = errors.New("malformed request")
+	ErrValidation      = errors.New("validation failed")
+	ErrUnavailable     = errors.New("service unavailable")
+	ErrUnauthorized    = errors.New("authentication required")
+	ErrForbidden       = errors.New("forbidden")
+	ErrTooLarge        = errors.New("request too large")
+	ErrMediaType       = errors.New("unsupported media type")
 )
+
+// Error attaches a human readable message to one of the sentinel errors
+// above. errors.Is(err, ErrNotFound) keeps working for wrapped errors, the
+// message is what ends up in the response body.
+type Error struct {
+	Kind    error
+	Message string
+}
+
+func NewError(kind error, format string, args ...interface{}) error {
+	return &Error{
+		Kind:    kind,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
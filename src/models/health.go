@@ -0,0 +1,50 @@
+package models
+
+const (
+	HealthOK   = "ok"
+	HealthFail = "fail"
+	// HealthWarn checks passed but need attention. They don't fail the
+	// readiness.
+	HealthWarn    = "warn"
+	HealthSkipped = "skipped"
+)
+
+type HealthCheck struct {
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+type Readiness struct {
+	Status string                  `json:"status"`
+	Checks map[string]*HealthCheck `json:"checks"`
+}
+
+type PoolHealth struct {
+	MaxConns      int32   `json:"max_conns"`
+	TotalConns    int32   `json:"total_conns"`
+	AcquiredConns int32   `json:"acquired_conns"`
+	IdleConns     int32   `json:"idle_conns"`
+	Saturation    float64 `json:"saturation"`
+	EmptyAcquires int64   `json:"empty_acquires"`
+	AcquireWaitMs int64   `json:"acquire_wait_ms"`
+}
+
+type MigrationHealth struct {
+	Current  int `json:"current"`
+	Expected int `json:"expected"`
+}
+
+type DBError struct {
+	Message string `json:"message"`
+	At      string `json:"at"`
+}
+
+type HealthReport struct {
+	Readiness
+	Storage     string           `json:"storage"`
+	Uptime      string           `json:"uptime"`
+	Pool        *PoolHealth      `json:"pool,omitempty"`
+	Migrations  *MigrationHealth `json:"migrations,omitempty"`
+	LastDBError *DBError         `json:"last_db_error,omitempty"`
+}
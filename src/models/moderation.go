@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// HiddenPostMessage replaces the message of a hidden post in listings.
+const HiddenPostMessage = "[hidden by a moderator]"
+
+// Ban keeps a user from creating threads, posting and voting in a forum
+// until Expires, or for good when it is nil.
+type Ban struct {
+	ForumId int        `json:"-"`
+	UserId  int        `json:"-"`
+	ById    int        `json:"-"`
+	Forum   string     `json:"forum"`
+	User    string     `json:"user"`
+	By      string     `json:"by,omitempty"`
+	Reason  string     `json:"reason,omitempty"`
+	Created time.Time  `json:"created"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+type BanRequest struct {
+	Reason  string     `json:"reason,omitempty"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
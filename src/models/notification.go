@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	NotificationReply   = "reply"
+	NotificationMention = "mention"
+	// NotificationPost is a new post in a watched thread.
+	NotificationPost = "post"
+)
+
+// Notification tells a user about a post that replies to or mentions them,
+// or that is new in a thread they watch.
+type Notification struct {
+	Id      int64     `json:"id"`
+	Type    string    `json:"type"`
+	Post    *Post     `json:"post"`
+	Created time.Time `json:"created"`
+	Read    bool      `json:"read"`
+}
+
+type NotificationListParams struct {
+	UserId int
+	Unread bool
+	// Before only lists notifications with a smaller id, to page through
+	// them newest first.
+	Before int64
+	Limit  int
+}
+
+// NotificationsReadRequest marks the notifications with Ids read, or every
+// notification when Ids is empty.
+type NotificationsReadRequest struct {
+	Ids []int64 `json:"ids,omitempty"`
+}
+
+type NotificationsRead struct {
+	Marked int `json:"marked"`
+}
@@ -3,16 +3,24 @@ package models
 import "github.com/tee8z/nullable"
 
 type Post struct {
-	Id       int64          `json:"id"`
-	Parent   nullable.Int64 `json:"parent,omitempty"`
-	Author   string         `json:"author"`
-	Message  string         `json:"message"`
-	IsEdited bool           `json:"isEdited,omitempty"`
-	Forum    string         `json:"forum"`
-	Thread   int            `json:"thread"`
-	Created  string         `json:"created"`
+	Id          int64          `json:"id"`
+	Parent      nullable.Int64 `json:"parent,omitempty"`
+	Author      string         `json:"author"`
+	Message     string         `json:"message"`
+	MessageHTML string         `json:"message_html,omitempty"`
+	Attachments []int64        `json:"attachments,omitempty"`
+	IsEdited    bool           `json:"isEdited,omitempty"`
+	Forum       string         `json:"forum"`
+	Thread      int            `json:"thread"`
+	Created     string         `json:"created"`
+	Hidden      bool           `json:"hidden,omitempty"`
+	Deleted     bool           `json:"deleted,omitempty"`
 }
 
+// DeletedPostMessage replaces the message of a deleted post, which stays in
+// tree listings so that its replies keep their place.
+const DeletedPostMessage = "[deleted]"
+
 const (
 	SortFlat = iota
 	SortTree
@@ -0,0 +1,17 @@
+package models
+
+// Revision is one stored version of a post or a thread. Revision 1 is the
+// original text, later ones follow each edit.
+type Revision struct {
+	Number  int    `json:"number"`
+	Editor  string `json:"editor,omitempty"`
+	Created string `json:"created"`
+	Title   string `json:"title,omitempty"`
+	Message string `json:"message"`
+}
+
+type RevisionDiff struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"`
+}
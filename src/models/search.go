@@ -0,0 +1,74 @@
+package models
+
+import (
+	"html"
+	"strings"
+	"time"
+)
+
+const (
+	SearchPost   = "post"
+	SearchThread = "thread"
+)
+
+const (
+	SearchByRank = iota
+	SearchByCreated
+)
+
+// SearchHighlightStart and SearchHighlightStop surround the matched words in
+// snippets.
+const (
+	SearchHighlightStart = "<mark>"
+	SearchHighlightStop  = "</mark>"
+)
+
+// SearchMarkStart and SearchMarkStop are what the search backends surround
+// matches with. They are control characters removed from the text first, so
+// they can't come from a message.
+const (
+	SearchMarkStart = "\x02"
+	SearchMarkStop  = "\x03"
+)
+
+var searchMarks = strings.NewReplacer(SearchMarkStart, "", SearchMarkStop, "")
+
+// StripSearchMarks removes the marker characters from text before matches
+// are marked in it.
+func StripSearchMarks(text string) string {
+	return searchMarks.Replace(text)
+}
+
+var searchHighlights = strings.NewReplacer(SearchMarkStart, SearchHighlightStart, SearchMarkStop, SearchHighlightStop)
+
+// HighlightSnippet escapes a snippet with marked matches as HTML and then
+// turns the marks into highlights.
+func HighlightSnippet(marked string) string {
+	return searchHighlights.Replace(html.EscapeString(marked))
+}
+
+type SearchParams struct {
+	Query  string
+	Forum  string
+	Author string
+	// Type is SearchPost, SearchThread or empty for both.
+	Type string
+	// Since is only used with SearchByCreated. Results sorted by rank are
+	// paged with Offset.
+	Since  time.Time
+	Limit  int
+	Offset int
+	Desc   bool
+	Sort   int
+}
+
+// SearchHit is a post or a thread matching a search, with the matched words
+// highlighted in Snippet.
+type SearchHit struct {
+	Type    string    `json:"type"`
+	Rank    float64   `json:"rank"`
+	Snippet string    `json:"snippet"`
+	Post    *Post     `json:"post,omitempty"`
+	Thread  *Thread   `json:"thread,omitempty"`
+	Created time.Time `json:"-"`
+}
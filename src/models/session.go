@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+type Credentials struct {
+	Nickname string `json:"nickname"`
+	Password string `json:"password"`
+}
+
+// Session is a signed in user. Only the hash of the token is stored, the
+// token itself is returned once, on login.
+type Session struct {
+	TokenHash []byte    `json:"-"`
+	UserId    int       `json:"-"`
+	Token     string    `json:"token,omitempty"`
+	User      *User     `json:"user"`
+	Created   time.Time `json:"created"`
+	Expires   time.Time `json:"expires"`
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Subscription levels. Watchers are notified of every new post in the
+// thread, trackers only of replies and mentions, as everyone else is, and
+// muted users of nothing from it.
+const (
+	SubscriptionWatching = "watching"
+	SubscriptionTracking = "tracking"
+	SubscriptionMuted    = "muted"
+)
+
+type Subscription struct {
+	User     string    `json:"user"`
+	UserId   int       `json:"-"`
+	ThreadId int       `json:"thread"`
+	Level    string    `json:"level"`
+	Created  time.Time `json:"created"`
+}
+
+type SubscriptionRequest struct {
+	Nickname string `json:"nickname"`
+	Level    string `json:"level"`
+}
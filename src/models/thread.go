@@ -3,13 +3,16 @@ package models
 import "github.com/tee8z/nullable"
 
 type Thread struct {
-	Id      int             `json:"id"`
-	Title   string          `json:"title"`
-	Author  string          `json:"author"`
-	Forum   string          `json:"forum"`
-	ForumId int             `json:"-"`
-	Message string          `json:"message"`
-	Votes   int             `json:"votes"`
-	Slug    nullable.String `json:"slug, omitempty"`
-	Created string          `json:"created"`
+	Id          int             `json:"id"`
+	Title       string          `json:"title"`
+	Author      string          `json:"author"`
+	Forum       string          `json:"forum"`
+	ForumId     int             `json:"-"`
+	Message     string          `json:"message"`
+	MessageHTML string          `json:"message_html,omitempty"`
+	Votes       int             `json:"votes"`
+	Slug        nullable.String `json:"slug,omitempty"`
+	Created     string          `json:"created"`
+	Locked      bool            `json:"locked,omitempty"`
+	Deleted     bool            `json:"deleted,omitempty"`
 }
@@ -1,9 +1,29 @@
 package models
 
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
 type User struct {
 	Id       int    `json:"-"`
 	Nickname string `json:"nickname"`
 	Fullname string `json:"fullname"`
 	About    string `json:"about"`
 	Email    string `json:"email"`
+
+	// Password is only read from requests, it is never stored or returned.
+	Password     string `json:"password,omitempty"`
+	PasswordHash string `json:"-"`
+	Role         string `json:"-"`
+}
+
+// Account is the signed in user as they see themselves.
+type Account struct {
+	*User
+	Role string `json:"role"`
+}
+
+type RoleRequest struct {
+	Role string `json:"role"`
 }
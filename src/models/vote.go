@@ -7,6 +7,6 @@ type Vote struct {
 }
 
 type VoteRequest struct {
-	Nickname string
-	Voice    int
+	Nickname string `json:"nickname"`
+	Voice    int    `json:"voice"`
 }
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook receives the events of one forum, or of every forum when Forum is
+// empty. An empty Events list stands for every event type.
+type Webhook struct {
+	Id      int       `json:"id"`
+	Forum   string    `json:"forum,omitempty"`
+	ForumId int       `json:"-"`
+	URL     string    `json:"url"`
+	Events  []string  `json:"events"`
+	By      string    `json:"by,omitempty"`
+	ById    int       `json:"-"`
+	Created time.Time `json:"created"`
+	// Secret signs the deliveries. It is only shown when the webhook is
+	// created.
+	Secret string `json:"secret,omitempty"`
+}
+
+type WebhookRequest struct {
+	URL    string   `json:"url"`
+	Forum  string   `json:"forum,omitempty"`
+	Events []string `json:"events,omitempty"`
+	// Secret is generated when empty.
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookDelivery is one event sent, or still to be sent, to a webhook.
+type WebhookDelivery struct {
+	Id          int64           `json:"id"`
+	WebhookId   int             `json:"webhook"`
+	Type        string          `json:"event"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	Attempts    int             `json:"attempts"`
+	NextAttempt *time.Time      `json:"nextAttempt,omitempty"`
+	LastStatus  int             `json:"lastStatus,omitempty"`
+	LastError   string          `json:"lastError,omitempty"`
+	Created     time.Time       `json:"created"`
+	Delivered   *time.Time      `json:"delivered,omitempty"`
+
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
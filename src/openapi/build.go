@@ -0,0 +1,181 @@
+package openapi
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi"
+)
+
+const jsonType = "application/json"
+
+// Op documents one route. Path parameters that are not listed in Params are
+// added as required strings.
+type Op struct {
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	Params      []*Parameter
+	// Body is a value of the request body type, e.g. models.User{}, or a
+	// *Schema.
+	Body interface{}
+	// BodyType is the media type of Body, JSON when empty.
+	BodyType  string
+	Responses []Resp
+	// Hidden routes are known but left out of the document, e.g. the docs UI.
+	Hidden bool
+}
+
+type Resp struct {
+	Status      int
+	Description string
+	// Body is a value of the response type or a *Schema, nil for an empty
+	// body.
+	Body interface{}
+	// ContentType is the media type of Body, JSON when empty.
+	ContentType string
+}
+
+func PathParam(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "path", Description: description, Required: true, Schema: schema}
+}
+
+func QueryParam(name, description string, schema *Schema) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// Build documents every route of the router with ops, which is keyed by
+// "METHOD pattern" like "GET /api/forum/{slug}/details". The routes without
+// an entry, and the entries without a route, are listed in the error, which
+// comes with the document of the other routes so that the spec can't
+// silently drift from the router.
+func Build(routes chi.Routes, info Info, ops map[string]*Op) (*Document, error) {
+	doc := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       info,
+		Paths:      map[string]PathItem{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+
+	s := &schemas{components: doc.Components.Schemas}
+	seen := map[string]bool{}
+
+	var problems []string
+
+	err := chi.Walk(routes, func(method, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		key := method + " " + route
+		seen[key] = true
+
+		op, ok := ops[key]
+		if !ok {
+			problems = append(problems, "no spec for route "+key)
+			return nil
+		}
+
+		if op.Hidden {
+			return nil
+		}
+
+		path := pathParam.ReplaceAllString(route, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = op.operation(s, route)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for key := range ops {
+		if !seen[key] {
+			problems = append(problems, "spec for unknown route "+key)
+		}
+	}
+
+	for _, item := range doc.Paths {
+		for _, op := range item {
+			for _, tag := range op.Tags {
+				if !hasTag(doc.Tags, tag) {
+					doc.Tags = append(doc.Tags, Tag{Name: tag})
+				}
+			}
+		}
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return doc, errors.New("openapi: " + strings.Join(problems, "; "))
+	}
+	return doc, nil
+}
+
+func hasTag(tags []Tag, name string) bool {
+	for _, t := range tags {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (op *Op) operation(s *schemas, route string) *Operation {
+	res := &Operation{
+		Tags:        op.Tags,
+		Summary:     op.Summary,
+		Description: op.Description,
+		OperationID: op.ID,
+		Parameters:  op.Params,
+		Responses:   map[string]*Response{},
+	}
+
+	for _, m := range pathParam.FindAllStringSubmatch(route, -1) {
+		if !hasParam(res.Parameters, m[1]) {
+			res.Parameters = append(res.Parameters, PathParam(m[1], "", String()))
+		}
+	}
+
+	if op.Body != nil {
+		res.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{orJSON(op.BodyType): {Schema: s.of(op.Body)}},
+		}
+	}
+
+	for _, r := range op.Responses {
+		resp := &Response{Description: r.Description}
+		if resp.Description == "" {
+			resp.Description = http.StatusText(r.Status)
+		}
+		if r.Body != nil {
+			resp.Content = map[string]*MediaType{orJSON(r.ContentType): {Schema: s.of(r.Body)}}
+		}
+		res.Responses[strconv.Itoa(r.Status)] = resp
+	}
+
+	return res
+}
+
+func orJSON(mediaType string) string {
+	if mediaType == "" {
+		return jsonType
+	}
+	return mediaType
+}
+
+func hasParam(params []*Parameter, name string) bool {
+	for _, p := range params {
+		if p.In == "path" && p.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/tee8z/nullable"
+)
+
+// Types that marshal themselves and need a hand-written schema.
+var knownTypes = map[reflect.Type]func() *Schema{
+	reflect.TypeOf(nullable.Int64{}): func() *Schema {
+		return &Schema{Type: "integer", Format: "int64", Nullable: true}
+	},
+	reflect.TypeOf(nullable.String{}): func() *Schema {
+		return &Schema{Type: "string", Nullable: true}
+	},
+	reflect.TypeOf(time.Time{}): func() *Schema {
+		return &Schema{Type: "string", Format: "date-time"}
+	},
+	reflect.TypeOf(json.RawMessage{}): func() *Schema {
+		return &Schema{Type: "object", Description: "Any JSON value."}
+	},
+}
+
+// schemas derives JSON schemas from Go types by their json tags. Named
+// structs are stored once under components and referenced from elsewhere.
+type schemas struct {
+	components map[string]*Schema
+}
+
+func (s *schemas) of(v interface{}) *Schema {
+	if schema, ok := v.(*Schema); ok {
+		return schema
+	}
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *schemas) schema(t reflect.Type) *Schema {
+	if known, ok := knownTypes[t]; ok {
+		return known()
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return s.schema(t.Elem())
+	case reflect.String:
+		return String()
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		return s.object(t)
+	}
+
+	return &Schema{}
+}
+
+func (s *schemas) object(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return s.properties(t)
+	}
+
+	ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+	if _, ok := s.components[t.Name()]; ok {
+		return ref
+	}
+
+	// Reserve the name first so recursive types terminate.
+	s.components[t.Name()] = &Schema{}
+	*s.components[t.Name()] = *s.properties(t)
+	return ref
+}
+
+func (s *schemas) properties(t reflect.Type) *Schema {
+	res := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := s.properties(indirect(field.Type))
+			for k, v := range embedded.Properties {
+				res.Properties[k] = v
+			}
+			res.Required = append(res.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		res.Properties[name] = s.schema(field.Type)
+
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Ptr {
+			res.Required = append(res.Required, name)
+		}
+	}
+
+	return res
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	return t
+}
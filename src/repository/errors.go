@@ -0,0 +1,54 @@
+package repository
+
+import "techno-forum/src/models"
+
+func UserNotFound(nickname string) error {
+	return models.NewError(models.ErrNotFound, "can't find user with nickname %s", nickname)
+}
+
+func ForumNotFound(slug string) error {
+	return models.NewError(models.ErrNotFound, "can't find forum with slug %s", slug)
+}
+
+func ThreadNotFound(slugOrId string) error {
+	return models.NewError(models.ErrNotFound, "can't find thread %s", slugOrId)
+}
+
+func PostNotFound(id int64) error {
+	return models.NewError(models.ErrNotFound, "can't find post with id %d", id)
+}
+
+func NotificationNotFound(id int64) error {
+	return models.NewError(models.ErrNotFound, "can't find notification %d", id)
+}
+
+func WebhookNotFound(id int) error {
+	return models.NewError(models.ErrNotFound, "can't find webhook %d", id)
+}
+
+func AttachmentNotFound(id int64) error {
+	return models.NewError(models.ErrNotFound, "can't find attachment %d", id)
+}
+
+// AttachmentUnavailable is returned when a post links an attachment that
+// doesn't exist, was uploaded by someone else or belongs to a post already.
+func AttachmentUnavailable(id int64) error {
+	return models.NewError(models.ErrValidation, "attachment %d doesn't exist, isn't the author's or is linked to another post", id)
+}
+
+func InvalidTimestamp(value string) error {
+	return models.NewError(models.ErrBadRequest, "invalid timestamp %q", value)
+}
+
+var (
+	ErrUserConflict   = models.NewError(models.ErrAlreadyExists, "user with such nickname or email already exists")
+	ErrNoParent       = models.NewError(models.ErrNoParent, "parent post doesn't exist")
+	ErrInvalidParent  = models.NewError(models.ErrInvalidParent, "parent post was created in another thread")
+	ErrVoteNotFound   = models.NewError(models.ErrNotFound, "can't find thread or user to vote")
+	ErrNoSession      = models.NewError(models.ErrNotFound, "no such session")
+	ErrNoBan          = models.NewError(models.ErrNotFound, "the user isn't banned in this forum")
+	ErrNotModerator   = models.NewError(models.ErrNotFound, "the user isn't a moderator of this forum")
+	ErrNoSubscription = models.NewError(models.ErrNotFound, "the user isn't subscribed to this thread")
+	ErrNoForumSub     = models.NewError(models.ErrNotFound, "the user isn't subscribed to this forum")
+	ErrDigestToken    = models.NewError(models.ErrNotFound, "unknown unsubscribe token")
+)
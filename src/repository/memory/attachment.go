@@ -0,0 +1,141 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type AttachmentRepository struct {
+	store *Store
+}
+
+func NewAttachmentRepository(store *Store) *AttachmentRepository {
+	return &AttachmentRepository{
+		store: store,
+	}
+}
+
+// checkAttachments makes sure that every attachment of the new posts is an
+// unlinked upload of the post's author, given at most once.
+func (s *Store) checkAttachments(posts []*models.Post, authorIds map[string]int) error {
+	seen := map[int64]bool{}
+	for _, post := range posts {
+		for _, id := range post.Attachments {
+			a, ok := s.attachments[id]
+			if !ok || a.postId != 0 || a.uploaderId != authorIds[post.Author] || seen[id] {
+				return repository.AttachmentUnavailable(id)
+			}
+			seen[id] = true
+		}
+	}
+	return nil
+}
+
+func (s *Store) attachmentModel(a *attachmentRow) *models.Attachment {
+	attachment := &models.Attachment{
+		Id:          a.id,
+		Uploader:    s.users[a.uploaderId].nickname,
+		UploaderId:  a.uploaderId,
+		Name:        a.name,
+		ContentType: a.contentType,
+		Size:        a.size,
+		SHA256:      a.sha256,
+		Created:     a.created,
+		Key:         a.key,
+	}
+	if a.postId != 0 {
+		postId := a.postId
+		attachment.Post = &postId
+	}
+	return attachment
+}
+
+func (repo *AttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[attachment.UploaderId]; !ok {
+		return repository.UserNotFound(attachment.Uploader)
+	}
+
+	s.lastAttachmentId++
+	a := &attachmentRow{
+		id:          s.lastAttachmentId,
+		uploaderId:  attachment.UploaderId,
+		name:        attachment.Name,
+		contentType: attachment.ContentType,
+		size:        attachment.Size,
+		sha256:      attachment.SHA256,
+		key:         attachment.Key,
+		created:     time.Now(),
+	}
+	s.attachments[a.id] = a
+
+	attachment.Id = a.id
+	attachment.Created = a.created
+	return nil
+}
+
+func (repo *AttachmentRepository) Get(ctx context.Context, id int64) (*models.Attachment, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.attachments[id]
+	if !ok {
+		return nil, repository.AttachmentNotFound(id)
+	}
+
+	if a.postId != 0 {
+		p, ok := s.posts[a.postId]
+		if !ok || p.deleted || p.hidden || s.threads[p.threadId].deleted {
+			return nil, repository.AttachmentNotFound(id)
+		}
+	}
+	return s.attachmentModel(a), nil
+}
+
+func (repo *AttachmentRepository) GetIds(ctx context.Context, postIds []int64) (map[int64][]int64, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := map[int64][]int64{}
+	for _, a := range s.attachments {
+		if a.postId != 0 && slices.Contains(postIds, a.postId) {
+			res[a.postId] = append(res[a.postId], a.id)
+		}
+	}
+	for _, ids := range res {
+		slices.Sort(ids)
+	}
+	return res, nil
+}
+
+func (repo *AttachmentRepository) DeleteUnlinked(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var unlinked []*attachmentRow
+	for _, a := range s.attachments {
+		if a.postId == 0 && a.created.Before(before) {
+			unlinked = append(unlinked, a)
+		}
+	}
+	slices.SortFunc(unlinked, func(a, b *attachmentRow) int {
+		return a.created.Compare(b.created)
+	})
+
+	keys := []string{}
+	for _, a := range unlinked[:min(limit, len(unlinked))] {
+		delete(s.attachments, a.id)
+		keys = append(keys, a.key)
+	}
+	return keys, nil
+}
@@ -0,0 +1,55 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"techno-forum/src/models"
+	"testing"
+	"time"
+)
+
+func TestDeleteUnlinked(t *testing.T) {
+	f := newFixture(t)
+	repo := NewAttachmentRepository(f.store)
+	ctx := context.Background()
+
+	var ids []int64
+	for _, key := range []string{"linked", "old", "older"} {
+		attachment := &models.Attachment{Uploader: "alice", UploaderId: f.users["alice"].Id, Key: key}
+		if err := repo.Create(ctx, attachment); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, attachment.Id)
+	}
+	f.store.attachments[ids[2]].created = f.store.attachments[ids[1]].created.Add(-time.Minute)
+
+	linked := post("alice", 0)
+	linked.Attachments = ids[:1]
+	f.addPosts(t, linked)
+
+	tests := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"oldest first", 1, []string{"older"}},
+		{"linked stay", 10, []string{"old"}},
+		{"nothing left", 10, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, err := repo.DeleteUnlinked(ctx, time.Now().Add(time.Second), tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(keys, tt.want) {
+				t.Errorf("got keys %v, want %v", keys, tt.want)
+			}
+		})
+	}
+
+	if _, err := repo.Get(ctx, ids[0]); err != nil {
+		t.Errorf("getting the linked attachment: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type BanRepository struct {
+	store *Store
+}
+
+func NewBanRepository(store *Store) *BanRepository {
+	return &BanRepository{
+		store: store,
+	}
+}
+
+func (s *Store) banModel(k forumUserKey, b *banRow) *models.Ban {
+	ban := &models.Ban{
+		ForumId: k.forumId,
+		UserId:  k.userId,
+		ById:    b.byId,
+		Forum:   s.forums[k.forumId].slug,
+		User:    s.users[k.userId].nickname,
+		Reason:  b.reason,
+		Created: b.created,
+		Expires: b.expires,
+	}
+	if by, ok := s.users[b.byId]; ok {
+		ban.By = by.nickname
+	}
+	return ban
+}
+
+func (repo *BanRepository) Ban(ctx context.Context, ban *models.Ban) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.forums[ban.ForumId]; !ok {
+		return models.NewError(models.ErrNotFound, "can't find forum")
+	}
+	if _, ok := s.users[ban.UserId]; !ok {
+		return models.NewError(models.ErrNotFound, "can't find user")
+	}
+
+	ban.Created = time.Now()
+	s.bans[forumUserKey{ban.ForumId, ban.UserId}] = &banRow{
+		byId:    ban.ById,
+		reason:  ban.Reason,
+		created: ban.Created,
+		expires: ban.Expires,
+	}
+	return nil
+}
+
+func (repo *BanRepository) Unban(ctx context.Context, forumId int, userId int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := forumUserKey{forumId, userId}
+	if _, ok := s.bans[k]; !ok {
+		return repository.ErrNoBan
+	}
+	delete(s.bans, k)
+	return nil
+}
+
+func (repo *BanRepository) GetByForum(ctx context.Context, forumId int) ([]*models.Ban, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	bans := []*models.Ban{}
+	for k, b := range s.bans {
+		if k.forumId == forumId && b.active(now) {
+			bans = append(bans, s.banModel(k, b))
+		}
+	}
+
+	sort.Slice(bans, func(i, j int) bool { return bans[i].Created.After(bans[j].Created) })
+	return bans, nil
+}
+
+func (repo *BanRepository) Active(ctx context.Context, forumId int, userIds []int) ([]*models.Ban, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	bans := []*models.Ban{}
+	for _, userId := range userIds {
+		k := forumUserKey{forumId, userId}
+		if b, ok := s.bans[k]; ok && b.active(now) {
+			bans = append(bans, s.banModel(k, b))
+		}
+	}
+	return bans, nil
+}
@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type DigestRepository struct {
+	store *Store
+}
+
+func NewDigestRepository(store *Store) *DigestRepository {
+	return &DigestRepository{
+		store: store,
+	}
+}
+
+func digestSettings(userId int, d *digestRow) *models.DigestSettings {
+	settings := &models.DigestSettings{UserId: userId, Frequency: d.frequency}
+	if d.frequency != models.DigestOff {
+		next := d.next
+		settings.Next = &next
+	}
+	return settings
+}
+
+func (repo *DigestRepository) GetSettings(ctx context.Context, userId int) (*models.DigestSettings, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	d, ok := s.digests[userId]
+	if !ok {
+		return &models.DigestSettings{UserId: userId, Frequency: models.DigestOff}, nil
+	}
+	return digestSettings(userId, d), nil
+}
+
+func (repo *DigestRepository) SetSettings(ctx context.Context, settings *models.DigestSettings) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.digests[settings.UserId]
+	if !ok {
+		d = &digestRow{frequency: models.DigestOff, token: settings.Token}
+		s.digests[settings.UserId] = d
+		s.digestByToken[d.token] = settings.UserId
+	}
+
+	if d.frequency == models.DigestOff {
+		d.lastPostId = s.lastPostId
+	}
+	d.frequency = settings.Frequency
+	d.next = time.Now().Add(models.DigestPeriod(settings.Frequency))
+
+	updated := digestSettings(settings.UserId, d)
+	settings.Next = updated.Next
+	settings.Token = d.token
+	return nil
+}
+
+func (repo *DigestRepository) Unsubscribe(ctx context.Context, token string) (*models.DigestSettings, error) {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	userId, ok := s.digestByToken[token]
+	if !ok {
+		return nil, repository.ErrDigestToken
+	}
+
+	d := s.digests[userId]
+	d.frequency = models.DigestOff
+
+	settings := digestSettings(userId, d)
+	settings.User = s.users[userId].nickname
+	return settings, nil
+}
+
+func (repo *DigestRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.DigestRecipient, error) {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var due []int
+	for userId, d := range s.digests {
+		if d.frequency != models.DigestOff && !d.next.After(now) {
+			due = append(due, userId)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return s.digests[due[i]].next.Before(s.digests[due[j]].next) })
+
+	res := []*models.DigestRecipient{}
+	for _, userId := range due[:min(limit, len(due))] {
+		d := s.digests[userId]
+		d.next = now.Add(lease)
+
+		user := s.users[userId]
+		res = append(res, &models.DigestRecipient{
+			UserId:    userId,
+			Nickname:  user.nickname,
+			Email:     user.email,
+			Frequency: d.frequency,
+			Token:     d.token,
+			After:     d.lastPostId,
+			Until:     s.lastPostId,
+		})
+	}
+	return res, nil
+}
+
+// follows tells whether posts in the thread go into the digests of the user.
+func (s *Store) follows(userId int, t *threadRow) bool {
+	if sub, ok := s.threadSubscriptions[t.id][userId]; ok {
+		return sub.level != models.SubscriptionMuted
+	}
+	_, ok := s.forumSubscriptions[t.forumId][userId]
+	return ok
+}
+
+func (repo *DigestRepository) Activity(ctx context.Context, recipient *models.DigestRecipient, limit int) ([]*models.DigestThread, int, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var threads []*models.DigestThread
+	byId := map[int]*models.DigestThread{}
+	total := 0
+
+	for id := recipient.After + 1; id <= recipient.Until; id++ {
+		p, ok := s.posts[id]
+		if !ok || p.deleted || p.hidden || p.authorId == recipient.UserId {
+			continue
+		}
+
+		t := s.threads[p.threadId]
+		if t.deleted || !s.follows(recipient.UserId, t) {
+			continue
+		}
+
+		total++
+		if total > limit {
+			continue
+		}
+
+		thread, ok := byId[t.id]
+		if !ok {
+			thread = &models.DigestThread{Id: t.id, Title: t.title, Forum: s.forums[t.forumId].slug}
+			byId[t.id] = thread
+			threads = append(threads, thread)
+		}
+		thread.Posts = append(thread.Posts, s.postModel(p))
+	}
+	return threads, total, nil
+}
+
+func (repo *DigestRepository) MarkSent(ctx context.Context, recipient *models.DigestRecipient) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.digests[recipient.UserId]
+	if !ok {
+		return nil
+	}
+
+	d.lastPostId = max(d.lastPostId, recipient.Until)
+	d.next = time.Now().Add(models.DigestPeriod(recipient.Frequency))
+	return nil
+}
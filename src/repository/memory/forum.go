@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type ForumRepository struct {
+	store *Store
+}
+
+func NewForumRepository(store *Store) *ForumRepository {
+	return &ForumRepository{
+		store: store,
+	}
+}
+
+func (s *Store) forumModel(f *forumRow) *models.Forum {
+	return &models.Forum{
+		Id:      f.id,
+		Title:   f.title,
+		Slug:    f.slug,
+		Author:  s.users[f.authorId].nickname,
+		Posts:   f.posts,
+		Threads: f.threads,
+	}
+}
+
+func (repo *ForumRepository) Create(ctx context.Context, forum *models.Forum, author_id int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.forumBySlug[key(forum.Slug)]; ok {
+		*forum = *s.forumModel(s.forums[id])
+		return models.ErrAlreadyExists
+	}
+
+	if _, ok := s.users[author_id]; !ok {
+		return models.NewError(models.ErrNotFound, "can't find forum author")
+	}
+
+	s.lastForumId++
+	f := &forumRow{
+		id:       s.lastForumId,
+		title:    forum.Title,
+		slug:     forum.Slug,
+		authorId: author_id,
+	}
+
+	s.forums[f.id] = f
+	s.forumBySlug[key(f.slug)] = f.id
+	forum.Id = f.id
+
+	return nil
+}
+
+func (repo *ForumRepository) Get(ctx context.Context, slug string) (*models.Forum, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.forumBySlug[key(slug)]
+	if !ok {
+		return nil, repository.ForumNotFound(slug)
+	}
+
+	return s.forumModel(s.forums[id]), nil
+}
+
+func (repo *ForumRepository) IsModerator(ctx context.Context, forumId int, userId int) (bool, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, ok := s.moderators[forumId][userId]
+	return ok, nil
+}
+
+func (repo *ForumRepository) AddModerator(ctx context.Context, forumId int, userId int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	moderators, ok := s.moderators[forumId]
+	if !ok {
+		moderators = map[int]struct{}{}
+		s.moderators[forumId] = moderators
+	}
+	moderators[userId] = struct{}{}
+	return nil
+}
+
+func (repo *ForumRepository) RemoveModerator(ctx context.Context, forumId int, userId int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.moderators[forumId][userId]; !ok {
+		return repository.ErrNotModerator
+	}
+	delete(s.moderators[forumId], userId)
+	return nil
+}
+
+func (repo *ForumRepository) GetModerators(ctx context.Context, forumId int) ([]*models.User, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []*models.User{}
+	for id := range s.moderators[forumId] {
+		user := s.users[id].toModel()
+		users = append(users, user)
+	}
+
+	sort.Slice(users, func(i, j int) bool { return key(users[i].Nickname) < key(users[j].Nickname) })
+	return users, nil
+}
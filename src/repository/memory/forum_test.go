@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"context"
+	"testing"
+)
+
+func TestForumCounters(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	forums := NewForumRepository(f.store)
+	posts := NewPostRepo(f.store)
+	threads := NewThreadRepository(f.store)
+
+	tests := []struct {
+		name    string
+		change  func() error
+		threads int
+		posts   int
+	}{
+		{"created", func() error { return nil }, 1, 6},
+		{"post deleted", func() error { return posts.SetDeleted(ctx, 4, true) }, 1, 5},
+		{"post deleted twice", func() error { return posts.SetDeleted(ctx, 4, true) }, 1, 5},
+		{"thread deleted", func() error { return threads.SetDeleted(ctx, f.thread.Id, true) }, 0, 0},
+		{"post restored in deleted thread", func() error { return posts.SetDeleted(ctx, 4, false) }, 0, 0},
+		{"thread restored", func() error { return threads.SetDeleted(ctx, f.thread.Id, false) }, 1, 6},
+	}
+
+	for _, tt := range tests {
+		if err := tt.change(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		// The slug is looked up case-insensitively.
+		forum, err := forums.Get(ctx, "f1")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if forum.Threads != tt.threads || forum.Posts != tt.posts {
+			t.Errorf("%s: got %d threads and %d posts, want %d and %d",
+				tt.name, forum.Threads, forum.Posts, tt.threads, tt.posts)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"techno-forum/src/mentions"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type NotificationRepository struct {
+	store *Store
+}
+
+func NewNotificationRepository(store *Store) *NotificationRepository {
+	return &NotificationRepository{
+		store: store,
+	}
+}
+
+// notify creates the notifications of the new posts like the postgres
+// repository does: the parent author is notified of a reply, mentioned
+// users of the mention, watchers of the post, nobody of their own posts,
+// nobody who muted the thread and nobody twice.
+func (s *Store) notify(threadId int, posts []*models.Post, authorIds map[string]int) {
+	now := time.Now()
+	subs := s.threadSubscriptions[threadId]
+
+	var watchers []int
+	for userId, sub := range subs {
+		if sub.level == models.SubscriptionWatching {
+			watchers = append(watchers, userId)
+		}
+	}
+	slices.Sort(watchers)
+
+	add := func(post *models.Post, userId int, typ string) {
+		if _, ok := s.users[userId]; !ok || userId == authorIds[post.Author] {
+			return
+		}
+		if sub, ok := subs[userId]; ok && sub.level == models.SubscriptionMuted {
+			return
+		}
+
+		notifications := s.userNotifications[userId]
+		if last := len(notifications) - 1; last >= 0 && notifications[last].postId == post.Id {
+			return
+		}
+
+		s.lastNotificationId++
+		s.userNotifications[userId] = append(notifications, &notificationRow{
+			id:      s.lastNotificationId,
+			userId:  userId,
+			typ:     typ,
+			postId:  post.Id,
+			created: now,
+		})
+	}
+
+	for _, post := range posts {
+		if parent := post.Parent.Get(); parent != nil {
+			add(post, s.posts[*parent].authorId, models.NotificationReply)
+		}
+		for _, nickname := range mentions.Parse(post.Message) {
+			add(post, s.userByNick[nickname], models.NotificationMention)
+		}
+		for _, watcher := range watchers {
+			add(post, watcher, models.NotificationPost)
+		}
+	}
+}
+
+func (s *Store) notificationModel(n *notificationRow) *models.Notification {
+	return &models.Notification{
+		Id:      n.id,
+		Type:    n.typ,
+		Post:    s.postModel(s.posts[n.postId]),
+		Created: n.created,
+		Read:    n.read,
+	}
+}
+
+// visible leaves out the notifications of posts that can't be read anymore.
+func (s *Store) visible(n *notificationRow) bool {
+	p, ok := s.posts[n.postId]
+	return ok && !p.deleted && !p.hidden && !s.threads[p.threadId].deleted
+}
+
+func (repo *NotificationRepository) List(ctx context.Context, params *models.NotificationListParams) ([]*models.Notification, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := []*models.Notification{}
+	notifications := s.userNotifications[params.UserId]
+	for i := len(notifications) - 1; i >= 0 && len(res) < params.Limit; i-- {
+		n := notifications[i]
+		if (params.Unread && n.read) || (params.Before != 0 && n.id >= params.Before) || !s.visible(n) {
+			continue
+		}
+		res = append(res, s.notificationModel(n))
+	}
+	return res, nil
+}
+
+func (repo *NotificationRepository) Get(ctx context.Context, userId int, id int64) (*models.Notification, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, n := range s.userNotifications[userId] {
+		if n.id == id && s.visible(n) {
+			return s.notificationModel(n), nil
+		}
+	}
+	return nil, repository.NotificationNotFound(id)
+}
+
+func (repo *NotificationRepository) MarkRead(ctx context.Context, userId int, ids []int64) (int, error) {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	marked := 0
+	for _, n := range s.userNotifications[userId] {
+		if n.read || (len(ids) > 0 && !slices.Contains(ids, n.id)) {
+			continue
+		}
+		n.read = true
+		marked++
+	}
+	return marked, nil
+}
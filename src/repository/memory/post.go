@@ -0,0 +1,420 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"techno-forum/src/events"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type PostRepository struct {
+	store *Store
+}
+
+func NewPostRepo(store *Store) *PostRepository {
+	return &PostRepository{
+		store: store,
+	}
+}
+
+func (s *Store) postModel(p *postRow) *models.Post {
+	t := s.threads[p.threadId]
+	return &models.Post{
+		Id:       p.id,
+		Parent:   p.parent,
+		Author:   s.users[p.authorId].nickname,
+		Message:  p.message,
+		IsEdited: p.edited,
+		Forum:    s.forums[t.forumId].slug,
+		Thread:   p.threadId,
+		Created:  p.created,
+		Hidden:   p.hidden,
+		Deleted:  p.deleted,
+	}
+}
+
+func comparePaths(a, b []int64) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+func (repo *PostRepository) AddPosts(ctx context.Context, thread *models.Thread, posts []*models.Post) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[thread.Id]
+	if !ok {
+		return repository.ThreadNotFound(strconv.Itoa(thread.Id))
+	}
+
+	ids := make(map[string]int, len(posts))
+	for _, post := range posts {
+		if _, ok := ids[post.Author]; ok {
+			continue
+		}
+
+		id, ok := s.userByNick[key(post.Author)]
+		if !ok {
+			return repository.UserNotFound(post.Author)
+		}
+		ids[post.Author] = id
+	}
+
+	for _, post := range posts {
+		parentId := post.Parent.Get()
+		if parentId == nil {
+			continue
+		}
+
+		parent, ok := s.posts[*parentId]
+		if !ok {
+			return repository.ErrNoParent
+		}
+
+		if parent.threadId != thread.Id {
+			return repository.ErrInvalidParent
+		}
+	}
+
+	if err := s.checkAttachments(posts, ids); err != nil {
+		return err
+	}
+
+	createdAt := time.Now().UTC().Format(timeLayout)
+
+	for _, post := range posts {
+		s.lastPostId++
+		p := &postRow{
+			id:          s.lastPostId,
+			message:     post.Message,
+			messageHTML: post.MessageHTML,
+			created:     createdAt,
+			parent:      post.Parent,
+			authorId:    ids[post.Author],
+			threadId:    thread.Id,
+		}
+
+		if parentId := p.parent.Get(); parentId != nil {
+			parentPath := s.posts[*parentId].path
+			p.path = append(append(make([]int64, 0, len(parentPath)+1), parentPath...), p.id)
+		} else {
+			p.path = []int64{p.id}
+		}
+
+		s.posts[p.id] = p
+		s.threadPostIds[thread.Id] = append(s.threadPostIds[thread.Id], p.id)
+
+		for _, id := range post.Attachments {
+			s.attachments[id].postId = p.id
+		}
+
+		post.Id = p.id
+		post.Thread = thread.Id
+		post.Forum = thread.Forum
+		post.Created = createdAt
+	}
+
+	s.forums[t.forumId].posts += len(posts)
+	for _, id := range ids {
+		s.linkUserToForum(id, t.forumId)
+	}
+
+	created := make([]*events.Event, 0, len(posts))
+	for _, post := range posts {
+		created = append(created, &events.Event{Type: events.PostCreated, ThreadId: thread.Id, Post: post})
+	}
+	s.writeOutbox(t.forumId, created...)
+	s.notify(thread.Id, posts, ids)
+	s.trackThread(thread.Id, ids)
+
+	return nil
+}
+
+func (repo *PostRepository) GetPost(ctx context.Context, id int64) (*models.Post, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, repository.PostNotFound(id)
+	}
+
+	return s.postModel(p), nil
+}
+
+func (repo *PostRepository) Update(ctx context.Context, post *models.Post, editorId int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[post.Id]
+	if !ok {
+		return repository.PostNotFound(post.Id)
+	}
+
+	if post.Message == "" {
+		post.Message = p.message
+	}
+
+	post.IsEdited = p.edited
+	if post.Message != p.message {
+		created, _ := time.Parse(timeLayout, p.created)
+		original := &revisionRow{number: 1, editorId: p.authorId, created: created, message: p.message}
+		s.postRevisions[p.id] = addRevision(s.postRevisions[p.id], original, editorId, "", post.Message)
+
+		post.IsEdited = true
+		p.message = post.Message
+		p.messageHTML = post.MessageHTML
+		p.edited = true
+	}
+
+	previous := s.postModel(p)
+	post.Author = previous.Author
+	post.Forum = previous.Forum
+	post.Created = previous.Created
+	post.Parent = previous.Parent
+	post.Thread = previous.Thread
+	post.Hidden = previous.Hidden
+	post.Deleted = previous.Deleted
+
+	return nil
+}
+
+func (repo *PostRepository) GetMessagesHTML(ctx context.Context, ids []int64) (map[int64]string, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make(map[int64]string, len(ids))
+	for _, id := range ids {
+		if p, ok := s.posts[id]; ok && p.messageHTML != "" {
+			res[id] = p.messageHTML
+		}
+	}
+	return res, nil
+}
+
+func (repo *PostRepository) GetRevisions(ctx context.Context, id int64) ([]*models.Revision, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.revisionModels(s.postRevisions[id]), nil
+}
+
+func (s *Store) threadPosts(threadId int) []*postRow {
+	ids := s.threadPostIds[threadId]
+	res := make([]*postRow, 0, len(ids))
+	for _, id := range ids {
+		res = append(res, s.posts[id])
+	}
+	return res
+}
+
+func (s *Store) postModels(posts []*postRow) []*models.Post {
+	res := make([]*models.Post, 0, len(posts))
+	for _, p := range posts {
+		res = append(res, s.postModel(p))
+	}
+	return res
+}
+
+func (repo *PostRepository) GetPostsFlat(ctx context.Context, params *models.PostListParams) ([]*models.Post, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	posts := s.threadPosts(params.ThreadId)
+	if params.Desc {
+		for i, j := 0, len(posts)-1; i < j; i, j = i+1, j-1 {
+			posts[i], posts[j] = posts[j], posts[i]
+		}
+	}
+
+	res := make([]*postRow, 0, params.Limit)
+	for _, p := range posts {
+		if len(res) == params.Limit {
+			break
+		}
+
+		if p.deleted {
+			continue
+		}
+
+		if params.Since > 0 {
+			if !params.Desc && p.id <= int64(params.Since) {
+				continue
+			}
+			if params.Desc && p.id >= int64(params.Since) {
+				continue
+			}
+		}
+
+		res = append(res, p)
+	}
+
+	return s.postModels(res), nil
+}
+
+func (repo *PostRepository) GetPostsTree(ctx context.Context, params *models.PostListParams) ([]*models.Post, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sincePath []int64
+	if params.Since > 0 {
+		since, ok := s.posts[int64(params.Since)]
+		if !ok {
+			return []*models.Post{}, nil
+		}
+		sincePath = since.path
+	}
+
+	posts := s.threadPosts(params.ThreadId)
+	sort.Slice(posts, func(i, j int) bool {
+		cmp := comparePaths(posts[i].path, posts[j].path)
+		if params.Desc {
+			return cmp > 0
+		}
+		return cmp < 0
+	})
+
+	res := make([]*postRow, 0, params.Limit)
+	for _, p := range posts {
+		if len(res) == params.Limit {
+			break
+		}
+
+		if sincePath != nil {
+			cmp := comparePaths(p.path, sincePath)
+			if !params.Desc && cmp <= 0 {
+				continue
+			}
+			if params.Desc && cmp >= 0 {
+				continue
+			}
+		}
+
+		res = append(res, p)
+	}
+
+	return s.postModels(res), nil
+}
+
+func (repo *PostRepository) GetPostsParent(ctx context.Context, params *models.PostListParams) ([]*models.Post, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var sinceRoot int64
+	if params.Since > 0 {
+		since, ok := s.posts[int64(params.Since)]
+		if !ok {
+			return []*models.Post{}, nil
+		}
+		sinceRoot = since.path[0]
+	}
+
+	posts := s.threadPosts(params.ThreadId)
+
+	roots := []int64{}
+	children := map[int64][]*postRow{}
+	for _, p := range posts {
+		root := p.path[0]
+		if root == p.id {
+			roots = append(roots, root)
+			continue
+		}
+		children[root] = append(children[root], p)
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		if params.Desc {
+			return roots[i] > roots[j]
+		}
+		return roots[i] < roots[j]
+	})
+
+	res := []*postRow{}
+	taken := 0
+	for _, root := range roots {
+		if taken == params.Limit {
+			break
+		}
+
+		if sinceRoot > 0 {
+			if !params.Desc && root <= sinceRoot {
+				continue
+			}
+			if params.Desc && root >= sinceRoot {
+				continue
+			}
+		}
+
+		tree := children[root]
+		sort.Slice(tree, func(i, j int) bool {
+			return comparePaths(tree[i].path, tree[j].path) < 0
+		})
+
+		res = append(res, s.posts[root])
+		res = append(res, tree...)
+		taken++
+	}
+
+	return s.postModels(res), nil
+}
+
+func (repo *PostRepository) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return repository.PostNotFound(id)
+	}
+
+	p.hidden = hidden
+	return nil
+}
+
+func (repo *PostRepository) SetDeleted(ctx context.Context, id int64, deleted bool) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return repository.PostNotFound(id)
+	}
+
+	if p.deleted == deleted {
+		return nil
+	}
+	p.deleted = deleted
+
+	t := s.threads[p.threadId]
+	if t.deleted {
+		return nil
+	}
+
+	if deleted {
+		s.forums[t.forumId].posts--
+	} else {
+		s.forums[t.forumId].posts++
+	}
+	return nil
+}
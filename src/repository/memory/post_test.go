@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"techno-forum/src/models"
+	"testing"
+	"time"
+)
+
+func TestPostListing(t *testing.T) {
+	f := newFixture(t)
+	repo := NewPostRepo(f.store)
+
+	tests := []struct {
+		name  string
+		sort  int
+		since int
+		desc  bool
+		limit int
+		want  []int64
+	}{
+		{"flat", models.SortFlat, 0, false, 10, []int64{1, 2, 3, 4, 5, 6}},
+		{"flat since", models.SortFlat, 2, false, 3, []int64{3, 4, 5}},
+		{"flat desc since", models.SortFlat, 5, true, 2, []int64{4, 3}},
+		{"tree", models.SortTree, 0, false, 10, []int64{1, 3, 4, 6, 2, 5}},
+		{"tree desc", models.SortTree, 0, true, 10, []int64{5, 2, 6, 4, 3, 1}},
+		{"tree since", models.SortTree, 3, false, 3, []int64{4, 6, 2}},
+		{"tree desc since", models.SortTree, 6, true, 2, []int64{4, 3}},
+		{"tree unknown since", models.SortTree, 99, false, 10, []int64{}},
+		{"parent tree", models.SortParent, 0, false, 1, []int64{1, 3, 4, 6}},
+		{"parent tree desc", models.SortParent, 0, true, 1, []int64{2, 5}},
+		{"parent tree since", models.SortParent, 4, false, 10, []int64{2, 5}},
+		{"parent tree desc since", models.SortParent, 5, true, 10, []int64{1, 3, 4, 6}},
+	}
+
+	list := map[int]func(context.Context, *models.PostListParams) ([]*models.Post, error){
+		models.SortFlat:   repo.GetPostsFlat,
+		models.SortTree:   repo.GetPostsTree,
+		models.SortParent: repo.GetPostsParent,
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts, err := list[tt.sort](context.Background(), &models.PostListParams{
+				ThreadId: f.thread.Id,
+				Limit:    tt.limit,
+				Since:    tt.since,
+				Sort:     tt.sort,
+				Desc:     tt.desc,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := []int64{}
+			for _, p := range posts {
+				ids = append(ids, p.Id)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("got posts %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestPostRevisionTimes(t *testing.T) {
+	f := newFixture(t)
+	repo := NewPostRepo(f.store)
+	ctx := context.Background()
+
+	for _, message := range []string{"first edit", "second edit"} {
+		if err := repo.Update(ctx, &models.Post{Id: 1, Message: message}, f.users["alice"].Id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revs, err := repo.GetRevisions(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != 3 {
+		t.Fatalf("got %d revisions, want 3", len(revs))
+	}
+
+	for _, rev := range revs {
+		if tm, err := time.Parse(timeLayout, rev.Created); err != nil || tm.Format(timeLayout) != rev.Created {
+			t.Errorf("revision %d was created at %q, not in the post layout", rev.Number, rev.Created)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"techno-forum/src/models"
+)
+
+// SearchRepository stands in for Postgres full-text search: a document
+// matches when each query word starts one of its words, which is a crude
+// stemming. Query operators aren't supported.
+type SearchRepository struct {
+	store *Store
+}
+
+func NewSearchRepository(store *Store) *SearchRepository {
+	return &SearchRepository{
+		store: store,
+	}
+}
+
+type wordSpan struct {
+	start, end int
+}
+
+// splitWords returns the byte offsets of the words of text.
+func splitWords(text string) []wordSpan {
+	var spans []wordSpan
+	start := -1
+	for i, r := range text {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		switch {
+		case isWord && start < 0:
+			start = i
+		case !isWord && start >= 0:
+			spans = append(spans, wordSpan{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, wordSpan{start, len(text)})
+	}
+	return spans
+}
+
+func queryTerms(query string) []string {
+	var terms []string
+	for _, span := range splitWords(query) {
+		terms = append(terms, strings.ToLower(query[span.start:span.end]))
+	}
+	return terms
+}
+
+func matchesTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchText returns how many words of text match terms, and whether every
+// term matched.
+func matchText(text string, terms []string) (int, map[string]bool) {
+	found := map[string]bool{}
+	count := 0
+	for _, span := range splitWords(text) {
+		word := strings.ToLower(text[span.start:span.end])
+		for _, term := range terms {
+			if strings.HasPrefix(word, term) {
+				found[term] = true
+				count++
+				break
+			}
+		}
+	}
+	return count, found
+}
+
+// highlight marks the words of text matching terms and escapes the result
+// the way the postgres repository does.
+func highlight(text string, terms []string) string {
+	text = models.StripSearchMarks(text)
+
+	var sb strings.Builder
+	last := 0
+	for _, span := range splitWords(text) {
+		if !matchesTerm(text[span.start:span.end], terms) {
+			continue
+		}
+		sb.WriteString(text[last:span.start])
+		sb.WriteString(models.SearchMarkStart)
+		sb.WriteString(text[span.start:span.end])
+		sb.WriteString(models.SearchMarkStop)
+		last = span.end
+	}
+	sb.WriteString(text[last:])
+	return models.HighlightSnippet(sb.String())
+}
+
+// rankTexts scores texts by the share of their words matching terms, with
+// weights applied per text. It is zero unless every term matched somewhere.
+func rankTexts(terms []string, texts []string, weights []float64) float64 {
+	found := map[string]bool{}
+	var score float64
+	total := 0
+	for i, text := range texts {
+		count, matched := matchText(text, terms)
+		for term := range matched {
+			found[term] = true
+		}
+		score += weights[i] * float64(count)
+		total += len(splitWords(text))
+	}
+
+	if len(found) != len(terms) || total == 0 {
+		return 0
+	}
+	return score / float64(total)
+}
+
+func (repo *SearchRepository) Search(ctx context.Context, params *models.SearchParams) ([]*models.SearchHit, error) {
+	terms := queryTerms(params.Query)
+	if len(terms) == 0 {
+		return []*models.SearchHit{}, nil
+	}
+
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	inScope := func(forumId int, authorId int, created time.Time) bool {
+		if params.Forum != "" && key(s.forums[forumId].slug) != key(params.Forum) {
+			return false
+		}
+		if params.Author != "" && key(s.users[authorId].nickname) != key(params.Author) {
+			return false
+		}
+		if !params.Since.IsZero() {
+			if !params.Desc && created.Before(params.Since) {
+				return false
+			}
+			if params.Desc && created.After(params.Since) {
+				return false
+			}
+		}
+		return true
+	}
+
+	hits := []*models.SearchHit{}
+
+	if params.Type != models.SearchThread {
+		for _, p := range s.posts {
+			t := s.threads[p.threadId]
+			if p.deleted || p.hidden || t.deleted {
+				continue
+			}
+
+			created, _ := time.Parse(timeLayout, p.created)
+			if !inScope(t.forumId, p.authorId, created) {
+				continue
+			}
+
+			rank := rankTexts(terms, []string{p.message}, []float64{1})
+			if rank == 0 {
+				continue
+			}
+
+			hits = append(hits, &models.SearchHit{
+				Type:    models.SearchPost,
+				Rank:    rank,
+				Snippet: highlight(p.message, terms),
+				Post:    s.postModel(p),
+				Created: created,
+			})
+		}
+	}
+
+	if params.Type != models.SearchPost {
+		for _, t := range s.threads {
+			if t.deleted || !inScope(t.forumId, t.authorId, t.created) {
+				continue
+			}
+
+			rank := rankTexts(terms, []string{t.title, t.message}, []float64{1, 0.4})
+			if rank == 0 {
+				continue
+			}
+
+			hits = append(hits, &models.SearchHit{
+				Type:    models.SearchThread,
+				Rank:    rank,
+				Snippet: highlight(t.title+"\n"+t.message, terms),
+				Thread:  s.threadModel(t),
+				Created: t.created,
+			})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if params.Sort == models.SearchByRank && a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if params.Desc {
+			a, b = b, a
+		}
+		if !a.Created.Equal(b.Created) {
+			return a.Created.Before(b.Created)
+		}
+		return a.Type < b.Type || a.Type == b.Type && hitId(a) < hitId(b)
+	})
+
+	hits = hits[min(params.Offset, len(hits)):]
+	if len(hits) > params.Limit {
+		hits = hits[:params.Limit]
+	}
+
+	return hits, nil
+}
+
+func hitId(hit *models.SearchHit) int64 {
+	if hit.Post != nil {
+		return hit.Post.Id
+	}
+	return int64(hit.Thread.Id)
+}
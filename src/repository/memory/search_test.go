@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"techno-forum/src/models"
+	"testing"
+)
+
+// TestSearchOffset pages through the hits sorted by rank and checks that the
+// pages join to the unpaged result.
+func TestSearchOffset(t *testing.T) {
+	f := newFixture(t)
+	repo := NewSearchRepository(f.store)
+
+	search := func(offset, limit int) []int64 {
+		t.Helper()
+		hits, err := repo.Search(context.Background(), &models.SearchParams{
+			Query:  "reply",
+			Limit:  limit,
+			Offset: offset,
+			Sort:   models.SearchByRank,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids := []int64{}
+		for _, hit := range hits {
+			ids = append(ids, hitId(hit))
+		}
+		return ids
+	}
+
+	all := search(0, 100)
+	if len(all) != 6 {
+		t.Fatalf("got hits %v, want the 6 posts", all)
+	}
+
+	tests := []struct {
+		name   string
+		offset int
+		limit  int
+		want   []int64
+	}{
+		{"first page", 0, 4, all[:4]},
+		{"second page", 4, 4, all[4:]},
+		{"past the end", 6, 4, []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := search(tt.offset, tt.limit); !slices.Equal(got, tt.want) {
+				t.Errorf("got hits %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"techno-forum/src/models"
+)
+
+type ServiceRepository struct {
+	store *Store
+}
+
+func NewServiceRepo(store *Store) *ServiceRepository {
+	return &ServiceRepository{
+		store: store,
+	}
+}
+
+func (repo *ServiceRepository) Clear(ctx context.Context) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+	return nil
+}
+
+func (repo *ServiceRepository) Status(ctx context.Context) (*models.ServiceInfo, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := &models.ServiceInfo{
+		User:  uint64(len(s.users)),
+		Forum: uint64(len(s.forums)),
+	}
+
+	for _, f := range s.forums {
+		res.Thread += uint64(f.threads)
+		res.Post += uint64(f.posts)
+	}
+
+	return res, nil
+}
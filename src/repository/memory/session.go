@@ -0,0 +1,83 @@
+package memory
+
+import (
+	"context"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"time"
+)
+
+type SessionRepository struct {
+	store *Store
+}
+
+func NewSessionRepository(store *Store) *SessionRepository {
+	return &SessionRepository{
+		store: store,
+	}
+}
+
+func (repo *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.users[session.UserId]; !ok {
+		return repository.UserNotFound(session.User.Nickname)
+	}
+
+	session.Created = time.Now()
+	s.sessions[string(session.TokenHash)] = &sessionRow{
+		userId:  session.UserId,
+		created: session.Created,
+		expires: session.Expires,
+	}
+	return nil
+}
+
+func (repo *SessionRepository) Get(ctx context.Context, tokenHash []byte) (*models.Session, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row, ok := s.sessions[string(tokenHash)]
+	if !ok || !row.expires.After(time.Now()) {
+		return nil, repository.ErrNoSession
+	}
+
+	user, ok := s.users[row.userId]
+	if !ok {
+		return nil, repository.ErrNoSession
+	}
+
+	return &models.Session{
+		TokenHash: tokenHash,
+		UserId:    row.userId,
+		User:      user.toModel(),
+		Created:   row.created,
+		Expires:   row.expires,
+	}, nil
+}
+
+func (repo *SessionRepository) Delete(ctx context.Context, tokenHash []byte) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, string(tokenHash))
+	return nil
+}
+
+func (repo *SessionRepository) DeleteExpired(ctx context.Context) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for hash, row := range s.sessions {
+		if !row.expires.After(now) {
+			delete(s.sessions, hash)
+		}
+	}
+	return nil
+}
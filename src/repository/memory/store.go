@@ -0,0 +1,349 @@
+package memory
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"sync"
+	"techno-forum/src/events"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"time"
+
+	"github.com/tee8z/nullable"
+)
+
+const timeLayout = "2006-01-02T15:04:05.000Z"
+
+type userRow struct {
+	id       int
+	nickname string
+	fullname string
+	about    string
+	email    string
+	password string
+	role     string
+}
+
+type sessionRow struct {
+	userId  int
+	created time.Time
+	expires time.Time
+}
+
+type forumRow struct {
+	id       int
+	title    string
+	slug     string
+	authorId int
+	posts    int
+	threads  int
+}
+
+type threadRow struct {
+	id          int
+	title       string
+	message     string
+	messageHTML string
+	votes       int
+	slug        nullable.String
+	created     time.Time
+	forumId     int
+	authorId    int
+	locked      bool
+	deleted     bool
+}
+
+type postRow struct {
+	id          int64
+	message     string
+	messageHTML string
+	edited      bool
+	created     string
+	path        []int64
+	parent      nullable.Int64
+	authorId    int
+	threadId    int
+	hidden      bool
+	deleted     bool
+}
+
+type attachmentRow struct {
+	id          int64
+	postId      int64
+	uploaderId  int
+	name        string
+	contentType string
+	size        int64
+	sha256      string
+	key         string
+	created     time.Time
+}
+
+type revisionRow struct {
+	number   int
+	editorId int
+	created  time.Time
+	title    string
+	message  string
+}
+
+type banRow struct {
+	byId    int
+	reason  string
+	created time.Time
+	expires *time.Time
+}
+
+func (b *banRow) active(now time.Time) bool {
+	return b.expires == nil || b.expires.After(now)
+}
+
+type notificationRow struct {
+	id      int64
+	userId  int
+	typ     string
+	postId  int64
+	created time.Time
+	read    bool
+}
+
+type subscriptionRow struct {
+	level   string
+	created time.Time
+}
+
+type digestRow struct {
+	frequency  string
+	token      string
+	next       time.Time
+	lastPostId int64
+}
+
+type webhookRow struct {
+	id      int
+	forumId int
+	url     string
+	events  []string
+	secret  string
+	byId    int
+	created time.Time
+}
+
+func (w *webhookRow) wants(forumId int, typ string) bool {
+	if w.forumId != 0 && w.forumId != forumId {
+		return false
+	}
+	return len(w.events) == 0 || slices.Contains(w.events, typ)
+}
+
+type outboxRow struct {
+	typ     string
+	forumId int
+	payload []byte
+}
+
+type deliveryRow struct {
+	id          int64
+	webhookId   int
+	typ         string
+	payload     []byte
+	status      string
+	attempts    int
+	nextAttempt time.Time
+	lastStatus  int
+	lastError   string
+	created     time.Time
+	delivered   *time.Time
+}
+
+// forumUserKey identifies a user within a forum, for bans.
+type forumUserKey struct {
+	forumId int
+	userId  int
+}
+
+type voteKey struct {
+	userId   int
+	threadId int
+}
+
+// Store holds the state shared by all in-memory repositories. Every
+// repository built on top of the same Store sees the same data, the way
+// Postgres repositories share one database.
+type Store struct {
+	mu sync.RWMutex
+
+	lastUserId   int
+	lastForumId  int
+	lastThreadId int
+	lastPostId   int64
+
+	lastNotificationId int64
+	lastAttachmentId   int64
+	lastWebhookId      int
+	lastDeliveryId     int64
+
+	users       map[int]*userRow
+	userByNick  map[string]int
+	userByEmail map[string]int
+
+	forums      map[int]*forumRow
+	forumBySlug map[string]int
+	forumUsers  map[int]map[int]struct{}
+	// moderators maps forum ids to the ids of their moderators.
+	moderators map[int]map[int]struct{}
+
+	threads      map[int]*threadRow
+	threadBySlug map[string]int
+
+	posts         map[int64]*postRow
+	threadPostIds map[int][]int64
+
+	attachments map[int64]*attachmentRow
+
+	votes map[voteKey]int
+
+	// userNotifications holds the notifications of each user, oldest first.
+	userNotifications map[int][]*notificationRow
+	// threadSubscriptions maps thread ids to the subscriptions by user id.
+	threadSubscriptions map[int]map[int]*subscriptionRow
+	// forumSubscriptions maps forum ids to the subscription times by user id.
+	forumSubscriptions map[int]map[int]time.Time
+
+	digests       map[int]*digestRow
+	digestByToken map[string]int
+
+	postRevisions   map[int64][]*revisionRow
+	threadRevisions map[int][]*revisionRow
+
+	bans map[forumUserKey]*banRow
+
+	webhooks   map[int]*webhookRow
+	outbox     []*outboxRow
+	deliveries map[int64]*deliveryRow
+
+	// sessions are keyed by string(tokenHash).
+	sessions map[string]*sessionRow
+}
+
+func NewStore() *Store {
+	store := &Store{}
+	store.reset()
+	return store
+}
+
+func (s *Store) reset() {
+	s.users = map[int]*userRow{}
+	s.userByNick = map[string]int{}
+	s.userByEmail = map[string]int{}
+	s.forums = map[int]*forumRow{}
+	s.forumBySlug = map[string]int{}
+	s.forumUsers = map[int]map[int]struct{}{}
+	s.moderators = map[int]map[int]struct{}{}
+	s.threads = map[int]*threadRow{}
+	s.threadBySlug = map[string]int{}
+	s.posts = map[int64]*postRow{}
+	s.threadPostIds = map[int][]int64{}
+	s.attachments = map[int64]*attachmentRow{}
+	s.votes = map[voteKey]int{}
+	s.userNotifications = map[int][]*notificationRow{}
+	s.threadSubscriptions = map[int]map[int]*subscriptionRow{}
+	s.forumSubscriptions = map[int]map[int]time.Time{}
+	s.digests = map[int]*digestRow{}
+	s.digestByToken = map[string]int{}
+	s.postRevisions = map[int64][]*revisionRow{}
+	s.threadRevisions = map[int][]*revisionRow{}
+	s.bans = map[forumUserKey]*banRow{}
+	s.webhooks = map[int]*webhookRow{}
+	s.outbox = nil
+	s.deliveries = map[int64]*deliveryRow{}
+	s.sessions = map[string]*sessionRow{}
+}
+
+// addRevision appends a revision by editorId to revs, starting with original
+// when there are none yet.
+func addRevision(revs []*revisionRow, original *revisionRow, editorId int, title, message string) []*revisionRow {
+	if len(revs) == 0 {
+		revs = append(revs, original)
+	}
+	return append(revs, &revisionRow{
+		number:   len(revs) + 1,
+		editorId: editorId,
+		created:  time.Now(),
+		title:    title,
+		message:  message,
+	})
+}
+
+func (s *Store) revisionModels(revs []*revisionRow) []*models.Revision {
+	res := make([]*models.Revision, 0, len(revs))
+	for _, r := range revs {
+		rev := &models.Revision{
+			Number:  r.number,
+			Created: r.created.UTC().Format(timeLayout),
+			Title:   r.title,
+			Message: r.message,
+		}
+		if editor, ok := s.users[r.editorId]; ok {
+			rev.Editor = editor.nickname
+		}
+		res = append(res, rev)
+	}
+	return res
+}
+
+// writeOutbox queues the events of the forum for the webhooks that want
+// them.
+func (s *Store) writeOutbox(forumId int, evs ...*events.Event) {
+	for _, ev := range evs {
+		wanted := false
+		for _, w := range s.webhooks {
+			if w.wants(forumId, ev.Type) {
+				wanted = true
+				break
+			}
+		}
+		if !wanted {
+			continue
+		}
+
+		payload, err := json.Marshal(ev)
+		if err != nil {
+			continue
+		}
+		s.outbox = append(s.outbox, &outboxRow{typ: ev.Type, forumId: forumId, payload: payload})
+	}
+}
+
+func key(s string) string {
+	return strings.ToLower(s)
+}
+
+func (s *Store) linkUserToForum(userId, forumId int) {
+	users, ok := s.forumUsers[forumId]
+	if !ok {
+		users = map[int]struct{}{}
+		s.forumUsers[forumId] = users
+	}
+	users[userId] = struct{}{}
+}
+
+func NewRepositories(store *Store) *repository.Repositories {
+	return &repository.Repositories{
+		Users:         NewUserRepo(store),
+		Forums:        NewForumRepository(store),
+		Threads:       NewThreadRepository(store),
+		Posts:         NewPostRepo(store),
+		Attachments:   NewAttachmentRepository(store),
+		Votes:         NewVoteRepository(store),
+		Notifications: NewNotificationRepository(store),
+		Subscriptions: NewSubscriptionRepository(store),
+		Digests:       NewDigestRepository(store),
+		Bans:          NewBanRepository(store),
+		Search:        NewSearchRepository(store),
+		Webhooks:      NewWebhookRepository(store),
+		Sessions:      NewSessionRepository(store),
+		Service:       NewServiceRepo(store),
+	}
+}
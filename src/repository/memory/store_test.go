@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"techno-forum/src/models"
+	"testing"
+
+	"github.com/tee8z/nullable"
+)
+
+// fixture is a forum F1 by alice with thread T1 by carol. The posts of the
+// thread form the tree
+//
+//	1 alice
+//	├── 3 Bob
+//	│   └── 4 alice
+//	└── 6 Bob
+//	2 Bob
+//	└── 5 alice
+type fixture struct {
+	store  *Store
+	forum  *models.Forum
+	thread *models.Thread
+	users  map[string]*models.User
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	ctx := context.Background()
+
+	f := &fixture{store: NewStore(), users: map[string]*models.User{}}
+	users := NewUserRepo(f.store)
+	for _, nickname := range []string{"alice", "Bob", "carol"} {
+		user := &models.User{Nickname: nickname, Fullname: nickname, Email: nickname + "@example.com"}
+		if _, err := users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		f.users[nickname] = user
+	}
+
+	f.forum = &models.Forum{Title: "Forum", Slug: "F1"}
+	if err := NewForumRepository(f.store).Create(ctx, f.forum, f.users["alice"].Id); err != nil {
+		t.Fatal(err)
+	}
+
+	slug := "T1"
+	f.thread = &models.Thread{Title: "Thread", Message: "message", Slug: nullable.NewString(&slug), Forum: f.forum.Slug}
+	if err := NewThreadRepository(f.store).Create(ctx, f.thread, f.users["carol"].Id, f.forum.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	f.addPosts(t, post("alice", 0), post("Bob", 0))
+	f.addPosts(t, post("Bob", 1))
+	f.addPosts(t, post("alice", 3), post("alice", 2), post("Bob", 1))
+	return f
+}
+
+func post(author string, parent int64) *models.Post {
+	p := &models.Post{Author: author, Message: "reply to " + author}
+	if parent != 0 {
+		p.Parent = nullable.NewInt64(&parent)
+	}
+	return p
+}
+
+func (f *fixture) addPosts(t *testing.T, posts ...*models.Post) {
+	t.Helper()
+	if err := NewPostRepo(f.store).AddPosts(context.Background(), f.thread, posts); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type SubscriptionRepository struct {
+	store *Store
+}
+
+func NewSubscriptionRepository(store *Store) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		store: store,
+	}
+}
+
+// watchThread subscribes the author of a new thread to it.
+func (s *Store) watchThread(threadId int, authorId int) {
+	s.threadSubscriptions[threadId] = map[int]*subscriptionRow{
+		authorId: {level: models.SubscriptionWatching, created: time.Now()},
+	}
+}
+
+// trackThread makes the authors of new posts track the thread, unless they
+// are subscribed to it already.
+func (s *Store) trackThread(threadId int, authorIds map[string]int) {
+	subs, ok := s.threadSubscriptions[threadId]
+	if !ok {
+		subs = map[int]*subscriptionRow{}
+		s.threadSubscriptions[threadId] = subs
+	}
+
+	now := time.Now()
+	for _, id := range authorIds {
+		if _, ok := subs[id]; !ok {
+			subs[id] = &subscriptionRow{level: models.SubscriptionTracking, created: now}
+		}
+	}
+}
+
+func (repo *SubscriptionRepository) Get(ctx context.Context, threadId int, userId int) (*models.Subscription, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.threadSubscriptions[threadId][userId]
+	if !ok {
+		return nil, repository.ErrNoSubscription
+	}
+
+	return &models.Subscription{
+		User:     s.users[userId].nickname,
+		UserId:   userId,
+		ThreadId: threadId,
+		Level:    sub.level,
+		Created:  sub.created,
+	}, nil
+}
+
+func (repo *SubscriptionRepository) Set(ctx context.Context, sub *models.Subscription) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.threads[sub.ThreadId]; !ok {
+		return repository.ThreadNotFound(strconv.Itoa(sub.ThreadId))
+	}
+
+	subs, ok := s.threadSubscriptions[sub.ThreadId]
+	if !ok {
+		subs = map[int]*subscriptionRow{}
+		s.threadSubscriptions[sub.ThreadId] = subs
+	}
+
+	row, ok := subs[sub.UserId]
+	if !ok {
+		row = &subscriptionRow{created: time.Now()}
+		subs[sub.UserId] = row
+	}
+	row.level = sub.Level
+	sub.Created = row.created
+	return nil
+}
+
+func (repo *SubscriptionRepository) Delete(ctx context.Context, threadId int, userId int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.threadSubscriptions[threadId][userId]; !ok {
+		return repository.ErrNoSubscription
+	}
+	delete(s.threadSubscriptions[threadId], userId)
+	return nil
+}
+
+func (repo *SubscriptionRepository) GetForum(ctx context.Context, forumId int, userId int) (*models.ForumSubscription, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	created, ok := s.forumSubscriptions[forumId][userId]
+	if !ok {
+		return nil, repository.ErrNoForumSub
+	}
+
+	return &models.ForumSubscription{
+		User:    s.users[userId].nickname,
+		UserId:  userId,
+		Forum:   s.forums[forumId].slug,
+		ForumId: forumId,
+		Created: created,
+	}, nil
+}
+
+func (repo *SubscriptionRepository) SetForum(ctx context.Context, sub *models.ForumSubscription) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs, ok := s.forumSubscriptions[sub.ForumId]
+	if !ok {
+		subs = map[int]time.Time{}
+		s.forumSubscriptions[sub.ForumId] = subs
+	}
+
+	created, ok := subs[sub.UserId]
+	if !ok {
+		created = time.Now()
+		subs[sub.UserId] = created
+	}
+	sub.Created = created
+	return nil
+}
+
+func (repo *SubscriptionRepository) DeleteForum(ctx context.Context, forumId int, userId int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.forumSubscriptions[forumId][userId]; !ok {
+		return repository.ErrNoForumSub
+	}
+	delete(s.forumSubscriptions[forumId], userId)
+	return nil
+}
@@ -0,0 +1,300 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"techno-forum/src/events"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type ThreadRepository struct {
+	store *Store
+}
+
+func NewThreadRepository(store *Store) *ThreadRepository {
+	return &ThreadRepository{
+		store: store,
+	}
+}
+
+func (s *Store) threadModel(t *threadRow) *models.Thread {
+	return &models.Thread{
+		Id:      t.id,
+		Title:   t.title,
+		Author:  s.users[t.authorId].nickname,
+		Forum:   s.forums[t.forumId].slug,
+		ForumId: t.forumId,
+		Message: t.message,
+		Votes:   t.votes,
+		Slug:    t.slug,
+		Created: t.created.Format(timeLayout),
+		Locked:  t.locked,
+		Deleted: t.deleted,
+	}
+}
+
+func (s *Store) threadBySlugKey(slug string) (*threadRow, bool) {
+	id, ok := s.threadBySlug[key(slug)]
+	if !ok {
+		return nil, false
+	}
+	return s.threads[id], true
+}
+
+func (repo *ThreadRepository) GetBySlug(ctx context.Context, slug string) (*models.Thread, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.threadBySlugKey(slug)
+	if !ok {
+		return nil, repository.ThreadNotFound(slug)
+	}
+
+	return s.threadModel(t), nil
+}
+
+func (repo *ThreadRepository) GetById(ctx context.Context, id string) (*models.Thread, error) {
+	threadId, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, repository.ThreadNotFound(id)
+	}
+
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.threads[threadId]
+	if !ok {
+		return nil, repository.ThreadNotFound(id)
+	}
+
+	return s.threadModel(t), nil
+}
+
+func parseSince(since string) (time.Time, error) {
+	if since == "" {
+		return time.Time{}, nil
+	}
+
+	tm, err := time.Parse("2006-01-02T15:04:05.000-07:00", since)
+	if err != nil {
+		tm, err = time.Parse(timeLayout, since)
+	}
+	if err != nil {
+		return tm, repository.InvalidTimestamp(since)
+	}
+	return tm.UTC(), nil
+}
+
+func (repo *ThreadRepository) GetByForum(ctx context.Context, forumId int, since string, desc bool, limit int) ([]*models.Thread, error) {
+	tm, err := parseSince(since)
+	if err != nil {
+		return nil, err
+	}
+
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := []*threadRow{}
+	for _, t := range s.threads {
+		if t.forumId != forumId || t.deleted {
+			continue
+		}
+
+		if since != "" {
+			if !desc && t.created.Before(tm) {
+				continue
+			}
+			if desc && t.created.After(tm) {
+				continue
+			}
+		}
+
+		found = append(found, t)
+	}
+
+	sort.Slice(found, func(i, j int) bool {
+		a, b := found[i], found[j]
+		if desc {
+			a, b = b, a
+		}
+		if !a.created.Equal(b.created) {
+			return a.created.Before(b.created)
+		}
+		return a.id < b.id
+	})
+
+	if len(found) > limit {
+		found = found[:limit]
+	}
+
+	res := make([]*models.Thread, 0, len(found))
+	for _, t := range found {
+		res = append(res, s.threadModel(t))
+	}
+
+	return res, nil
+}
+
+func (repo *ThreadRepository) Create(ctx context.Context, thread *models.Thread, author_id int, forum_id int) error {
+	created := time.Now().UTC()
+
+	if thread.Created != "" {
+		var err error
+		created, err = time.Parse("2006-01-02T15:04:05.000-07:00", thread.Created)
+		if err != nil {
+			return repository.InvalidTimestamp(thread.Created)
+		}
+		created = created.UTC()
+	}
+
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if slug := thread.Slug.Get(); slug != nil {
+		if t, ok := s.threadBySlugKey(*slug); ok {
+			*thread = *s.threadModel(t)
+			return models.ErrAlreadyExists
+		}
+	}
+
+	f, ok := s.forums[forum_id]
+	if !ok {
+		return models.NewError(models.ErrNotFound, "can't find thread forum")
+	}
+
+	if _, ok := s.users[author_id]; !ok {
+		return models.NewError(models.ErrNotFound, "can't find thread author")
+	}
+
+	s.lastThreadId++
+	t := &threadRow{
+		id:          s.lastThreadId,
+		title:       thread.Title,
+		message:     thread.Message,
+		messageHTML: thread.MessageHTML,
+		slug:        thread.Slug,
+		created:     created,
+		forumId:     forum_id,
+		authorId:    author_id,
+	}
+
+	s.threads[t.id] = t
+	if slug := t.slug.Get(); slug != nil {
+		s.threadBySlug[key(*slug)] = t.id
+	}
+
+	f.threads++
+	s.linkUserToForum(author_id, forum_id)
+	s.watchThread(t.id, author_id)
+
+	thread.Id = t.id
+	thread.ForumId = forum_id
+	thread.Created = created.Format(timeLayout)
+
+	model := s.threadModel(t)
+	model.MessageHTML = t.messageHTML
+	s.writeOutbox(forum_id, &events.Event{Type: events.ThreadCreated, ThreadId: t.id, Thread: model})
+
+	return nil
+}
+
+func (repo *ThreadRepository) Update(ctx context.Context, thread *models.Thread, editorId int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[thread.Id]
+	if !ok {
+		return repository.ThreadNotFound(strconv.Itoa(thread.Id))
+	}
+
+	if thread.Title == t.title && thread.Message == t.message {
+		return nil
+	}
+
+	original := &revisionRow{number: 1, editorId: t.authorId, created: t.created, title: t.title, message: t.message}
+	s.threadRevisions[t.id] = addRevision(s.threadRevisions[t.id], original, editorId, thread.Title, thread.Message)
+
+	t.title = thread.Title
+	t.message = thread.Message
+	t.messageHTML = thread.MessageHTML
+
+	return nil
+}
+
+func (repo *ThreadRepository) GetMessagesHTML(ctx context.Context, ids []int) (map[int]string, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make(map[int]string, len(ids))
+	for _, id := range ids {
+		if t, ok := s.threads[id]; ok && t.messageHTML != "" {
+			res[id] = t.messageHTML
+		}
+	}
+	return res, nil
+}
+
+func (repo *ThreadRepository) GetRevisions(ctx context.Context, id int) ([]*models.Revision, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.revisionModels(s.threadRevisions[id]), nil
+}
+
+func (repo *ThreadRepository) SetLocked(ctx context.Context, id int, locked bool) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[id]
+	if !ok {
+		return repository.ThreadNotFound(strconv.Itoa(id))
+	}
+
+	t.locked = locked
+	return nil
+}
+
+func (repo *ThreadRepository) SetDeleted(ctx context.Context, id int, deleted bool) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[id]
+	if !ok {
+		return repository.ThreadNotFound(strconv.Itoa(id))
+	}
+
+	if t.deleted == deleted {
+		return nil
+	}
+	t.deleted = deleted
+
+	posts := 0
+	for _, p := range s.threadPosts(id) {
+		if !p.deleted {
+			posts++
+		}
+	}
+
+	f := s.forums[t.forumId]
+	if deleted {
+		f.threads--
+		f.posts -= posts
+	} else {
+		f.threads++
+		f.posts += posts
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"techno-forum/src/models"
+	"testing"
+)
+
+func TestThreadSlugCase(t *testing.T) {
+	f := newFixture(t)
+
+	thread, err := NewThreadRepository(f.store).GetBySlug(context.Background(), "t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Id != f.thread.Id {
+		t.Errorf("got thread %d, want %d", thread.Id, f.thread.Id)
+	}
+}
+
+func TestThreadsByForum(t *testing.T) {
+	f := newFixture(t)
+	repo := NewThreadRepository(f.store)
+	ctx := context.Background()
+
+	// T1 of the fixture is created now, after these.
+	for _, created := range []string{
+		"2020-01-02T00:00:00.000+03:00",
+		"2020-01-01T00:00:00.000+03:00",
+		"2020-01-03T00:00:00.000+03:00",
+	} {
+		thread := &models.Thread{Title: created, Created: created}
+		if err := repo.Create(ctx, thread, f.users["alice"].Id, f.forum.Id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		since string
+		desc  bool
+		limit int
+		want  []int
+	}{
+		{"all", "", false, 10, []int{3, 2, 4, 1}},
+		{"desc", "", true, 2, []int{1, 4}},
+		{"since", "2020-01-02T00:00:00.000+03:00", false, 10, []int{2, 4, 1}},
+		{"since utc", "2020-01-01T21:00:00.000Z", false, 2, []int{2, 4}},
+		{"desc since", "2020-01-02T00:00:00.000+03:00", true, 10, []int{2, 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threads, err := repo.GetByForum(ctx, f.forum.Id, tt.since, tt.desc, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ids := []int{}
+			for _, thread := range threads {
+				ids = append(ids, thread.Id)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("got threads %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestThreadCreateWatches(t *testing.T) {
+	f := newFixture(t)
+
+	sub, err := NewSubscriptionRepository(f.store).Get(context.Background(), f.thread.Id, f.users["carol"].Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sub.Level != models.SubscriptionWatching {
+		t.Errorf("got level %s, want %s", sub.Level, models.SubscriptionWatching)
+	}
+}
@@ -0,0 +1,205 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type UserRepository struct {
+	store *Store
+}
+
+func NewUserRepo(store *Store) *UserRepository {
+	return &UserRepository{
+		store: store,
+	}
+}
+
+func (u *userRow) toModel() *models.User {
+	return &models.User{
+		Id:       u.id,
+		Nickname: u.nickname,
+		Fullname: u.fullname,
+		About:    u.about,
+		Email:    u.email,
+
+		PasswordHash: u.password,
+		Role:         u.role,
+	}
+}
+
+func (repo *UserRepository) Create(ctx context.Context, profile *models.User) ([]*models.User, error) {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	byNick, nickTaken := s.userByNick[key(profile.Nickname)]
+	byEmail, emailTaken := s.userByEmail[key(profile.Email)]
+
+	if nickTaken || emailTaken {
+		users := []*models.User{}
+		if nickTaken {
+			users = append(users, s.users[byNick].toModel())
+		}
+		if emailTaken && (!nickTaken || byEmail != byNick) {
+			users = append(users, s.users[byEmail].toModel())
+		}
+		sort.Slice(users, func(i, j int) bool { return users[i].Id < users[j].Id })
+		return users, models.ErrAlreadyExists
+	}
+
+	s.lastUserId++
+	u := &userRow{
+		id:       s.lastUserId,
+		nickname: profile.Nickname,
+		fullname: profile.Fullname,
+		about:    profile.About,
+		email:    profile.Email,
+		password: profile.PasswordHash,
+		role:     models.RoleUser,
+	}
+
+	s.users[u.id] = u
+	s.userByNick[key(u.nickname)] = u.id
+	s.userByEmail[key(u.email)] = u.id
+	profile.Id = u.id
+
+	return nil, nil
+}
+
+func (repo *UserRepository) GetByNickName(ctx context.Context, nickname string) (*models.User, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	id, ok := s.userByNick[key(nickname)]
+	if !ok {
+		return nil, repository.UserNotFound(nickname)
+	}
+
+	return s.users[id].toModel(), nil
+}
+
+func (repo *UserRepository) GetByNickNames(ctx context.Context, nicknames []string) ([]*models.User, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	seen := map[int]bool{}
+	users := []*models.User{}
+	for _, nickname := range nicknames {
+		id, ok := s.userByNick[key(nickname)]
+		if !ok || seen[id] {
+			continue
+		}
+		seen[id] = true
+		users = append(users, s.users[id].toModel())
+	}
+
+	return users, nil
+}
+
+func (repo *UserRepository) GetByForum(ctx context.Context, forumId int, limit int, since string, desc bool) ([]*models.User, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := []*models.User{}
+	for id := range s.forumUsers[forumId] {
+		u := s.users[id]
+
+		if since != "" {
+			if !desc && key(u.nickname) <= key(since) {
+				continue
+			}
+			if desc && key(u.nickname) >= key(since) {
+				continue
+			}
+		}
+
+		users = append(users, u.toModel())
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		if desc {
+			return key(users[i].Nickname) > key(users[j].Nickname)
+		}
+		return key(users[i].Nickname) < key(users[j].Nickname)
+	})
+
+	if len(users) > limit {
+		users = users[:limit]
+	}
+
+	return users, nil
+}
+
+func (repo *UserRepository) Update(ctx context.Context, profile *models.User) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.userByNick[key(profile.Nickname)]
+	if !ok {
+		return repository.UserNotFound(profile.Nickname)
+	}
+	u := s.users[id]
+
+	if profile.Nickname == "" {
+		profile.Nickname = u.nickname
+	}
+
+	if profile.Fullname == "" {
+		profile.Fullname = u.fullname
+	}
+
+	if profile.About == "" {
+		profile.About = u.about
+	}
+
+	if profile.Email == "" {
+		profile.Email = u.email
+	}
+
+	if other, ok := s.userByNick[key(profile.Nickname)]; ok && other != u.id {
+		return repository.ErrUserConflict
+	}
+
+	if other, ok := s.userByEmail[key(profile.Email)]; ok && other != u.id {
+		return repository.ErrUserConflict
+	}
+
+	delete(s.userByNick, key(u.nickname))
+	delete(s.userByEmail, key(u.email))
+
+	u.nickname = profile.Nickname
+	u.fullname = profile.Fullname
+	u.about = profile.About
+	u.email = profile.Email
+	if profile.PasswordHash != "" {
+		u.password = profile.PasswordHash
+	}
+	profile.PasswordHash = u.password
+
+	s.userByNick[key(u.nickname)] = u.id
+	s.userByEmail[key(u.email)] = u.id
+
+	return nil
+}
+
+func (repo *UserRepository) SetRole(ctx context.Context, nickname string, role string) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id, ok := s.userByNick[key(nickname)]
+	if !ok {
+		return repository.UserNotFound(nickname)
+	}
+
+	s.users[id].role = role
+	return nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"techno-forum/src/models"
+	"testing"
+)
+
+func TestUserNicknameCase(t *testing.T) {
+	f := newFixture(t)
+	repo := NewUserRepo(f.store)
+	ctx := context.Background()
+
+	for _, nickname := range []string{"bob", "BOB", "Bob"} {
+		user, err := repo.GetByNickName(ctx, nickname)
+		if err != nil {
+			t.Fatalf("%s: %v", nickname, err)
+		}
+		if user.Nickname != "Bob" {
+			t.Errorf("%s: got nickname %s, want Bob", nickname, user.Nickname)
+		}
+	}
+
+	conflicts, err := repo.Create(ctx, &models.User{Nickname: "ALICE", Email: "other@example.com"})
+	if !errors.Is(err, models.ErrAlreadyExists) {
+		t.Fatalf("got error %v, want %v", err, models.ErrAlreadyExists)
+	}
+	if len(conflicts) != 1 || conflicts[0].Nickname != "alice" {
+		t.Errorf("got conflicts %v, want alice", conflicts)
+	}
+}
+
+func TestUsersByForum(t *testing.T) {
+	f := newFixture(t)
+	repo := NewUserRepo(f.store)
+
+	tests := []struct {
+		name  string
+		since string
+		desc  bool
+		limit int
+		want  []string
+	}{
+		{"all", "", false, 10, []string{"alice", "Bob", "carol"}},
+		{"desc", "", true, 10, []string{"carol", "Bob", "alice"}},
+		{"limit", "", false, 2, []string{"alice", "Bob"}},
+		{"since", "bob", false, 10, []string{"carol"}},
+		{"desc since", "CAROL", true, 10, []string{"Bob", "alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, err := repo.GetByForum(context.Background(), f.forum.Id, tt.limit, tt.since, tt.desc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			nicknames := []string{}
+			for _, u := range users {
+				nicknames = append(nicknames, u.Nickname)
+			}
+			if !slices.Equal(nicknames, tt.want) {
+				t.Errorf("got users %v, want %v", nicknames, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"techno-forum/src/events"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type VoteRepository struct {
+	store *Store
+}
+
+func NewVoteRepository(store *Store) *VoteRepository {
+	return &VoteRepository{
+		store: store,
+	}
+}
+
+func (repo *VoteRepository) Vote(ctx context.Context, vote *models.Vote) error {
+	if vote.Value != 1 && vote.Value != -1 {
+		return models.NewError(models.ErrValidation, "voice must be 1 or -1, got %d", vote.Value)
+	}
+
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[vote.ThreadId]
+	if !ok {
+		return repository.ErrVoteNotFound
+	}
+
+	if _, ok := s.users[vote.UserId]; !ok {
+		return repository.ErrVoteNotFound
+	}
+
+	k := voteKey{userId: vote.UserId, threadId: vote.ThreadId}
+	t.votes += vote.Value - s.votes[k]
+	s.votes[k] = vote.Value
+
+	s.writeOutbox(t.forumId, &events.Event{Type: events.ThreadVoted, ThreadId: t.id, Thread: s.threadModel(t)})
+
+	return nil
+}
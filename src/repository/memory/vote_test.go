@@ -0,0 +1,43 @@
+package memory
+
+import (
+	"context"
+	"strconv"
+	"techno-forum/src/models"
+	"testing"
+)
+
+func TestVoteUpsert(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	votes := NewVoteRepository(f.store)
+	threads := NewThreadRepository(f.store)
+
+	tests := []struct {
+		voter string
+		value int
+		want  int
+	}{
+		{"alice", 1, 1},
+		{"alice", 1, 1},
+		{"alice", -1, -1},
+		{"Bob", -1, -2},
+		{"carol", 1, -1},
+		{"Bob", 1, 1},
+	}
+
+	for i, tt := range tests {
+		err := votes.Vote(ctx, &models.Vote{UserId: f.users[tt.voter].Id, ThreadId: f.thread.Id, Value: tt.value})
+		if err != nil {
+			t.Fatalf("vote %d: %v", i, err)
+		}
+
+		thread, err := threads.GetById(ctx, strconv.Itoa(f.thread.Id))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if thread.Votes != tt.want {
+			t.Errorf("vote %d by %s: got %d votes, want %d", i, tt.voter, thread.Votes, tt.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package memory
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+)
+
+type WebhookRepository struct {
+	store *Store
+}
+
+func NewWebhookRepository(store *Store) *WebhookRepository {
+	return &WebhookRepository{
+		store: store,
+	}
+}
+
+func (s *Store) webhookModel(w *webhookRow) *models.Webhook {
+	hook := &models.Webhook{
+		Id:      w.id,
+		ForumId: w.forumId,
+		URL:     w.url,
+		Events:  slices.Clone(w.events),
+		ById:    w.byId,
+		Created: w.created,
+	}
+	if f, ok := s.forums[w.forumId]; ok {
+		hook.Forum = f.slug
+	}
+	if by, ok := s.users[w.byId]; ok {
+		hook.By = by.nickname
+	}
+	return hook
+}
+
+func deliveryModel(d *deliveryRow) *models.WebhookDelivery {
+	delivery := &models.WebhookDelivery{
+		Id:         d.id,
+		WebhookId:  d.webhookId,
+		Type:       d.typ,
+		Payload:    d.payload,
+		Status:     d.status,
+		Attempts:   d.attempts,
+		LastStatus: d.lastStatus,
+		LastError:  d.lastError,
+		Created:    d.created,
+		Delivered:  d.delivered,
+	}
+	if d.status == models.DeliveryPending {
+		next := d.nextAttempt
+		delivery.NextAttempt = &next
+	}
+	return delivery
+}
+
+func (repo *WebhookRepository) Create(ctx context.Context, hook *models.Webhook) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.forums[hook.ForumId]; hook.ForumId != 0 && !ok {
+		return models.NewError(models.ErrNotFound, "can't find webhook forum")
+	}
+
+	s.lastWebhookId++
+	hook.Id = s.lastWebhookId
+	hook.Created = time.Now()
+	s.webhooks[hook.Id] = &webhookRow{
+		id:      hook.Id,
+		forumId: hook.ForumId,
+		url:     hook.URL,
+		events:  slices.Clone(hook.Events),
+		secret:  hook.Secret,
+		byId:    hook.ById,
+		created: hook.Created,
+	}
+	return nil
+}
+
+func (repo *WebhookRepository) Get(ctx context.Context, id int) (*models.Webhook, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	w, ok := s.webhooks[id]
+	if !ok {
+		return nil, repository.WebhookNotFound(id)
+	}
+	return s.webhookModel(w), nil
+}
+
+func (repo *WebhookRepository) List(ctx context.Context) ([]*models.Webhook, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	res := make([]*models.Webhook, 0, len(s.webhooks))
+	for _, w := range s.webhooks {
+		res = append(res, s.webhookModel(w))
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Id < res[j].Id
+	})
+	return res, nil
+}
+
+func (repo *WebhookRepository) Delete(ctx context.Context, id int) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return repository.WebhookNotFound(id)
+	}
+
+	delete(s.webhooks, id)
+	for did, d := range s.deliveries {
+		if d.webhookId == id {
+			delete(s.deliveries, did)
+		}
+	}
+	return nil
+}
+
+func (repo *WebhookRepository) Dispatch(ctx context.Context, limit int) (int, error) {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch := s.outbox[:min(limit, len(s.outbox))]
+	s.outbox = s.outbox[len(batch):]
+
+	hooks := make([]*webhookRow, 0, len(s.webhooks))
+	for _, w := range s.webhooks {
+		hooks = append(hooks, w)
+	}
+	sort.Slice(hooks, func(i, j int) bool {
+		return hooks[i].id < hooks[j].id
+	})
+
+	now := time.Now()
+	for _, ev := range batch {
+		for _, w := range hooks {
+			if !w.wants(ev.forumId, ev.typ) {
+				continue
+			}
+
+			s.lastDeliveryId++
+			s.deliveries[s.lastDeliveryId] = &deliveryRow{
+				id:          s.lastDeliveryId,
+				webhookId:   w.id,
+				typ:         ev.typ,
+				payload:     ev.payload,
+				status:      models.DeliveryPending,
+				nextAttempt: now,
+				created:     now,
+			}
+		}
+	}
+	return len(batch), nil
+}
+
+func (repo *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	due := []*deliveryRow{}
+	for _, d := range s.deliveries {
+		if d.status == models.DeliveryPending && !d.nextAttempt.After(now) {
+			due = append(due, d)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].nextAttempt.Equal(due[j].nextAttempt) {
+			return due[i].nextAttempt.Before(due[j].nextAttempt)
+		}
+		return due[i].id < due[j].id
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+
+	res := make([]*models.WebhookDelivery, 0, len(due))
+	for _, d := range due {
+		d.attempts++
+		d.nextAttempt = now.Add(lease)
+
+		delivery := deliveryModel(d)
+		w := s.webhooks[d.webhookId]
+		delivery.URL = w.url
+		delivery.Secret = w.secret
+		res = append(res, delivery)
+	}
+	return res, nil
+}
+
+func (repo *WebhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	s := repo.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, ok := s.deliveries[delivery.Id]
+	if !ok {
+		return nil
+	}
+
+	d.status = delivery.Status
+	d.lastStatus = delivery.LastStatus
+	d.lastError = delivery.LastError
+	if delivery.NextAttempt != nil {
+		d.nextAttempt = *delivery.NextAttempt
+	}
+	if d.status == models.DeliveryDelivered {
+		now := time.Now()
+		d.delivered = &now
+	}
+	return nil
+}
+
+func (repo *WebhookRepository) GetDeliveries(ctx context.Context, webhookId int, limit int) ([]*models.WebhookDelivery, error) {
+	s := repo.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := []*deliveryRow{}
+	for _, d := range s.deliveries {
+		if d.webhookId == webhookId {
+			found = append(found, d)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].id > found[j].id
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+
+	res := make([]*models.WebhookDelivery, 0, len(found))
+	for _, d := range found {
+		res = append(res, deliveryModel(d))
+	}
+	return res, nil
+}
@@ -0,0 +1,151 @@
+package postgres
+
+import (
+	"context"
+	"slices"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type AttachmentRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewAttachmentRepository(dbpool *pgxpool.Pool) *AttachmentRepository {
+	return &AttachmentRepository{
+		dbpool: dbpool,
+	}
+}
+
+// linkAttachments links the attachments of the new posts to them. Each
+// attachment must be an unlinked upload of the post's author.
+func linkAttachments(ctx context.Context, tx pgx.Tx, posts []*models.Post, authorIds map[string]int) error {
+	var ids, postIds []int64
+	var uploaderIds []int
+	for _, post := range posts {
+		for _, id := range post.Attachments {
+			ids = append(ids, id)
+			postIds = append(postIds, post.Id)
+			uploaderIds = append(uploaderIds, authorIds[post.Author])
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := tx.Query(ctx,
+		`UPDATE Attachments a SET post_id = n.post_id
+		 FROM unnest($1::bigint[], $2::bigint[], $3::integer[]) AS n(id, post_id, uploader_id)
+		 WHERE a.id = n.id AND a.uploader_id = n.uploader_id AND a.post_id IS NULL
+		 RETURNING a.id`, ids, postIds, uploaderIds)
+	if err != nil {
+		return err
+	}
+
+	linked, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+	if err != nil {
+		return err
+	}
+
+	// An id given twice is only updated once, so it shows up as missing too.
+	for _, id := range ids {
+		i := slices.Index(linked, id)
+		if i < 0 {
+			return repository.AttachmentUnavailable(id)
+		}
+		linked = slices.Delete(linked, i, i+1)
+	}
+	return nil
+}
+
+func (repo *AttachmentRepository) Create(ctx context.Context, attachment *models.Attachment) error {
+	ctx = metrics.WithMethod(ctx, "AttachmentRepository.Create")
+
+	return repo.dbpool.QueryRow(ctx,
+		`INSERT INTO Attachments (uploader_id, name, content_type, size, sha256, storage_key)
+		 VALUES ($1, $2, $3, $4, $5, $6)
+		 RETURNING id, created_at`,
+		attachment.UploaderId, attachment.Name, attachment.ContentType, attachment.Size, attachment.SHA256, attachment.Key).
+		Scan(&attachment.Id, &attachment.Created)
+}
+
+func (repo *AttachmentRepository) Get(ctx context.Context, id int64) (*models.Attachment, error) {
+	ctx = metrics.WithMethod(ctx, "AttachmentRepository.Get")
+
+	attachment := &models.Attachment{Id: id}
+
+	err := repo.dbpool.QueryRow(ctx,
+		`SELECT a.post_id, u.nickname, a.uploader_id, a.name, a.content_type, a.size, a.sha256, a.created_at, a.storage_key
+		 FROM Attachments a JOIN Users u ON u.id = a.uploader_id
+		 LEFT JOIN Posts p ON p.id = a.post_id
+		 LEFT JOIN Threads t ON t.id = p.thread_id
+		 WHERE a.id = $1 AND (a.post_id IS NULL OR (p.deleted_at IS NULL AND NOT p.hidden AND t.deleted_at IS NULL))`, id).
+		Scan(
+			&attachment.Post,
+			&attachment.Uploader,
+			&attachment.UploaderId,
+			&attachment.Name,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.SHA256,
+			&attachment.Created,
+			&attachment.Key,
+		)
+	if err == pgx.ErrNoRows {
+		return nil, repository.AttachmentNotFound(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (repo *AttachmentRepository) GetIds(ctx context.Context, postIds []int64) (map[int64][]int64, error) {
+	ctx = metrics.WithMethod(ctx, "AttachmentRepository.GetIds")
+
+	res := map[int64][]int64{}
+	if len(postIds) == 0 {
+		return res, nil
+	}
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT post_id, id FROM Attachments WHERE post_id = ANY($1) ORDER BY id`, postIds)
+	if err != nil {
+		return nil, err
+	}
+
+	var postId, id int64
+	_, err = pgx.ForEachRow(rows, []any{&postId, &id}, func() error {
+		res[postId] = append(res[postId], id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (repo *AttachmentRepository) DeleteUnlinked(ctx context.Context, before time.Time, limit int) ([]string, error) {
+	ctx = metrics.WithMethod(ctx, "AttachmentRepository.DeleteUnlinked")
+
+	// The row locks keep a post from linking an attachment being deleted,
+	// the post fails to link it instead.
+	rows, err := repo.dbpool.Query(ctx,
+		`DELETE FROM Attachments WHERE id IN (
+			SELECT id FROM Attachments
+			WHERE post_id IS NULL AND created_at < $1
+			ORDER BY created_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED)
+		 RETURNING storage_key`, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, pgx.RowTo[string])
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type BanRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewBanRepository(dbpool *pgxpool.Pool) *BanRepository {
+	return &BanRepository{
+		dbpool: dbpool,
+	}
+}
+
+const banColumns = `f.slug, u.nickname, COALESCE(b.nickname, ''), fb.reason, fb.created_at, fb.expires_at,
+	fb.forum_id, fb.user_id, COALESCE(fb.banned_by, 0)`
+
+const banTables = `ForumBans fb JOIN Forums f ON f.id = fb.forum_id
+	JOIN Users u ON u.id = fb.user_id
+	LEFT JOIN Users b ON b.id = fb.banned_by`
+
+func scanBan(row pgx.CollectableRow) (*models.Ban, error) {
+	var ban models.Ban
+	err := row.Scan(
+		&ban.Forum,
+		&ban.User,
+		&ban.By,
+		&ban.Reason,
+		&ban.Created,
+		&ban.Expires,
+		&ban.ForumId,
+		&ban.UserId,
+		&ban.ById,
+	)
+	return &ban, err
+}
+
+func (repo *BanRepository) Ban(ctx context.Context, ban *models.Ban) error {
+	ctx = metrics.WithMethod(ctx, "BanRepository.Ban")
+
+	return repo.dbpool.QueryRow(ctx,
+		`INSERT INTO ForumBans (forum_id, user_id, banned_by, reason, expires_at)
+		 VALUES ($1, $2, NULLIF($3, 0), $4, $5)
+		 ON CONFLICT (forum_id, user_id) DO UPDATE SET
+			banned_by = EXCLUDED.banned_by,
+			reason = EXCLUDED.reason,
+			created_at = now(),
+			expires_at = EXCLUDED.expires_at
+		 RETURNING created_at`,
+		ban.ForumId, ban.UserId, ban.ById, ban.Reason, ban.Expires).Scan(&ban.Created)
+}
+
+func (repo *BanRepository) Unban(ctx context.Context, forumId int, userId int) error {
+	ctx = metrics.WithMethod(ctx, "BanRepository.Unban")
+
+	tag, err := repo.dbpool.Exec(ctx,
+		"DELETE FROM ForumBans WHERE forum_id = $1 AND user_id = $2", forumId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNoBan
+	}
+	return nil
+}
+
+func (repo *BanRepository) GetByForum(ctx context.Context, forumId int) ([]*models.Ban, error) {
+	ctx = metrics.WithMethod(ctx, "BanRepository.GetByForum")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT `+banColumns+` FROM `+banTables+`
+		 WHERE fb.forum_id = $1 AND (fb.expires_at IS NULL OR fb.expires_at > now())
+		 ORDER BY fb.created_at DESC`, forumId)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanBan)
+}
+
+func (repo *BanRepository) Active(ctx context.Context, forumId int, userIds []int) ([]*models.Ban, error) {
+	ctx = metrics.WithMethod(ctx, "BanRepository.Active")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT `+banColumns+` FROM `+banTables+`
+		 WHERE fb.forum_id = $1 AND fb.user_id = ANY($2)
+		   AND (fb.expires_at IS NULL OR fb.expires_at > now())`, forumId, userIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanBan)
+}
@@ -0,0 +1,174 @@
+package postgres
+
+import (
+	"context"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type DigestRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewDigestRepository(dbpool *pgxpool.Pool) *DigestRepository {
+	return &DigestRepository{
+		dbpool: dbpool,
+	}
+}
+
+func (repo *DigestRepository) GetSettings(ctx context.Context, userId int) (*models.DigestSettings, error) {
+	ctx = metrics.WithMethod(ctx, "DigestRepository.GetSettings")
+
+	settings := &models.DigestSettings{UserId: userId, Frequency: models.DigestOff}
+
+	var next time.Time
+	err := repo.dbpool.QueryRow(ctx,
+		"SELECT frequency, next_at FROM Digests WHERE user_id = $1", userId).Scan(&settings.Frequency, &next)
+
+	if err == pgx.ErrNoRows {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.Frequency != models.DigestOff {
+		settings.Next = &next
+	}
+	return settings, nil
+}
+
+func (repo *DigestRepository) SetSettings(ctx context.Context, settings *models.DigestSettings) error {
+	ctx = metrics.WithMethod(ctx, "DigestRepository.SetSettings")
+
+	var next time.Time
+	err := repo.dbpool.QueryRow(ctx,
+		`INSERT INTO Digests (user_id, frequency, token, next_at, last_post_id)
+		 VALUES ($1, $2, $3, now() + make_interval(secs => $4), (SELECT COALESCE(max(id), 0) FROM Posts))
+		 ON CONFLICT (user_id) DO UPDATE SET
+			frequency = EXCLUDED.frequency,
+			next_at = EXCLUDED.next_at,
+			last_post_id = CASE WHEN Digests.frequency = 'off' THEN EXCLUDED.last_post_id ELSE Digests.last_post_id END
+		 RETURNING token, next_at`,
+		settings.UserId, settings.Frequency, settings.Token, models.DigestPeriod(settings.Frequency).Seconds()).
+		Scan(&settings.Token, &next)
+	if err != nil {
+		return err
+	}
+
+	settings.Next = nil
+	if settings.Frequency != models.DigestOff {
+		settings.Next = &next
+	}
+	return nil
+}
+
+func (repo *DigestRepository) Unsubscribe(ctx context.Context, token string) (*models.DigestSettings, error) {
+	ctx = metrics.WithMethod(ctx, "DigestRepository.Unsubscribe")
+
+	settings := &models.DigestSettings{Frequency: models.DigestOff}
+	err := repo.dbpool.QueryRow(ctx,
+		`UPDATE Digests d SET frequency = 'off'
+		 FROM Users u
+		 WHERE d.token = $1 AND u.id = d.user_id
+		 RETURNING d.user_id, u.nickname`, token).Scan(&settings.UserId, &settings.User)
+
+	if err == pgx.ErrNoRows {
+		return nil, repository.ErrDigestToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (repo *DigestRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.DigestRecipient, error) {
+	ctx = metrics.WithMethod(ctx, "DigestRepository.ClaimDue")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`WITH due AS (
+			SELECT user_id FROM Digests
+			WHERE frequency <> 'off' AND next_at <= now()
+			ORDER BY next_at LIMIT $1 FOR UPDATE SKIP LOCKED
+		 )
+		 UPDATE Digests d SET next_at = now() + make_interval(secs => $2)
+		 FROM due, Users u
+		 WHERE d.user_id = due.user_id AND u.id = d.user_id
+		 RETURNING d.user_id, u.nickname, u.email, d.frequency, d.token, d.last_post_id,
+			(SELECT COALESCE(max(id), 0) FROM Posts)`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.DigestRecipient, error) {
+		var r models.DigestRecipient
+		err := row.Scan(&r.UserId, &r.Nickname, &r.Email, &r.Frequency, &r.Token, &r.After, &r.Until)
+		return &r, err
+	})
+}
+
+func (repo *DigestRepository) Activity(ctx context.Context, recipient *models.DigestRecipient, limit int) ([]*models.DigestThread, int, error) {
+	ctx = metrics.WithMethod(ctx, "DigestRepository.Activity")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT p.id, u.nickname, p.message, p.edited, f.slug, p.parent_id, p.thread_id, p.created_at,
+			t.title, count(*) OVER ()
+		 FROM Posts p JOIN Threads t ON t.id = p.thread_id
+			JOIN Forums f ON f.id = t.forum_id
+			JOIN Users u ON u.id = p.author_id
+		 WHERE p.id > $2 AND p.id <= $3 AND p.author_id <> $1
+		   AND p.deleted_at IS NULL AND NOT p.hidden AND t.deleted_at IS NULL
+		   AND NOT EXISTS (SELECT 1 FROM ThreadSubscriptions s
+			WHERE s.thread_id = t.id AND s.user_id = $1 AND s.level = 'muted')
+		   AND (EXISTS (SELECT 1 FROM ThreadSubscriptions s WHERE s.thread_id = t.id AND s.user_id = $1)
+			OR EXISTS (SELECT 1 FROM ForumSubscriptions s WHERE s.forum_id = t.forum_id AND s.user_id = $1))
+		 ORDER BY p.id
+		 LIMIT $4`, recipient.UserId, recipient.After, recipient.Until, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var threads []*models.DigestThread
+	byId := map[int]*models.DigestThread{}
+	total := 0
+
+	var post models.Post
+	var created time.Time
+	var title string
+	_, err = pgx.ForEachRow(rows,
+		[]any{&post.Id, &post.Author, &post.Message, &post.IsEdited, &post.Forum, &post.Parent, &post.Thread, &created, &title, &total},
+		func() error {
+			thread, ok := byId[post.Thread]
+			if !ok {
+				thread = &models.DigestThread{Id: post.Thread, Title: title, Forum: post.Forum}
+				byId[post.Thread] = thread
+				threads = append(threads, thread)
+			}
+
+			p := post
+			p.Created = created.Format("2006-01-02T15:04:05.000Z")
+			thread.Posts = append(thread.Posts, &p)
+			return nil
+		})
+	if err != nil {
+		return nil, 0, err
+	}
+	return threads, total, nil
+}
+
+func (repo *DigestRepository) MarkSent(ctx context.Context, recipient *models.DigestRecipient) error {
+	ctx = metrics.WithMethod(ctx, "DigestRepository.MarkSent")
+
+	_, err := repo.dbpool.Exec(ctx,
+		`UPDATE Digests SET
+			last_post_id = GREATEST(last_post_id, $2),
+			next_at = now() + make_interval(secs => $3)
+		 WHERE user_id = $1`,
+		recipient.UserId, recipient.Until, models.DigestPeriod(recipient.Frequency).Seconds())
+	return err
+}
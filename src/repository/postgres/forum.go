@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ForumRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewForumRepository(dbpool *pgxpool.Pool) *ForumRepository {
+	return &ForumRepository{
+		dbpool: dbpool,
+	}
+}
+
+func (repo *ForumRepository) Create(ctx context.Context, forum *models.Forum, author_id int) error {
+	ctx = metrics.WithMethod(ctx, "ForumRepository.Create")
+
+	_, err := repo.dbpool.Exec(ctx,
+		"INSERT INTO Forums (title, slug, author_id) VALUES ($1, $2, $3)", forum.Title, forum.Slug, author_id)
+
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation {
+		return err
+	}
+
+	err = repo.dbpool.QueryRow(ctx,
+		`SELECT f.id, u.nickname, f.title,
+			   f.slug, f.posts_cnt, f.threads_cnt
+		FROM Forums f 
+		JOIN users u ON f.author_id = u.id
+		WHERE lower(f.slug) = lower($1)`, forum.Slug).
+		Scan(
+			&forum.Id,
+			&forum.Author,
+			&forum.Title,
+			&forum.Slug,
+			&forum.Posts,
+			&forum.Threads,
+		)
+
+	if err != nil {
+		return err
+	}
+
+	return models.ErrAlreadyExists
+}
+
+func (repo *ForumRepository) Get(ctx context.Context, slug string) (*models.Forum, error) {
+	ctx = metrics.WithMethod(ctx, "ForumRepository.Get")
+
+	forum := &models.Forum{}
+
+	err := repo.dbpool.QueryRow(ctx,
+		`SELECT f.id, u.nickname, f.title,
+			f.slug, f.posts_cnt, f.threads_cnt
+		FROM Forums f 
+		JOIN users u ON f.author_id = u.id
+		WHERE lower(f.slug) = lower($1)`, slug).
+		Scan(
+			&forum.Id,
+			&forum.Author,
+			&forum.Title,
+			&forum.Slug,
+			&forum.Posts,
+			&forum.Threads,
+		)
+
+	if err == pgx.ErrNoRows {
+		return nil, repository.ForumNotFound(slug)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return forum, nil
+}
+
+func (repo *ForumRepository) IsModerator(ctx context.Context, forumId int, userId int) (bool, error) {
+	ctx = metrics.WithMethod(ctx, "ForumRepository.IsModerator")
+
+	var res bool
+
+	err := repo.dbpool.QueryRow(ctx,
+		"SELECT EXISTS (SELECT 1 FROM ForumModerators WHERE forum_id = $1 AND user_id = $2)",
+		forumId, userId).Scan(&res)
+
+	return res, err
+}
+
+func (repo *ForumRepository) AddModerator(ctx context.Context, forumId int, userId int) error {
+	ctx = metrics.WithMethod(ctx, "ForumRepository.AddModerator")
+
+	_, err := repo.dbpool.Exec(ctx,
+		"INSERT INTO ForumModerators (forum_id, user_id) VALUES ($1, $2) ON CONFLICT DO NOTHING",
+		forumId, userId)
+	return err
+}
+
+func (repo *ForumRepository) RemoveModerator(ctx context.Context, forumId int, userId int) error {
+	ctx = metrics.WithMethod(ctx, "ForumRepository.RemoveModerator")
+
+	tag, err := repo.dbpool.Exec(ctx,
+		"DELETE FROM ForumModerators WHERE forum_id = $1 AND user_id = $2", forumId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNotModerator
+	}
+	return nil
+}
+
+func (repo *ForumRepository) GetModerators(ctx context.Context, forumId int) ([]*models.User, error) {
+	ctx = metrics.WithMethod(ctx, "ForumRepository.GetModerators")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT u.id, u.nickname, u.fullname, u.about, u.email
+		 FROM ForumModerators m JOIN Users u ON u.id = m.user_id
+		 WHERE m.forum_id = $1
+		 ORDER BY lower(u.nickname)`, forumId)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.User, error) {
+		var user models.User
+		err := row.Scan(
+			&user.Id,
+			&user.Nickname,
+			&user.Fullname,
+			&user.About,
+			&user.Email,
+		)
+		return &user, err
+	})
+}
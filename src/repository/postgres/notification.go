@@ -0,0 +1,219 @@
+package postgres
+
+import (
+	"context"
+	"techno-forum/src/mentions"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NotificationRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewNotificationRepository(dbpool *pgxpool.Pool) *NotificationRepository {
+	return &NotificationRepository{
+		dbpool: dbpool,
+	}
+}
+
+// notify creates the notifications of the new posts in thread: one for the
+// author of the parent, one for every mentioned user and one for every
+// watcher of the thread. Authors aren't notified of their own posts, users
+// who muted the thread aren't notified of anything in it, and everyone else
+// is notified at most once per post, for the first of those reasons.
+// authorIds maps the nicknames of the authors to their ids, as getAuthorIds
+// returns them.
+func notify(ctx context.Context, tx pgx.Tx, thread *models.Thread, posts []*models.Post, authorIds map[string]int) error {
+	var parentIds []int64
+	var mentioned []string
+	postMentions := make([][]string, len(posts))
+	for i, post := range posts {
+		if parent := post.Parent.Get(); parent != nil {
+			parentIds = append(parentIds, *parent)
+		}
+		postMentions[i] = mentions.Parse(post.Message)
+		mentioned = append(mentioned, postMentions[i]...)
+	}
+
+	parentAuthors := map[int64]int{}
+	if len(parentIds) > 0 {
+		rows, err := tx.Query(ctx, "SELECT id, author_id FROM Posts WHERE id = ANY($1)", parentIds)
+		if err != nil {
+			return err
+		}
+
+		var id int64
+		var authorId int
+		_, err = pgx.ForEachRow(rows, []any{&id, &authorId}, func() error {
+			parentAuthors[id] = authorId
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	userIds := map[string]int{}
+	if len(mentioned) > 0 {
+		rows, err := tx.Query(ctx, "SELECT lower(nickname), id FROM Users WHERE lower(nickname) = ANY($1)", mentioned)
+		if err != nil {
+			return err
+		}
+
+		var nickname string
+		var id int
+		_, err = pgx.ForEachRow(rows, []any{&nickname, &id}, func() error {
+			userIds[nickname] = id
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var watchers []int
+	muted := map[int]bool{}
+	rows, err := tx.Query(ctx,
+		"SELECT user_id, level FROM ThreadSubscriptions WHERE thread_id = $1 AND level <> 'tracking'", thread.Id)
+	if err != nil {
+		return err
+	}
+
+	var userId int
+	var level string
+	_, err = pgx.ForEachRow(rows, []any{&userId, &level}, func() error {
+		if level == models.SubscriptionMuted {
+			muted[userId] = true
+		} else {
+			watchers = append(watchers, userId)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var users []int
+	var types []string
+	var postIds []int64
+	add := func(post *models.Post, userId int, typ string) {
+		if userId == 0 || userId == authorIds[post.Author] || muted[userId] {
+			return
+		}
+		users = append(users, userId)
+		types = append(types, typ)
+		postIds = append(postIds, post.Id)
+	}
+
+	for i, post := range posts {
+		if parent := post.Parent.Get(); parent != nil {
+			add(post, parentAuthors[*parent], models.NotificationReply)
+		}
+		for _, nickname := range postMentions[i] {
+			add(post, userIds[nickname], models.NotificationMention)
+		}
+		for _, watcher := range watchers {
+			add(post, watcher, models.NotificationPost)
+		}
+	}
+
+	if len(users) == 0 {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx,
+		`INSERT INTO Notifications (user_id, type, post_id)
+		 SELECT n.user_id, n.type, n.post_id
+		 FROM unnest($1::integer[], $2::varchar[], $3::bigint[]) WITH ORDINALITY AS n(user_id, type, post_id, i)
+		 ORDER BY n.i
+		 ON CONFLICT (user_id, post_id) DO NOTHING`, users, types, postIds)
+	return err
+}
+
+const notificationColumns = `n.id, n.type, n.created_at, n.read_at IS NOT NULL,
+	p.id, u.nickname, p.message, p.edited, f.slug, p.parent_id, p.thread_id, p.created_at`
+
+const notificationTables = `Notifications n JOIN Posts p ON p.id = n.post_id
+	JOIN Users u ON u.id = p.author_id
+	JOIN Threads t ON t.id = p.thread_id
+	JOIN Forums f ON f.id = t.forum_id`
+
+// visibleNotification leaves out the notifications of posts that can't be
+// read anymore.
+const visibleNotification = `p.deleted_at IS NULL AND NOT p.hidden AND t.deleted_at IS NULL`
+
+func scanNotification(row pgx.CollectableRow) (*models.Notification, error) {
+	notification := &models.Notification{Post: &models.Post{}}
+	post := notification.Post
+
+	var created time.Time
+	err := row.Scan(
+		&notification.Id,
+		&notification.Type,
+		&notification.Created,
+		&notification.Read,
+		&post.Id,
+		&post.Author,
+		&post.Message,
+		&post.IsEdited,
+		&post.Forum,
+		&post.Parent,
+		&post.Thread,
+		&created,
+	)
+	post.Created = created.Format("2006-01-02T15:04:05.000Z")
+	return notification, err
+}
+
+func (repo *NotificationRepository) List(ctx context.Context, params *models.NotificationListParams) ([]*models.Notification, error) {
+	ctx = metrics.WithMethod(ctx, "NotificationRepository.List")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT `+notificationColumns+` FROM `+notificationTables+`
+		 WHERE n.user_id = $1 AND `+visibleNotification+`
+		   AND (NOT $2 OR n.read_at IS NULL)
+		   AND ($3 = 0 OR n.id < $3)
+		 ORDER BY n.id DESC
+		 LIMIT $4`, params.UserId, params.Unread, params.Before, params.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanNotification)
+}
+
+func (repo *NotificationRepository) Get(ctx context.Context, userId int, id int64) (*models.Notification, error) {
+	ctx = metrics.WithMethod(ctx, "NotificationRepository.Get")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT `+notificationColumns+` FROM `+notificationTables+`
+		 WHERE n.id = $1 AND n.user_id = $2 AND `+visibleNotification, id, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	notification, err := pgx.CollectOneRow(rows, scanNotification)
+	if err == pgx.ErrNoRows {
+		return nil, repository.NotificationNotFound(id)
+	}
+	return notification, err
+}
+
+func (repo *NotificationRepository) MarkRead(ctx context.Context, userId int, ids []int64) (int, error) {
+	ctx = metrics.WithMethod(ctx, "NotificationRepository.MarkRead")
+
+	tag, err := repo.dbpool.Exec(ctx,
+		`UPDATE Notifications SET read_at = now()
+		 WHERE user_id = $1 AND read_at IS NULL AND (COALESCE(cardinality($2::bigint[]), 0) = 0 OR id = ANY($2))`,
+		userId, ids)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
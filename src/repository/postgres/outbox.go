@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"techno-forum/src/events"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// writeOutbox queues the events of the forum for the webhooks in the
+// transaction that causes them. Events no webhook wants are not queued at
+// all, so forums without webhooks pay only for the check.
+func writeOutbox(ctx context.Context, tx pgx.Tx, forumId int, evs ...*events.Event) error {
+	types := make([]string, 0, len(evs))
+	payloads := make([]string, 0, len(evs))
+	for _, ev := range evs {
+		payload, err := json.Marshal(ev)
+		if err != nil {
+			return err
+		}
+		types = append(types, ev.Type)
+		payloads = append(payloads, string(payload))
+	}
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO Outbox (type, forum_id, payload)
+		 SELECT e.type, $1, e.payload::jsonb
+		 FROM unnest($2::varchar[], $3::text[]) WITH ORDINALITY AS e(type, payload, n)
+		 WHERE EXISTS (
+			SELECT 1 FROM Webhooks w
+			WHERE (w.forum_id IS NULL OR w.forum_id = $1)
+			  AND (cardinality(w.events) = 0 OR e.type = ANY(w.events)))
+		 ORDER BY e.n`, forumId, types, payloads)
+	return err
+}
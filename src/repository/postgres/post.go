@@ -0,0 +1,559 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"techno-forum/src/events"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/utils"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type PostRepository struct {
+	dbpool *pgxpool.Pool
+	log    *slog.Logger
+}
+
+func NewPostRepo(dbpool *pgxpool.Pool, logger *slog.Logger) *PostRepository {
+	return &PostRepository{
+		dbpool: dbpool,
+		log:    logger,
+	}
+}
+
+func getAuthorIds(ctx context.Context, tx pgx.Tx, posts []*models.Post) (map[string]int, error) {
+	res := make(map[string]int, len(posts))
+
+	for _, post := range posts {
+		_, ok := res[post.Author]
+		if ok {
+			continue
+		}
+
+		var id int
+		err := tx.QueryRow(ctx,
+			"SELECT id FROM users WHERE lower(nickname) = lower($1)", post.Author).Scan(&id)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return nil, repository.UserNotFound(post.Author)
+			}
+			return nil, err
+		}
+
+		res[post.Author] = id
+	}
+
+	return res, nil
+}
+
+func LinkUsersToForum(ctx context.Context, tx pgx.Tx, forumId int, ids map[string]int) error {
+	query := `INSERT INTO ForumUserLinks(user_id, forum_id) VALUES `
+
+	args := make([]interface{}, 0, len(ids)+1)
+	args = append(args, forumId)
+
+	i := 2
+	for _, id := range ids {
+		args = append(args, id)
+		query += fmt.Sprintf("($%d, $1),", i)
+		i++
+	}
+
+	query = query[:len(query)-1] + " ON CONFLICT DO NOTHING"
+
+	_, err := tx.Exec(ctx, query, args...)
+	return err
+}
+
+func (repo *PostRepository) AddPosts(ctx context.Context, thread *models.Thread, posts []*models.Post) error {
+	ctx = metrics.WithMethod(ctx, "PostRepository.AddPosts")
+
+	return utils.MakeTx(ctx, repo.dbpool, func(tx pgx.Tx) error {
+		ids, err := getAuthorIds(ctx, tx, posts)
+		if err != nil {
+			return err
+		}
+
+		createdAt := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+		query := `INSERT INTO Posts(thread_id, author_id, parent_id, message, message_html, created_at) VALUES `
+
+		args := make([]interface{}, 0, len(posts)*4+2)
+		args = append(args, thread.Id, createdAt)
+
+		i := 3
+		for _, post := range posts {
+			post.Thread = thread.Id
+			post.Forum = thread.Forum
+			post.Created = createdAt
+
+			query += fmt.Sprintf("($1, $%d, $%d, $%d, $%d, $2),", i, i+1, i+2, i+3)
+			args = append(args, ids[post.Author], post.Parent, post.Message, post.MessageHTML)
+			i += 4
+		}
+
+		query = query[:len(query)-1] + " RETURNING id"
+
+		rows, err := tx.Query(ctx, query, args...)
+
+		if err != nil {
+			return err
+		}
+
+		postIds, err := pgx.CollectRows(rows, pgx.RowTo[int64])
+
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if !errors.As(err, &pgErr) {
+				return err
+			}
+
+			if pgErr.Code == pgerrcode.ForeignKeyViolation {
+				return repository.ErrNoParent
+			}
+
+			if pgErr.Code == pgerrcode.IntegrityConstraintViolation {
+				return repository.ErrInvalidParent
+			}
+
+			return err
+		}
+
+		created := make([]*events.Event, 0, len(posts))
+		for i, id := range postIds {
+			posts[i].Id = id
+			created = append(created, &events.Event{Type: events.PostCreated, ThreadId: thread.Id, Post: posts[i]})
+		}
+
+		if err = linkAttachments(ctx, tx, posts, ids); err != nil {
+			return err
+		}
+
+		if err = writeOutbox(ctx, tx, thread.ForumId, created...); err != nil {
+			return err
+		}
+
+		if err = notify(ctx, tx, thread, posts, ids); err != nil {
+			return err
+		}
+
+		if err = trackThread(ctx, tx, thread.Id, ids); err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE Forums SET posts_cnt = posts_cnt + $1 WHERE id = $2`,
+			len(posts), thread.ForumId,
+		)
+		if err != nil {
+			return err
+		}
+
+		return LinkUsersToForum(ctx, tx, thread.ForumId, ids)
+	})
+}
+
+func (repo *PostRepository) GetPost(ctx context.Context, id int64) (*models.Post, error) {
+	ctx = metrics.WithMethod(ctx, "PostRepository.GetPost")
+
+	post := &models.Post{Id: id}
+
+	var created time.Time
+	err := repo.dbpool.QueryRow(ctx,
+		`SELECT u.nickname, p.message, p.edited, f.slug, p.parent_id, p.thread_id, p.created_at, p.hidden, p.deleted_at IS NOT NULL AS deleted
+		 FROM Posts p JOIN users u  ON u.id = p.author_id
+		 			 JOIN threads t ON t.id = p.thread_id
+					 JOIN forums f  ON f.id = t.forum_id
+		 WHERE p.id = $1`, id).
+		Scan(
+			&post.Author,
+			&post.Message,
+			&post.IsEdited,
+			&post.Forum,
+			&post.Parent,
+			&post.Thread,
+			&created,
+			&post.Hidden,
+			&post.Deleted,
+		)
+
+	post.Created = created.Format("2006-01-02T15:04:05.000Z")
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, repository.PostNotFound(id)
+		}
+		return nil, err
+	}
+
+	return post, nil
+}
+
+func (repo *PostRepository) Update(ctx context.Context, post *models.Post, editorId int) error {
+	ctx = metrics.WithMethod(ctx, "PostRepository.Update")
+
+	previous, err := repo.GetPost(ctx, post.Id)
+	if err != nil {
+		return err
+	}
+
+	if post.Message == "" {
+		post.Message = previous.Message
+	}
+
+	post.IsEdited = previous.IsEdited
+
+	err = utils.MakeTx(ctx, repo.dbpool, func(tx pgx.Tx) error {
+		var message string
+		err := tx.QueryRow(ctx, `SELECT message FROM Posts WHERE id = $1 FOR UPDATE`, post.Id).Scan(&message)
+		if err != nil {
+			return err
+		}
+
+		if post.Message == message {
+			return nil
+		}
+		post.IsEdited = true
+
+		// The original text becomes revision 1 on the first edit.
+		_, err = tx.Exec(ctx,
+			`INSERT INTO PostRevisions (post_id, number, editor_id, message, created_at)
+			 SELECT id, 1, author_id, message, created_at AT TIME ZONE 'UTC' FROM Posts
+			 WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM PostRevisions WHERE post_id = $1)`, post.Id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO PostRevisions (post_id, number, editor_id, message)
+			 SELECT $1, max(number) + 1, $2, $3 FROM PostRevisions WHERE post_id = $1`,
+			post.Id, editorId, post.Message)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE Posts SET message = $1, message_html = $2, edited = true WHERE id = $3`,
+			post.Message, post.MessageHTML, post.Id)
+		return err
+	})
+
+	if err != nil {
+		return err
+	}
+
+	post.Author = previous.Author
+	post.Forum = previous.Forum
+	post.Created = previous.Created
+	post.Parent = previous.Parent
+	post.Thread = previous.Thread
+	post.Hidden = previous.Hidden
+	post.Deleted = previous.Deleted
+
+	return nil
+}
+
+func (repo *PostRepository) GetMessagesHTML(ctx context.Context, ids []int64) (map[int64]string, error) {
+	ctx = metrics.WithMethod(ctx, "PostRepository.GetMessagesHTML")
+
+	rows, err := repo.dbpool.Query(ctx,
+		"SELECT id, message_html FROM Posts WHERE id = ANY($1) AND message_html IS NOT NULL", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[int64]string, len(ids))
+	var id int64
+	var html string
+	_, err = pgx.ForEachRow(rows, []any{&id, &html}, func() error {
+		res[id] = html
+		return nil
+	})
+	return res, err
+}
+
+func (repo *PostRepository) GetRevisions(ctx context.Context, id int64) ([]*models.Revision, error) {
+	ctx = metrics.WithMethod(ctx, "PostRepository.GetRevisions")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT r.number, COALESCE(u.nickname, ''), r.created_at, '', r.message
+		 FROM PostRevisions r LEFT JOIN Users u ON u.id = r.editor_id
+		 WHERE r.post_id = $1
+		 ORDER BY r.number`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanRevision)
+}
+
+func (repo *PostRepository) GetPostsFlat(ctx context.Context, params *models.PostListParams) ([]*models.Post, error) {
+	ctx = metrics.WithMethod(ctx, "PostRepository.GetPostsFlat")
+
+	repo.log.DebugContext(ctx, "listing posts", "sort", "flat", "thread", params.ThreadId,
+		"since", params.Since, "limit", params.Limit, "desc", params.Desc)
+
+	query := `SELECT p.id, u.nickname, p.message, p.edited,
+					 p.parent_id, p.thread_id, p.created_at, p.hidden, p.deleted_at IS NOT NULL AS deleted
+				FROM Posts p JOIN users u  ON u.id = p.author_id
+				WHERE p.thread_id = $1 AND p.deleted_at IS NULL `
+
+	args := []interface{}{params.ThreadId}
+
+	if params.Since > 0 {
+		args = append(args, params.Since)
+		if !params.Desc {
+			query += "AND p.id > $2"
+		} else {
+			query += "AND p.id < $2"
+		}
+	}
+
+	query += " ORDER BY p.id "
+
+	if params.Desc {
+		query += "DESC "
+	}
+
+	args = append(args, params.Limit)
+	query += fmt.Sprintf("LIMIT $%d", len(args))
+
+	rows, err := repo.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var created time.Time
+
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Post, error) {
+		post := &models.Post{}
+		err := row.Scan(
+			&post.Id,
+			&post.Author,
+			&post.Message,
+			&post.IsEdited,
+			&post.Parent,
+			&post.Thread,
+			&created,
+			&post.Hidden,
+			&post.Deleted,
+		)
+		post.Created = created.Format("2006-01-02T15:04:05.000Z")
+		return post, err
+	})
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return posts, nil
+		}
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (repo *PostRepository) GetPostsTree(ctx context.Context, params *models.PostListParams) ([]*models.Post, error) {
+	ctx = metrics.WithMethod(ctx, "PostRepository.GetPostsTree")
+
+	repo.log.DebugContext(ctx, "listing posts", "sort", "tree", "thread", params.ThreadId,
+		"since", params.Since, "limit", params.Limit, "desc", params.Desc)
+
+	query := `SELECT p.id, u.nickname, p.message, p.edited,
+					 p.parent_id, p.thread_id, p.created_at, p.hidden, p.deleted_at IS NOT NULL AS deleted
+			  FROM Posts p JOIN users u  ON u.id = p.author_id
+			  WHERE p.thread_id = $1 `
+
+	args := []interface{}{params.ThreadId}
+
+	if params.Since > 0 {
+		args = append(args, params.Since)
+		if !params.Desc {
+			query += "AND p.path > (SELECT path FROM Posts WHERE id = $2)"
+		} else {
+			query += "AND p.path < (SELECT path FROM Posts WHERE id = $2)"
+		}
+	}
+	query += " ORDER BY p.path"
+
+	if params.Desc {
+		query += " DESC"
+	}
+
+	args = append(args, params.Limit)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+
+	rows, err := repo.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var created time.Time
+
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Post, error) {
+		post := &models.Post{}
+		err := row.Scan(
+			&post.Id,
+			&post.Author,
+			&post.Message,
+			&post.IsEdited,
+			&post.Parent,
+			&post.Thread,
+			&created,
+			&post.Hidden,
+			&post.Deleted,
+		)
+		post.Created = created.Format("2006-01-02T15:04:05.000Z")
+		return post, err
+	})
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return posts, nil
+		}
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (repo *PostRepository) GetPostsParent(ctx context.Context, params *models.PostListParams) ([]*models.Post, error) {
+	ctx = metrics.WithMethod(ctx, "PostRepository.GetPostsParent")
+
+	repo.log.DebugContext(ctx, "listing posts", "sort", "parent_tree", "thread", params.ThreadId,
+		"since", params.Since, "limit", params.Limit, "desc", params.Desc)
+
+	query := `WITH parents AS (
+			  SELECT p.id, u.nickname, p.message, p.edited,
+					 p.parent_id, p.thread_id, p.created_at, p.hidden, p.deleted_at IS NOT NULL AS deleted,
+					 p.path as path
+			  FROM Posts p JOIN users u  ON u.id = p.author_id
+			  WHERE p.thread_id = $1 AND p.id = p.path[1] `
+
+	args := []interface{}{params.ThreadId}
+
+	if params.Since > 0 {
+		args = append(args, params.Since)
+		if !params.Desc {
+			query += "AND p.id > (SELECT path[1] FROM Posts WHERE id = $2)"
+		} else {
+			query += "AND p.id < (SELECT path[1] FROM Posts WHERE id = $2)"
+		}
+	}
+
+	query += " ORDER BY p.id"
+	if params.Desc {
+		query += " DESC"
+	}
+
+	args = append(args, params.Limit)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+
+	query += `), final AS (
+				SELECT p.id, u.nickname, p.message, p.edited,
+					   p.parent_id, p.thread_id, p.created_at, p.hidden, p.deleted_at IS NOT NULL AS deleted,
+					   p.path as path
+				FROM Posts p JOIN users u  ON u.id = p.author_id
+					   		JOIN parents  ON parents.id = p.path[1]
+		   		WHERE p.id != p.path[1]
+		 		UNION ALL
+		 		SELECT * FROM parents)
+				SELECT id, nickname, message, edited,
+				   parent_id, thread_id, created_at, hidden, deleted
+				FROM final ORDER BY path[1]`
+
+	if params.Desc {
+		query += " DESC"
+	}
+
+	query += " NULLS FIRST, path[2:]"
+
+	rows, err := repo.dbpool.Query(ctx, query, args...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var created time.Time
+
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.Post, error) {
+		post := &models.Post{}
+		err := row.Scan(
+			&post.Id,
+			&post.Author,
+			&post.Message,
+			&post.IsEdited,
+			&post.Parent,
+			&post.Thread,
+			&created,
+			&post.Hidden,
+			&post.Deleted,
+		)
+		post.Created = created.Format("2006-01-02T15:04:05.000Z")
+		return post, err
+	})
+
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return posts, nil
+		}
+		return nil, err
+	}
+
+	return posts, nil
+}
+
+func (repo *PostRepository) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	ctx = metrics.WithMethod(ctx, "PostRepository.SetHidden")
+
+	tag, err := repo.dbpool.Exec(ctx, "UPDATE Posts SET hidden = $1 WHERE id = $2", hidden, id)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.PostNotFound(id)
+	}
+	return nil
+}
+
+func (repo *PostRepository) SetDeleted(ctx context.Context, id int64, deleted bool) error {
+	ctx = metrics.WithMethod(ctx, "PostRepository.SetDeleted")
+
+	return utils.MakeTx(ctx, repo.dbpool, func(tx pgx.Tx) error {
+		var forumId int
+		var threadDeleted bool
+
+		err := tx.QueryRow(ctx,
+			`UPDATE Posts p SET deleted_at = CASE WHEN $2 THEN now() END
+			 FROM Threads t
+			 WHERE p.id = $1 AND t.id = p.thread_id AND (p.deleted_at IS NOT NULL) != $2
+			 RETURNING t.forum_id, t.deleted_at IS NOT NULL`, id, deleted).
+			Scan(&forumId, &threadDeleted)
+
+		if err == pgx.ErrNoRows {
+			_, err = repo.GetPost(ctx, id)
+			return err
+		}
+
+		if err != nil || threadDeleted {
+			return err
+		}
+
+		change := 1
+		if deleted {
+			change = -1
+		}
+
+		_, err = tx.Exec(ctx, "UPDATE Forums SET posts_cnt = posts_cnt + $1 WHERE id = $2", change, forumId)
+		return err
+	})
+}
@@ -0,0 +1,29 @@
+package postgres
+
+import (
+	"log/slog"
+	"techno-forum/src/repository"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+func NewRepositories(dbpool *pgxpool.Pool, logger *slog.Logger) *repository.Repositories {
+	logger = logger.With("layer", "repository")
+
+	return &repository.Repositories{
+		Users:         NewUserRepo(dbpool),
+		Forums:        NewForumRepository(dbpool),
+		Threads:       NewThreadRepository(dbpool),
+		Posts:         NewPostRepo(dbpool, logger),
+		Attachments:   NewAttachmentRepository(dbpool),
+		Votes:         NewVoteRepository(dbpool),
+		Notifications: NewNotificationRepository(dbpool),
+		Subscriptions: NewSubscriptionRepository(dbpool),
+		Digests:       NewDigestRepository(dbpool),
+		Bans:          NewBanRepository(dbpool),
+		Search:        NewSearchRepository(dbpool, logger),
+		Webhooks:      NewWebhookRepository(dbpool),
+		Sessions:      NewSessionRepository(dbpool),
+		Service:       NewServiceRepo(dbpool),
+	}
+}
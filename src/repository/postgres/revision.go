@@ -0,0 +1,22 @@
+package postgres
+
+import (
+	"techno-forum/src/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+func scanRevision(row pgx.CollectableRow) (*models.Revision, error) {
+	var rev models.Revision
+	var created time.Time
+	err := row.Scan(
+		&rev.Number,
+		&rev.Editor,
+		&created,
+		&rev.Title,
+		&rev.Message,
+	)
+	rev.Created = created.UTC().Format("2006-01-02T15:04:05.000Z")
+	return &rev, err
+}
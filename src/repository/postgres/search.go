@@ -0,0 +1,231 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SearchRepository struct {
+	dbpool *pgxpool.Pool
+	log    *slog.Logger
+}
+
+func NewSearchRepository(dbpool *pgxpool.Pool, logger *slog.Logger) *SearchRepository {
+	return &SearchRepository{
+		dbpool: dbpool,
+		log:    logger,
+	}
+}
+
+// searchQuery matches the words in both configurations the documents are
+// indexed with.
+const searchQuery = `websearch_to_tsquery('russian', $1) || websearch_to_tsquery('english', $1)`
+
+// The russian configuration stems English words too, so it highlights both.
+// Matches are marked with models.SearchMarkStart and SearchMarkStop, chr(2)
+// and chr(3), and the snippet is escaped before they become highlights.
+const headlineOptions = `'StartSel=' || chr(2) || ', StopSel=' || chr(3) || ', MaxFragments=2, MaxWords=30, MinWords=10'`
+
+// headlineText drops the marker characters from the highlighted text.
+func headlineText(text string) string {
+	return `translate(` + text + `, chr(2) || chr(3), '')`
+}
+
+// searchFilters appends the conditions shared by post and thread searches.
+// table is the alias of the searched table.
+func searchFilters(params *models.SearchParams, table string, args []interface{}) (string, []interface{}) {
+	var where string
+	if params.Forum != "" {
+		args = append(args, params.Forum)
+		where += fmt.Sprintf(" AND lower(f.slug) = lower($%d)", len(args))
+	}
+	if params.Author != "" {
+		args = append(args, params.Author)
+		where += fmt.Sprintf(" AND lower(u.nickname) = lower($%d)", len(args))
+	}
+	if !params.Since.IsZero() {
+		args = append(args, params.Since)
+		if params.Desc {
+			where += fmt.Sprintf(" AND %s.created_at <= $%d", table, len(args))
+		} else {
+			where += fmt.Sprintf(" AND %s.created_at >= $%d", table, len(args))
+		}
+	}
+	return where, args
+}
+
+// searchOrder sorts by the output columns of the search queries.
+func searchOrder(params *models.SearchParams) string {
+	dir := ""
+	if params.Desc {
+		dir = " DESC"
+	}
+	if params.Sort == models.SearchByCreated {
+		return "created_at" + dir + ", id" + dir
+	}
+	return "rank DESC, created_at" + dir + ", id" + dir
+}
+
+func (repo *SearchRepository) Search(ctx context.Context, params *models.SearchParams) ([]*models.SearchHit, error) {
+	ctx = metrics.WithMethod(ctx, "SearchRepository.Search")
+
+	repo.log.DebugContext(ctx, "searching", "query", params.Query, "type", params.Type,
+		"forum", params.Forum, "author", params.Author, "limit", params.Limit)
+
+	var res []*models.SearchHit
+
+	if params.Type != models.SearchThread {
+		posts, err := repo.searchPosts(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, posts...)
+	}
+
+	if params.Type != models.SearchPost {
+		threads, err := repo.searchThreads(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, threads...)
+	}
+
+	// Both queries return their first Offset+Limit hits, and the page is
+	// cut from the merged ones.
+	if params.Type == "" {
+		sortHits(res, params)
+		res = res[min(params.Offset, len(res)):]
+		if len(res) > params.Limit {
+			res = res[:params.Limit]
+		}
+	}
+
+	return res, nil
+}
+
+// pageArgs appends the LIMIT and OFFSET of a post or thread search.
+func pageArgs(params *models.SearchParams, args []interface{}) (string, []interface{}) {
+	if params.Type == "" {
+		args = append(args, params.Offset+params.Limit, 0)
+	} else {
+		args = append(args, params.Limit, params.Offset)
+	}
+	return fmt.Sprintf("LIMIT $%d OFFSET $%d", len(args)-1, len(args)), args
+}
+
+// sortHits merges the posts and threads found separately in the order the
+// queries used, with posts before threads created at the same time.
+func sortHits(hits []*models.SearchHit, params *models.SearchParams) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if params.Sort == models.SearchByRank && a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if params.Desc {
+			a, b = b, a
+		}
+		if !a.Created.Equal(b.Created) {
+			return a.Created.Before(b.Created)
+		}
+		return a.Type < b.Type
+	})
+}
+
+func (repo *SearchRepository) searchPosts(ctx context.Context, params *models.SearchParams) ([]*models.SearchHit, error) {
+	where, args := searchFilters(params, "p", []interface{}{params.Query})
+	page, args := pageArgs(params, args)
+
+	// ts_headline is slow, so it only runs on the page that is returned.
+	query := `SELECT id, author, message, edited, parent_id, thread_id, created_at, forum, rank,
+			ts_headline('russian', ` + headlineText("message") + `, ` + searchQuery + `, ` + headlineOptions + `)
+		FROM (
+			SELECT p.id, u.nickname AS author, p.message, p.edited, p.parent_id, p.thread_id,
+				p.created_at, f.slug AS forum, ts_rank(p.search, ` + searchQuery + `) AS rank
+			FROM Posts p JOIN Users u ON u.id = p.author_id
+				JOIN Threads t ON t.id = p.thread_id
+				JOIN Forums f ON f.id = t.forum_id
+			WHERE p.search @@ (` + searchQuery + `)
+				AND p.deleted_at IS NULL AND NOT p.hidden AND t.deleted_at IS NULL` + where + `
+			ORDER BY ` + searchOrder(params) + `
+			` + page + `
+		) hits
+		ORDER BY ` + searchOrder(params)
+
+	rows, err := repo.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.SearchHit, error) {
+		hit := &models.SearchHit{Type: models.SearchPost, Post: &models.Post{}}
+		err := row.Scan(
+			&hit.Post.Id,
+			&hit.Post.Author,
+			&hit.Post.Message,
+			&hit.Post.IsEdited,
+			&hit.Post.Parent,
+			&hit.Post.Thread,
+			&hit.Created,
+			&hit.Post.Forum,
+			&hit.Rank,
+			&hit.Snippet,
+		)
+		hit.Snippet = models.HighlightSnippet(hit.Snippet)
+		hit.Post.Created = hit.Created.Format("2006-01-02T15:04:05.000Z")
+		return hit, err
+	})
+}
+
+func (repo *SearchRepository) searchThreads(ctx context.Context, params *models.SearchParams) ([]*models.SearchHit, error) {
+	where, args := searchFilters(params, "t", []interface{}{params.Query})
+	page, args := pageArgs(params, args)
+
+	query := `SELECT id, title, author, forum, forum_id, message, votes_cnt, slug, created_at, locked, rank,
+			ts_headline('russian', ` + headlineText(`title || E'\n' || message`) + `, ` + searchQuery + `, ` + headlineOptions + `)
+		FROM (
+			SELECT t.id, t.title, u.nickname AS author, f.slug AS forum, f.id AS forum_id, t.message,
+				t.votes_cnt, t.slug, t.created_at, t.locked, ts_rank(t.search, ` + searchQuery + `) AS rank
+			FROM Threads t JOIN Users u ON u.id = t.author_id
+				JOIN Forums f ON f.id = t.forum_id
+			WHERE t.search @@ (` + searchQuery + `) AND t.deleted_at IS NULL` + where + `
+			ORDER BY ` + searchOrder(params) + `
+			` + page + `
+		) hits
+		ORDER BY ` + searchOrder(params)
+
+	rows, err := repo.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.SearchHit, error) {
+		hit := &models.SearchHit{Type: models.SearchThread, Thread: &models.Thread{}}
+		var created time.Time
+		err := row.Scan(
+			&hit.Thread.Id,
+			&hit.Thread.Title,
+			&hit.Thread.Author,
+			&hit.Thread.Forum,
+			&hit.Thread.ForumId,
+			&hit.Thread.Message,
+			&hit.Thread.Votes,
+			&hit.Thread.Slug,
+			&created,
+			&hit.Thread.Locked,
+			&hit.Rank,
+			&hit.Snippet,
+		)
+		hit.Snippet = models.HighlightSnippet(hit.Snippet)
+		hit.Created = created
+		hit.Thread.Created = created.Format("2006-01-02T15:04:05.000Z")
+		return hit, err
+	})
+}
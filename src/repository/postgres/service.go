@@ -1,7 +1,8 @@
-package repository
+package postgres
 
 import (
 	"context"
+	"techno-forum/src/metrics"
 	"techno-forum/src/models"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -17,21 +18,25 @@ func NewServiceRepo(dbpool *pgxpool.Pool) *ServiceRepository {
 	}
 }
 
-func (repo *ServiceRepository) Clear() error {
-	_, err := repo.dbpool.Exec(context.Background(), "TRUNCATE users CASCADE")
+func (repo *ServiceRepository) Clear(ctx context.Context) error {
+	ctx = metrics.WithMethod(ctx, "ServiceRepository.Clear")
+
+	_, err := repo.dbpool.Exec(ctx, "TRUNCATE users CASCADE")
 	return err
 }
 
-func (repo *ServiceRepository) Status() (*models.ServiceInfo, error) {
+func (repo *ServiceRepository) Status(ctx context.Context) (*models.ServiceInfo, error) {
+	ctx = metrics.WithMethod(ctx, "ServiceRepository.Status")
+
 	res := &models.ServiceInfo{}
 
-	err := repo.dbpool.QueryRow(context.Background(), "SELECT count(*) FROM users").Scan(&res.User)
+	err := repo.dbpool.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&res.User)
 
 	if err != nil {
 		return nil, err
 	}
 
-	err = repo.dbpool.QueryRow(context.Background(),
+	err = repo.dbpool.QueryRow(ctx,
 		`SELECT count(*), COALESCE(sum(threads_cnt), 0), COALESCE(sum(posts_cnt), 0) FROM forums`).
 		Scan(&res.Forum, &res.Thread, &res.Post)
 	if err != nil {
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SessionRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewSessionRepository(dbpool *pgxpool.Pool) *SessionRepository {
+	return &SessionRepository{
+		dbpool: dbpool,
+	}
+}
+
+func (repo *SessionRepository) Create(ctx context.Context, session *models.Session) error {
+	ctx = metrics.WithMethod(ctx, "SessionRepository.Create")
+
+	return repo.dbpool.QueryRow(ctx,
+		`INSERT INTO Sessions (token_hash, user_id, expires_at) VALUES ($1, $2, $3)
+		 RETURNING created_at`,
+		session.TokenHash, session.UserId, session.Expires).Scan(&session.Created)
+}
+
+func (repo *SessionRepository) Get(ctx context.Context, tokenHash []byte) (*models.Session, error) {
+	ctx = metrics.WithMethod(ctx, "SessionRepository.Get")
+
+	session := &models.Session{TokenHash: tokenHash, User: &models.User{}}
+
+	err := repo.dbpool.QueryRow(ctx,
+		`SELECT s.user_id, s.created_at, s.expires_at,
+				u.nickname, u.fullname, u.about, u.email, COALESCE(u.password_hash, ''), u.role
+		 FROM Sessions s JOIN Users u ON u.id = s.user_id
+		 WHERE s.token_hash = $1 AND s.expires_at > now()`, tokenHash).
+		Scan(&session.UserId, &session.Created, &session.Expires,
+			&session.User.Nickname,
+			&session.User.Fullname,
+			&session.User.About,
+			&session.User.Email,
+			&session.User.PasswordHash,
+			&session.User.Role)
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, repository.ErrNoSession
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	session.User.Id = session.UserId
+	return session, nil
+}
+
+func (repo *SessionRepository) Delete(ctx context.Context, tokenHash []byte) error {
+	ctx = metrics.WithMethod(ctx, "SessionRepository.Delete")
+
+	_, err := repo.dbpool.Exec(ctx, "DELETE FROM Sessions WHERE token_hash = $1", tokenHash)
+	return err
+}
+
+func (repo *SessionRepository) DeleteExpired(ctx context.Context) error {
+	ctx = metrics.WithMethod(ctx, "SessionRepository.DeleteExpired")
+
+	_, err := repo.dbpool.Exec(ctx, "DELETE FROM Sessions WHERE expires_at <= now()")
+	return err
+}
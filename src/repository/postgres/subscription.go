@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type SubscriptionRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewSubscriptionRepository(dbpool *pgxpool.Pool) *SubscriptionRepository {
+	return &SubscriptionRepository{
+		dbpool: dbpool,
+	}
+}
+
+// watchThread subscribes the author of a new thread to it.
+func watchThread(ctx context.Context, tx pgx.Tx, threadId int, authorId int) error {
+	_, err := tx.Exec(ctx,
+		`INSERT INTO ThreadSubscriptions (user_id, thread_id, level) VALUES ($1, $2, 'watching')`, authorId, threadId)
+	return err
+}
+
+// trackThread makes the authors of new posts track the thread, unless they
+// are subscribed to it already.
+func trackThread(ctx context.Context, tx pgx.Tx, threadId int, authorIds map[string]int) error {
+	ids := make([]int, 0, len(authorIds))
+	for _, id := range authorIds {
+		ids = append(ids, id)
+	}
+
+	_, err := tx.Exec(ctx,
+		`INSERT INTO ThreadSubscriptions (user_id, thread_id, level)
+		 SELECT unnest($2::integer[]), $1, 'tracking'
+		 ON CONFLICT DO NOTHING`, threadId, ids)
+	return err
+}
+
+func (repo *SubscriptionRepository) Get(ctx context.Context, threadId int, userId int) (*models.Subscription, error) {
+	ctx = metrics.WithMethod(ctx, "SubscriptionRepository.Get")
+
+	sub := &models.Subscription{ThreadId: threadId, UserId: userId}
+	err := repo.dbpool.QueryRow(ctx,
+		`SELECT u.nickname, s.level, s.created_at
+		 FROM ThreadSubscriptions s JOIN Users u ON u.id = s.user_id
+		 WHERE s.thread_id = $1 AND s.user_id = $2`, threadId, userId).
+		Scan(&sub.User, &sub.Level, &sub.Created)
+
+	if err == pgx.ErrNoRows {
+		return nil, repository.ErrNoSubscription
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (repo *SubscriptionRepository) Set(ctx context.Context, sub *models.Subscription) error {
+	ctx = metrics.WithMethod(ctx, "SubscriptionRepository.Set")
+
+	err := repo.dbpool.QueryRow(ctx,
+		`INSERT INTO ThreadSubscriptions (user_id, thread_id, level) VALUES ($1, $2, $3)
+		 ON CONFLICT (thread_id, user_id) DO UPDATE SET level = EXCLUDED.level
+		 RETURNING created_at`, sub.UserId, sub.ThreadId, sub.Level).Scan(&sub.Created)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation {
+		return models.NewError(models.ErrValidation, "unknown subscription level %q", sub.Level)
+	}
+	return err
+}
+
+func (repo *SubscriptionRepository) Delete(ctx context.Context, threadId int, userId int) error {
+	ctx = metrics.WithMethod(ctx, "SubscriptionRepository.Delete")
+
+	tag, err := repo.dbpool.Exec(ctx,
+		"DELETE FROM ThreadSubscriptions WHERE thread_id = $1 AND user_id = $2", threadId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNoSubscription
+	}
+	return nil
+}
+
+func (repo *SubscriptionRepository) GetForum(ctx context.Context, forumId int, userId int) (*models.ForumSubscription, error) {
+	ctx = metrics.WithMethod(ctx, "SubscriptionRepository.GetForum")
+
+	sub := &models.ForumSubscription{ForumId: forumId, UserId: userId}
+	err := repo.dbpool.QueryRow(ctx,
+		`SELECT u.nickname, f.slug, s.created_at
+		 FROM ForumSubscriptions s JOIN Users u ON u.id = s.user_id JOIN Forums f ON f.id = s.forum_id
+		 WHERE s.forum_id = $1 AND s.user_id = $2`, forumId, userId).
+		Scan(&sub.User, &sub.Forum, &sub.Created)
+
+	if err == pgx.ErrNoRows {
+		return nil, repository.ErrNoForumSub
+	}
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (repo *SubscriptionRepository) SetForum(ctx context.Context, sub *models.ForumSubscription) error {
+	ctx = metrics.WithMethod(ctx, "SubscriptionRepository.SetForum")
+
+	return repo.dbpool.QueryRow(ctx,
+		`WITH inserted AS (
+			INSERT INTO ForumSubscriptions (user_id, forum_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+			RETURNING created_at
+		 )
+		 SELECT created_at FROM inserted
+		 UNION ALL
+		 SELECT created_at FROM ForumSubscriptions WHERE forum_id = $2 AND user_id = $1
+		 LIMIT 1`, sub.UserId, sub.ForumId).Scan(&sub.Created)
+}
+
+func (repo *SubscriptionRepository) DeleteForum(ctx context.Context, forumId int, userId int) error {
+	ctx = metrics.WithMethod(ctx, "SubscriptionRepository.DeleteForum")
+
+	tag, err := repo.dbpool.Exec(ctx,
+		"DELETE FROM ForumSubscriptions WHERE forum_id = $1 AND user_id = $2", forumId, userId)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ErrNoForumSub
+	}
+	return nil
+}
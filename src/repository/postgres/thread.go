@@ -0,0 +1,360 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"techno-forum/src/events"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/utils"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ThreadRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewThreadRepository(dbpool *pgxpool.Pool) *ThreadRepository {
+	return &ThreadRepository{
+		dbpool: dbpool,
+	}
+}
+
+// querier is what selectThread needs of a pool or a transaction.
+type querier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func selectThread(ctx context.Context, q querier, cond string, arg any) (*models.Thread, error) {
+	var thread models.Thread
+	var created time.Time
+	err := q.QueryRow(ctx,
+		`SELECT t.id, t.title, u.nickname, f.slug, f.id,
+		t.message, t.votes_cnt, t.slug, t.created_at, t.locked, t.deleted_at IS NOT NULL
+		FROM Threads t 
+		JOIN users u ON t.author_id = u.id
+		JOIN forums f ON t.forum_id = f.id
+		WHERE `+cond, arg).
+		Scan(
+			&thread.Id,
+			&thread.Title,
+			&thread.Author,
+			&thread.Forum,
+			&thread.ForumId,
+			&thread.Message,
+			&thread.Votes,
+			&thread.Slug,
+			&created,
+			&thread.Locked,
+			&thread.Deleted,
+		)
+	if err != nil {
+		return nil, err
+	}
+
+	thread.Created = created.Format("2006-01-02T15:04:05.000Z")
+	return &thread, nil
+}
+
+func (repo *ThreadRepository) GetBySlug(ctx context.Context, slug string) (*models.Thread, error) {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.GetBySlug")
+
+	thread, err := selectThread(ctx, repo.dbpool, "lower(t.slug) = lower($1)", slug)
+	if err == pgx.ErrNoRows {
+		return nil, repository.ThreadNotFound(slug)
+	}
+	return thread, err
+}
+
+func (repo *ThreadRepository) GetById(ctx context.Context, id string) (*models.Thread, error) {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.GetById")
+
+	thread, err := selectThread(ctx, repo.dbpool, "t.id = $1", id)
+	if err == pgx.ErrNoRows {
+		return nil, repository.ThreadNotFound(id)
+	}
+	return thread, err
+}
+
+func (repo *ThreadRepository) GetByForum(ctx context.Context, forumId int, since string, desc bool, limit int) ([]*models.Thread, error) {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.GetByForum")
+
+	var tm time.Time
+	var err error
+
+	if since == "" {
+		tm = time.Time{}
+	} else {
+		tm, err = time.Parse("2006-01-02T15:04:05.000-07:00", since)
+		if err != nil {
+			tm, err = time.Parse("2006-01-02T15:04:05.000Z", since)
+			if err != nil {
+				return nil, repository.InvalidTimestamp(since)
+			}
+		}
+	}
+
+	tm = tm.UTC()
+
+	query := `SELECT t.id, t.title, u.nickname, f.slug,
+					 t.message, t.votes_cnt, t.slug, t.created_at, t.locked
+				FROM threads t JOIN users u ON t.author_id = u.id
+							  JOIN forums f ON t.forum_id  = f.id
+				WHERE t.forum_id = $1 AND t.deleted_at IS NULL AND t.created_at`
+
+	if !desc {
+		query += " >= $2 ORDER BY t.created_at"
+	} else {
+		if tm.Equal(time.Time{}) {
+			tm = time.Date(2261, 12, 31, 0, 0, 0, 0, time.Local).UTC()
+		}
+		query += " <= $2 ORDER BY t.created_at DESC"
+	}
+	query += " LIMIT $3"
+
+	rows, err := repo.dbpool.Query(ctx, query, forumId, tm, limit)
+	res := []*models.Thread{}
+
+	if err == pgx.ErrNoRows {
+		return res, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var created time.Time
+
+	for rows.Next() {
+		thread := &models.Thread{}
+		err = rows.Scan(
+			&thread.Id,
+			&thread.Title,
+			&thread.Author,
+			&thread.Forum,
+			&thread.Message,
+			&thread.Votes,
+			&thread.Slug,
+			&created,
+			&thread.Locked,
+		)
+
+		thread.Created = created.Format("2006-01-02T15:04:05.000Z")
+
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, thread)
+	}
+
+	return res, nil
+}
+
+func (repo *ThreadRepository) Create(ctx context.Context, thread *models.Thread, author_id int, forum_id int) error {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.Create")
+
+	var err error
+
+	var created any
+	if thread.Created != "" {
+		timeParseLayout := "2006-01-02T15:04:05.000-07:00"
+		tm, t_err := time.Parse(timeParseLayout, thread.Created)
+
+		if t_err != nil {
+			return repository.InvalidTimestamp(thread.Created)
+		}
+
+		thread.Created = tm.UTC().Format("2006-01-02T15:04:05.000Z")
+		created = thread.Created
+	}
+
+	err = utils.MakeTx(ctx, repo.dbpool, func(tx pgx.Tx) error {
+		var createdAt time.Time
+		err := tx.QueryRow(ctx,
+			`INSERT INTO Threads (title, author_id, forum_id, message, created_at, slug, message_html) 
+			values ($1, $2, $3, $4, COALESCE($5::timestamp, now()), $6, $7) RETURNING id, created_at`,
+			thread.Title,
+			author_id,
+			forum_id,
+			thread.Message,
+			created,
+			thread.Slug,
+			thread.MessageHTML,
+		).Scan(&thread.Id, &createdAt)
+		if err != nil {
+			return err
+		}
+
+		if err = watchThread(ctx, tx, thread.Id, author_id); err != nil {
+			return err
+		}
+
+		thread.ForumId = forum_id
+		thread.Created = createdAt.Format("2006-01-02T15:04:05.000Z")
+		return writeOutbox(ctx, tx, forum_id, &events.Event{Type: events.ThreadCreated, ThreadId: thread.Id, Thread: thread})
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation {
+		return err
+	}
+
+	existing, err := selectThread(ctx, repo.dbpool, "lower(t.slug) = lower($1)", thread.Slug)
+	if err != nil {
+		return err
+	}
+
+	*thread = *existing
+	return models.ErrAlreadyExists
+}
+
+func (repo *ThreadRepository) Update(ctx context.Context, thread *models.Thread, editorId int) error {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.Update")
+
+	err := utils.MakeTx(ctx, repo.dbpool, func(tx pgx.Tx) error {
+		var title, message string
+		err := tx.QueryRow(ctx, `SELECT title, message FROM Threads WHERE id = $1 FOR UPDATE`, thread.Id).
+			Scan(&title, &message)
+		if err == pgx.ErrNoRows {
+			return repository.ThreadNotFound(strconv.Itoa(thread.Id))
+		}
+		if err != nil {
+			return err
+		}
+
+		if thread.Title == title && thread.Message == message {
+			return nil
+		}
+
+		// The original text becomes revision 1 on the first edit.
+		_, err = tx.Exec(ctx,
+			`INSERT INTO ThreadRevisions (thread_id, number, editor_id, title, message, created_at)
+			 SELECT id, 1, author_id, title, message, created_at AT TIME ZONE 'UTC' FROM Threads
+			 WHERE id = $1 AND NOT EXISTS (SELECT 1 FROM ThreadRevisions WHERE thread_id = $1)`, thread.Id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO ThreadRevisions (thread_id, number, editor_id, title, message)
+			 SELECT $1, max(number) + 1, $2, $3, $4 FROM ThreadRevisions WHERE thread_id = $1`,
+			thread.Id, editorId, thread.Title, thread.Message)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE Threads SET 
+							title = $1,
+							message = $2,
+							message_html = $3
+							WHERE id = $4`, thread.Title, thread.Message, thread.MessageHTML, thread.Id)
+		return err
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return models.ErrAlreadyExists
+	}
+	return err
+}
+
+func (repo *ThreadRepository) GetMessagesHTML(ctx context.Context, ids []int) (map[int]string, error) {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.GetMessagesHTML")
+
+	rows, err := repo.dbpool.Query(ctx,
+		"SELECT id, message_html FROM Threads WHERE id = ANY($1) AND message_html IS NOT NULL", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[int]string, len(ids))
+	var id int
+	var html string
+	_, err = pgx.ForEachRow(rows, []any{&id, &html}, func() error {
+		res[id] = html
+		return nil
+	})
+	return res, err
+}
+
+func (repo *ThreadRepository) GetRevisions(ctx context.Context, id int) ([]*models.Revision, error) {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.GetRevisions")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT r.number, COALESCE(u.nickname, ''), r.created_at, r.title, r.message
+		 FROM ThreadRevisions r LEFT JOIN Users u ON u.id = r.editor_id
+		 WHERE r.thread_id = $1
+		 ORDER BY r.number`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanRevision)
+}
+
+func (repo *ThreadRepository) SetLocked(ctx context.Context, id int, locked bool) error {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.SetLocked")
+
+	tag, err := repo.dbpool.Exec(ctx, "UPDATE Threads SET locked = $1 WHERE id = $2", locked, id)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.ThreadNotFound(strconv.Itoa(id))
+	}
+	return nil
+}
+
+func (repo *ThreadRepository) SetDeleted(ctx context.Context, id int, deleted bool) error {
+	ctx = metrics.WithMethod(ctx, "ThreadRepository.SetDeleted")
+
+	return utils.MakeTx(ctx, repo.dbpool, func(tx pgx.Tx) error {
+		var forumId int
+
+		err := tx.QueryRow(ctx,
+			`UPDATE Threads SET deleted_at = CASE WHEN $2 THEN now() END
+			 WHERE id = $1 AND (deleted_at IS NOT NULL) != $2
+			 RETURNING forum_id`, id, deleted).Scan(&forumId)
+
+		if err == pgx.ErrNoRows {
+			_, err = repo.GetById(ctx, strconv.Itoa(id))
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+
+		change := 1
+		if deleted {
+			change = -1
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE Forums SET
+				threads_cnt = threads_cnt + $1,
+				posts_cnt = posts_cnt + $1 * (SELECT count(*) FROM Posts WHERE thread_id = $2 AND deleted_at IS NULL)
+			 WHERE id = $3`, change, id, forumId)
+		return err
+	})
+}
@@ -0,0 +1,254 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type UserRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewUserRepo(dbpool *pgxpool.Pool) *UserRepository {
+	return &UserRepository{
+		dbpool: dbpool,
+	}
+}
+
+func (repo *UserRepository) Create(ctx context.Context, profile *models.User) ([]*models.User, error) {
+	ctx = metrics.WithMethod(ctx, "UserRepository.Create")
+
+	_, err := repo.dbpool.Exec(ctx,
+		"INSERT INTO Users (nickname, fullname, email, about, password_hash) values ($1, $2, $3, $4, NULLIF($5, ''))",
+		profile.Nickname,
+		profile.Fullname,
+		profile.Email,
+		profile.About,
+		profile.PasswordHash,
+	)
+
+	if err == nil {
+		return nil, nil
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) || pgErr.Code != pgerrcode.UniqueViolation {
+		return nil, err
+	}
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT id, nickname, fullname, about, email
+		 FROM Users WHERE lower(email) = lower($1) OR lower(nickname) = lower($2)`,
+		profile.Email, profile.Nickname)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	users := []*models.User{}
+
+	for rows.Next() {
+		userProfile := models.User{}
+		err = rows.Scan(
+			&userProfile.Id,
+			&userProfile.Nickname,
+			&userProfile.Fullname,
+			&userProfile.About,
+			&userProfile.Email,
+		)
+
+		users = append(users, &userProfile)
+	}
+
+	return users, models.ErrAlreadyExists
+}
+
+func (repo *UserRepository) GetByNickName(ctx context.Context, nickname string) (*models.User, error) {
+	ctx = metrics.WithMethod(ctx, "UserRepository.GetByNickName")
+
+	res := &models.User{}
+
+	err := repo.dbpool.QueryRow(ctx,
+		`SELECT id, nickname, fullname, about, email, COALESCE(password_hash, ''), role
+		 FROM Users WHERE lower(nickname) = lower($1)`, nickname).
+		Scan(&res.Id,
+			&res.Nickname,
+			&res.Fullname,
+			&res.About,
+			&res.Email,
+			&res.PasswordHash,
+			&res.Role)
+
+	if err == pgx.ErrNoRows {
+		return nil, repository.UserNotFound(nickname)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (repo *UserRepository) GetByNickNames(ctx context.Context, nicknames []string) ([]*models.User, error) {
+	ctx = metrics.WithMethod(ctx, "UserRepository.GetByNickNames")
+
+	lower := make([]string, len(nicknames))
+	for i, nickname := range nicknames {
+		lower[i] = strings.ToLower(nickname)
+	}
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT id, nickname, fullname, about, email, COALESCE(password_hash, ''), role
+		 FROM Users WHERE lower(nickname) = ANY($1)`, lower)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.User, error) {
+		var user models.User
+		err := row.Scan(
+			&user.Id,
+			&user.Nickname,
+			&user.Fullname,
+			&user.About,
+			&user.Email,
+			&user.PasswordHash,
+			&user.Role,
+		)
+		return &user, err
+	})
+}
+
+func (repo *UserRepository) GetByForum(ctx context.Context, forumId int, limit int, since string, desc bool) ([]*models.User, error) {
+	ctx = metrics.WithMethod(ctx, "UserRepository.GetByForum")
+
+	query := `SELECT u.id, u.nickname, u.fullname, u.about, u.email
+				FROM users u JOIN ForumUserLinks uf ON u.id = uf.user_id
+							JOIN forums f ON f.id = uf.forum_id
+				WHERE f.id = $1 `
+
+	args := []interface{}{forumId}
+
+	if since != "" {
+		query += "AND lower(u.nickname) "
+		args = append(args, since)
+
+		if !desc {
+			query += "> lower($2)"
+		} else {
+			query += "< lower($2)"
+		}
+	}
+
+	query += " ORDER BY lower(u.nickname)"
+	if desc {
+		query += " DESC"
+	}
+
+	args = append(args, limit)
+	query += fmt.Sprintf(" LIMIT $%d", len(args))
+
+	rows, err := repo.dbpool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.User, error) {
+		var user models.User
+		err := row.Scan(
+			&user.Id,
+			&user.Nickname,
+			&user.Fullname,
+			&user.About,
+			&user.Email,
+		)
+		return &user, err
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return users, nil
+		}
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (repo *UserRepository) Update(ctx context.Context, profile *models.User) error {
+	ctx = metrics.WithMethod(ctx, "UserRepository.Update")
+
+	user, err := repo.GetByNickName(ctx, profile.Nickname)
+
+	if err != nil {
+		return err
+	}
+
+	if profile.Nickname == "" {
+		profile.Nickname = user.Nickname
+	}
+
+	if profile.Fullname == "" {
+		profile.Fullname = user.Fullname
+	}
+
+	if profile.About == "" {
+		profile.About = user.About
+	}
+
+	if profile.Email == "" {
+		profile.Email = user.Email
+	}
+
+	if profile.PasswordHash == "" {
+		profile.PasswordHash = user.PasswordHash
+	}
+
+	_, err = repo.dbpool.Exec(ctx,
+		`UPDATE Users SET 
+						nickname = $1,
+						fullname = $2,
+						about = $3,
+						email = $4,
+						password_hash = NULLIF($5, '')
+						WHERE id = $6`, profile.Nickname, profile.Fullname, profile.About, profile.Email, profile.PasswordHash, user.Id)
+
+	if err == nil {
+		return nil
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.UniqueViolation {
+		return repository.ErrUserConflict
+	}
+	return err
+}
+
+func (repo *UserRepository) SetRole(ctx context.Context, nickname string, role string) error {
+	ctx = metrics.WithMethod(ctx, "UserRepository.SetRole")
+
+	tag, err := repo.dbpool.Exec(ctx,
+		"UPDATE Users SET role = $1 WHERE lower(nickname) = lower($2)", role, nickname)
+
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.UserNotFound(nickname)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"techno-forum/src/events"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/utils"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type VoteRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewVoteRepository(dbpool *pgxpool.Pool) *VoteRepository {
+	return &VoteRepository{
+		dbpool: dbpool,
+	}
+}
+
+func (repo *VoteRepository) Vote(ctx context.Context, vote *models.Vote) error {
+	ctx = metrics.WithMethod(ctx, "VoteRepository.Vote")
+
+	err := utils.MakeTx(ctx, repo.dbpool, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO vote(author_id, thread_id, value)
+				VALUES($1, $2, $3) ON CONFLICT (author_id, thread_id) 
+				DO UPDATE SET value = EXCLUDED.value`,
+			vote.UserId, vote.ThreadId, vote.Value,
+		)
+		if err != nil {
+			return err
+		}
+
+		thread, err := selectThread(ctx, tx, "t.id = $1", vote.ThreadId)
+		if err != nil {
+			return err
+		}
+
+		return writeOutbox(ctx, tx, thread.ForumId, &events.Event{Type: events.ThreadVoted, ThreadId: thread.Id, Thread: thread})
+	})
+
+	if err != nil {
+		var pgErr *pgconn.PgError
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+			return repository.ErrVoteNotFound
+		}
+
+		if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.CheckViolation {
+			return models.NewError(models.ErrValidation, "voice must be 1 or -1, got %d", vote.Value)
+		}
+
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,210 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"time"
+
+	"github.com/jackc/pgerrcode"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type WebhookRepository struct {
+	dbpool *pgxpool.Pool
+}
+
+func NewWebhookRepository(dbpool *pgxpool.Pool) *WebhookRepository {
+	return &WebhookRepository{
+		dbpool: dbpool,
+	}
+}
+
+const webhookColumns = `w.id, COALESCE(f.slug, ''), COALESCE(w.forum_id, 0), w.url, w.events,
+	COALESCE(u.nickname, ''), COALESCE(w.created_by, 0), w.created_at`
+
+const webhookTables = `Webhooks w LEFT JOIN Forums f ON f.id = w.forum_id
+	LEFT JOIN Users u ON u.id = w.created_by`
+
+func scanWebhook(row pgx.CollectableRow) (*models.Webhook, error) {
+	var hook models.Webhook
+	err := row.Scan(
+		&hook.Id,
+		&hook.Forum,
+		&hook.ForumId,
+		&hook.URL,
+		&hook.Events,
+		&hook.By,
+		&hook.ById,
+		&hook.Created,
+	)
+	return &hook, err
+}
+
+const deliveryColumns = `d.id, d.webhook_id, d.type, d.payload, d.status, d.attempts,
+	CASE WHEN d.status = 'pending' THEN d.next_attempt_at END, COALESCE(d.last_status, 0), d.last_error,
+	d.created_at, d.delivered_at`
+
+// deliveryDest returns the scan destinations of deliveryColumns.
+func deliveryDest(delivery *models.WebhookDelivery, payload *[]byte) []any {
+	return []any{
+		&delivery.Id,
+		&delivery.WebhookId,
+		&delivery.Type,
+		payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttempt,
+		&delivery.LastStatus,
+		&delivery.LastError,
+		&delivery.Created,
+		&delivery.Delivered,
+	}
+}
+
+func scanDelivery(row pgx.CollectableRow) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	var payload []byte
+	err := row.Scan(deliveryDest(&delivery, &payload)...)
+	delivery.Payload = payload
+	return &delivery, err
+}
+
+func (repo *WebhookRepository) Create(ctx context.Context, hook *models.Webhook) error {
+	ctx = metrics.WithMethod(ctx, "WebhookRepository.Create")
+
+	err := repo.dbpool.QueryRow(ctx,
+		`INSERT INTO Webhooks (forum_id, url, events, secret, created_by)
+		 VALUES (NULLIF($1, 0), $2, $3, $4, NULLIF($5, 0))
+		 RETURNING id, created_at`,
+		hook.ForumId, hook.URL, hook.Events, hook.Secret, hook.ById).Scan(&hook.Id, &hook.Created)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgerrcode.ForeignKeyViolation {
+		return models.NewError(models.ErrNotFound, "can't find webhook forum")
+	}
+	return err
+}
+
+func (repo *WebhookRepository) Get(ctx context.Context, id int) (*models.Webhook, error) {
+	ctx = metrics.WithMethod(ctx, "WebhookRepository.Get")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT `+webhookColumns+` FROM `+webhookTables+` WHERE w.id = $1`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	hook, err := pgx.CollectOneRow(rows, scanWebhook)
+	if err == pgx.ErrNoRows {
+		return nil, repository.WebhookNotFound(id)
+	}
+	return hook, err
+}
+
+func (repo *WebhookRepository) List(ctx context.Context) ([]*models.Webhook, error) {
+	ctx = metrics.WithMethod(ctx, "WebhookRepository.List")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT `+webhookColumns+` FROM `+webhookTables+` ORDER BY w.id`)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanWebhook)
+}
+
+func (repo *WebhookRepository) Delete(ctx context.Context, id int) error {
+	ctx = metrics.WithMethod(ctx, "WebhookRepository.Delete")
+
+	tag, err := repo.dbpool.Exec(ctx, "DELETE FROM Webhooks WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	if tag.RowsAffected() == 0 {
+		return repository.WebhookNotFound(id)
+	}
+	return nil
+}
+
+func (repo *WebhookRepository) Dispatch(ctx context.Context, limit int) (int, error) {
+	ctx = metrics.WithMethod(ctx, "WebhookRepository.Dispatch")
+
+	var moved int
+	err := repo.dbpool.QueryRow(ctx,
+		`WITH batch AS (
+			DELETE FROM Outbox WHERE id IN (
+				SELECT id FROM Outbox ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED)
+			RETURNING id, type, forum_id, payload
+		 ), queued AS (
+			INSERT INTO WebhookDeliveries (webhook_id, type, payload)
+			SELECT w.id, b.type, b.payload FROM batch b JOIN Webhooks w
+				ON (w.forum_id IS NULL OR w.forum_id = b.forum_id)
+			   AND (cardinality(w.events) = 0 OR b.type = ANY(w.events))
+			ORDER BY b.id, w.id
+		 )
+		 SELECT count(*) FROM batch`, limit).Scan(&moved)
+	return moved, err
+}
+
+func (repo *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	ctx = metrics.WithMethod(ctx, "WebhookRepository.ClaimDue")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`WITH due AS (
+			SELECT id FROM WebhookDeliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at, id LIMIT $1 FOR UPDATE SKIP LOCKED
+		 )
+		 UPDATE WebhookDeliveries d SET
+			attempts = d.attempts + 1,
+			next_attempt_at = now() + make_interval(secs => $2)
+		 FROM due, Webhooks w
+		 WHERE d.id = due.id AND w.id = d.webhook_id
+		 RETURNING `+deliveryColumns+`, w.url, w.secret`, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (*models.WebhookDelivery, error) {
+		var delivery models.WebhookDelivery
+		var payload []byte
+		err := row.Scan(append(deliveryDest(&delivery, &payload), &delivery.URL, &delivery.Secret)...)
+		delivery.Payload = payload
+		return &delivery, err
+	})
+}
+
+func (repo *WebhookRepository) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	ctx = metrics.WithMethod(ctx, "WebhookRepository.RecordAttempt")
+
+	_, err := repo.dbpool.Exec(ctx,
+		`UPDATE WebhookDeliveries SET
+			status = $2,
+			last_status = NULLIF($3, 0),
+			last_error = $4,
+			next_attempt_at = COALESCE($5, next_attempt_at),
+			delivered_at = CASE WHEN $2 = 'delivered' THEN now() END
+		 WHERE id = $1`,
+		delivery.Id, delivery.Status, delivery.LastStatus, delivery.LastError, delivery.NextAttempt)
+	return err
+}
+
+func (repo *WebhookRepository) GetDeliveries(ctx context.Context, webhookId int, limit int) ([]*models.WebhookDelivery, error) {
+	ctx = metrics.WithMethod(ctx, "WebhookRepository.GetDeliveries")
+
+	rows, err := repo.dbpool.Query(ctx,
+		`SELECT `+deliveryColumns+` FROM WebhookDeliveries d
+		 WHERE d.webhook_id = $1
+		 ORDER BY d.id DESC LIMIT $2`, webhookId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, scanDelivery)
+}
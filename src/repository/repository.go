@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"techno-forum/src/models"
+	"time"
+)
+
+type UserRepository interface {
+	Create(ctx context.Context, profile *models.User) ([]*models.User, error)
+	GetByNickName(ctx context.Context, nickname string) (*models.User, error)
+	// GetByNickNames returns the users found among nicknames, in no particular order.
+	GetByNickNames(ctx context.Context, nicknames []string) ([]*models.User, error)
+	GetByForum(ctx context.Context, forumId int, limit int, since string, desc bool) ([]*models.User, error)
+	Update(ctx context.Context, profile *models.User) error
+	SetRole(ctx context.Context, nickname string, role string) error
+}
+
+type ForumRepository interface {
+	Create(ctx context.Context, forum *models.Forum, author_id int) error
+	Get(ctx context.Context, slug string) (*models.Forum, error)
+	IsModerator(ctx context.Context, forumId int, userId int) (bool, error)
+	AddModerator(ctx context.Context, forumId int, userId int) error
+	RemoveModerator(ctx context.Context, forumId int, userId int) error
+	GetModerators(ctx context.Context, forumId int) ([]*models.User, error)
+}
+
+type ThreadRepository interface {
+	GetBySlug(ctx context.Context, slug string) (*models.Thread, error)
+	GetById(ctx context.Context, id string) (*models.Thread, error)
+	GetByForum(ctx context.Context, forumId int, since string, desc bool, limit int) ([]*models.Thread, error)
+	// Create adds the thread and subscribes its author to it as watching.
+	Create(ctx context.Context, thread *models.Thread, author_id int, forum_id int) error
+	// Update saves the title and message, keeping the previous ones as a
+	// revision by editorId when they change.
+	Update(ctx context.Context, thread *models.Thread, editorId int) error
+	// GetMessagesHTML returns the rendered messages of the threads with ids
+	// that have one stored.
+	GetMessagesHTML(ctx context.Context, ids []int) (map[int]string, error)
+	// GetRevisions returns the stored revisions, oldest first. It is empty
+	// for a thread that was never edited.
+	GetRevisions(ctx context.Context, id int) ([]*models.Revision, error)
+	SetLocked(ctx context.Context, id int, locked bool) error
+	// SetDeleted soft deletes or restores the thread, and moves its posts
+	// in or out of the forum counters.
+	SetDeleted(ctx context.Context, id int, deleted bool) error
+}
+
+type PostRepository interface {
+	// AddPosts saves the posts, links their attachments, notifies the
+	// authors of their parents, the users they mention and the watchers of
+	// the thread, and makes the authors track the thread unless they are
+	// subscribed already. Attachments must be unlinked uploads of the
+	// post's author.
+	AddPosts(ctx context.Context, thread *models.Thread, posts []*models.Post) error
+	GetPost(ctx context.Context, id int64) (*models.Post, error)
+	// Update saves the message, keeping the previous one as a revision by
+	// editorId when it changes.
+	Update(ctx context.Context, post *models.Post, editorId int) error
+	// GetMessagesHTML returns the rendered messages of the posts with ids
+	// that have one stored.
+	GetMessagesHTML(ctx context.Context, ids []int64) (map[int64]string, error)
+	// GetRevisions returns the stored revisions, oldest first. It is empty
+	// for a post that was never edited.
+	GetRevisions(ctx context.Context, id int64) ([]*models.Revision, error)
+	GetPostsFlat(ctx context.Context, params *models.PostListParams) ([]*models.Post, error)
+	GetPostsTree(ctx context.Context, params *models.PostListParams) ([]*models.Post, error)
+	GetPostsParent(ctx context.Context, params *models.PostListParams) ([]*models.Post, error)
+	SetHidden(ctx context.Context, id int64, hidden bool) error
+	// SetDeleted soft deletes or restores the post. Flat listings skip
+	// deleted posts, tree listings keep them.
+	SetDeleted(ctx context.Context, id int64, deleted bool) error
+}
+
+type AttachmentRepository interface {
+	// Create saves the attachment and sets its id and creation time.
+	Create(ctx context.Context, attachment *models.Attachment) error
+	// Get leaves out attachments of deleted or hidden posts and of posts
+	// in deleted threads.
+	Get(ctx context.Context, id int64) (*models.Attachment, error)
+	// GetIds returns the ids of the attachments of each post, in order of
+	// upload.
+	GetIds(ctx context.Context, postIds []int64) (map[int64][]int64, error)
+	// DeleteUnlinked deletes at most limit attachments no post links that
+	// were uploaded before the given time, and returns their storage keys.
+	DeleteUnlinked(ctx context.Context, before time.Time, limit int) ([]string, error)
+}
+
+type NotificationRepository interface {
+	// List returns the notifications of params.UserId newest first, leaving
+	// out those of deleted and hidden posts.
+	List(ctx context.Context, params *models.NotificationListParams) ([]*models.Notification, error)
+	Get(ctx context.Context, userId int, id int64) (*models.Notification, error)
+	// MarkRead marks the notifications of the user with ids read, or all of
+	// them when ids is empty, and returns how many were unread.
+	MarkRead(ctx context.Context, userId int, ids []int64) (int, error)
+}
+
+type SubscriptionRepository interface {
+	Get(ctx context.Context, threadId int, userId int) (*models.Subscription, error)
+	// Set subscribes the user or changes the level of their subscription.
+	Set(ctx context.Context, sub *models.Subscription) error
+	Delete(ctx context.Context, threadId int, userId int) error
+
+	GetForum(ctx context.Context, forumId int, userId int) (*models.ForumSubscription, error)
+	// SetForum subscribes the user to the forum, keeping an existing
+	// subscription as it is.
+	SetForum(ctx context.Context, sub *models.ForumSubscription) error
+	DeleteForum(ctx context.Context, forumId int, userId int) error
+}
+
+type DigestRepository interface {
+	// GetSettings returns the digest settings of the user, with frequency off
+	// when they never set one.
+	GetSettings(ctx context.Context, userId int) (*models.DigestSettings, error)
+	// SetSettings changes the frequency and schedules the next digest one
+	// period from now. A user without settings gets settings.Token for
+	// unsubscribing, the token of the others is returned in it. When the
+	// digest was off, the next one starts with the posts created after now.
+	SetSettings(ctx context.Context, settings *models.DigestSettings) error
+	// Unsubscribe turns off the digest of the user with token.
+	Unsubscribe(ctx context.Context, token string) (*models.DigestSettings, error)
+	// ClaimDue returns up to limit users whose digest is due and postpones
+	// them by lease, so that only one worker sends each digest.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.DigestRecipient, error)
+	// Activity returns up to limit new posts for the recipient, grouped by
+	// thread, and how many there are in total. Posts come from the threads
+	// the user is subscribed to and from the threads of the forums they are
+	// subscribed to, except the muted ones and the user's own posts.
+	Activity(ctx context.Context, recipient *models.DigestRecipient, limit int) ([]*models.DigestThread, int, error)
+	// MarkSent records that the digest covering the recipient's posts was
+	// sent and schedules the next one.
+	MarkSent(ctx context.Context, recipient *models.DigestRecipient) error
+}
+
+type VoteRepository interface {
+	Vote(ctx context.Context, vote *models.Vote) error
+}
+
+type BanRepository interface {
+	// Ban creates the ban or replaces the one the user already has.
+	Ban(ctx context.Context, ban *models.Ban) error
+	Unban(ctx context.Context, forumId int, userId int) error
+	// GetByForum returns the bans of the forum that haven't expired.
+	GetByForum(ctx context.Context, forumId int) ([]*models.Ban, error)
+	// Active returns the unexpired bans in the forum of any of userIds.
+	Active(ctx context.Context, forumId int, userIds []int) ([]*models.Ban, error)
+}
+
+type SearchRepository interface {
+	// Search returns the visible posts and threads matching params.Query.
+	Search(ctx context.Context, params *models.SearchParams) ([]*models.SearchHit, error)
+}
+
+type WebhookRepository interface {
+	// Create saves the webhook and sets its id and creation time.
+	Create(ctx context.Context, hook *models.Webhook) error
+	Get(ctx context.Context, id int) (*models.Webhook, error)
+	List(ctx context.Context) ([]*models.Webhook, error)
+	Delete(ctx context.Context, id int) error
+	// Dispatch moves up to limit events from the outbox to the deliveries
+	// of the webhooks that want them, and returns how many it moved.
+	Dispatch(ctx context.Context, limit int) (int, error)
+	// ClaimDue returns up to limit pending deliveries that are due, with
+	// the attempt being made already counted, and puts their next attempt
+	// off by lease so that no other worker takes them meanwhile.
+	ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]*models.WebhookDelivery, error)
+	// RecordAttempt saves the status, last response and next attempt of the
+	// delivery.
+	RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error
+	// GetDeliveries returns up to limit deliveries of the webhook, newest
+	// first.
+	GetDeliveries(ctx context.Context, webhookId int, limit int) ([]*models.WebhookDelivery, error)
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	// Get returns an unexpired session with its user.
+	Get(ctx context.Context, tokenHash []byte) (*models.Session, error)
+	Delete(ctx context.Context, tokenHash []byte) error
+	DeleteExpired(ctx context.Context) error
+}
+
+type ServiceRepository interface {
+	Clear(ctx context.Context) error
+	Status(ctx context.Context) (*models.ServiceInfo, error)
+}
+
+type Repositories struct {
+	Users         UserRepository
+	Forums        ForumRepository
+	Threads       ThreadRepository
+	Posts         PostRepository
+	Attachments   AttachmentRepository
+	Votes         VoteRepository
+	Notifications NotificationRepository
+	Subscriptions SubscriptionRepository
+	Digests       DigestRepository
+	Bans          BanRepository
+	Search        SearchRepository
+	Webhooks      WebhookRepository
+	Sessions      SessionRepository
+	Service       ServiceRepository
+}
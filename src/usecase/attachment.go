@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"techno-forum/src/auth"
+	"techno-forum/src/blobstore"
+	"techno-forum/src/config"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// maxNameLength is the most bytes of the client's file name that are kept.
+const maxNameLength = 255
+
+type AttachmentUseCase struct {
+	AttachmentRepo repository.AttachmentRepository
+	UserRepo       repository.UserRepository
+	Blobs          blobstore.Store
+	cfg            config.Attachments
+	types          []string
+}
+
+func NewAttachmentUseCase(attachments repository.AttachmentRepository, users repository.UserRepository,
+	blobs blobstore.Store, cfg config.Attachments) *AttachmentUseCase {
+	var types []string
+	for _, typ := range strings.Split(cfg.Types, ",") {
+		types = append(types, strings.ToLower(strings.TrimSpace(typ)))
+	}
+
+	return &AttachmentUseCase{
+		AttachmentRepo: attachments,
+		UserRepo:       users,
+		Blobs:          blobs,
+		cfg:            cfg,
+		types:          types,
+	}
+}
+
+// allowed reports whether the media type is one of the configured types or
+// matches a type/* wildcard.
+func (usecase *AttachmentUseCase) allowed(mediaType string) bool {
+	for _, typ := range usecase.types {
+		if typ == mediaType || strings.HasSuffix(typ, "/*") && strings.HasPrefix(mediaType, typ[:len(typ)-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+// Upload stores the file as an attachment of the user, to be linked by one
+// of their posts. The content type is sniffed from the contents, whatever
+// the client claims.
+func (usecase *AttachmentUseCase) Upload(ctx context.Context, nickname string, name string, body io.Reader) (_ *models.Attachment, err error) {
+	ctx, span := tracing.Start(ctx, "AttachmentUseCase.Upload")
+	defer tracing.End(span, &err)
+
+	nickname = auth.Nickname(ctx, nickname)
+	if nickname == "" {
+		return nil, models.NewError(models.ErrBadRequest, "nickname is required")
+	}
+
+	user, err := usecase.UserRepo.GetByNickName(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.ActAs(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// The contents are spooled to a temporary file to check the size and
+	// hash them before anything is stored.
+	tmp, err := os.CreateTemp("", "attachment-*")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), io.LimitReader(body, int64(usecase.cfg.MaxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if size > int64(usecase.cfg.MaxSize) {
+		return nil, models.NewError(models.ErrTooLarge, "attachments may be at most %d bytes", usecase.cfg.MaxSize)
+	}
+	if size == 0 {
+		return nil, models.NewError(models.ErrValidation, "the file is empty")
+	}
+
+	head := make([]byte, 512)
+	n, err := tmp.ReadAt(head, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	contentType := http.DetectContentType(head[:n])
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !usecase.allowed(mediaType) {
+		return nil, models.NewError(models.ErrMediaType, "files of type %s can't be attached", mediaType)
+	}
+
+	key, err := blobKey()
+	if err != nil {
+		return nil, err
+	}
+
+	attachment := &models.Attachment{
+		Uploader:    user.Nickname,
+		UploaderId:  user.Id,
+		Name:        cleanFileName(name),
+		ContentType: contentType,
+		Size:        size,
+		SHA256:      hex.EncodeToString(hash.Sum(nil)),
+		Key:         key,
+	}
+
+	if _, err = tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	obj := &blobstore.Object{Key: key, ContentType: contentType, Size: size, SHA256: attachment.SHA256}
+	if err = usecase.Blobs.Put(ctx, obj, tmp); err != nil {
+		return nil, err
+	}
+
+	if err = usecase.AttachmentRepo.Create(ctx, attachment); err != nil {
+		usecase.Blobs.Delete(context.WithoutCancel(ctx), key)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (usecase *AttachmentUseCase) Get(ctx context.Context, id int64) (_ *models.Attachment, err error) {
+	ctx, span := tracing.Start(ctx, "AttachmentUseCase.Get")
+	defer tracing.End(span, &err)
+
+	attachment, err := usecase.AttachmentRepo.Get(ctx, id)
+	if err != nil || attachment.Post != nil {
+		return attachment, err
+	}
+
+	// Until a post links it, an upload is only the uploader's to see.
+	uploader, err := usecase.UserRepo.GetByNickName(ctx, attachment.Uploader)
+	if err != nil {
+		return nil, err
+	}
+	if auth.ActAs(ctx, uploader) != nil {
+		return nil, repository.AttachmentNotFound(id)
+	}
+	return attachment, nil
+}
+
+// Open returns the contents of the attachment.
+func (usecase *AttachmentUseCase) Open(ctx context.Context, attachment *models.Attachment) (_ io.ReadCloser, err error) {
+	ctx, span := tracing.Start(ctx, "AttachmentUseCase.Open")
+	defer tracing.End(span, &err)
+
+	body, err := usecase.Blobs.Get(ctx, attachment.Key)
+	if errors.Is(err, blobstore.ErrNotExist) {
+		return nil, models.NewError(models.ErrNotFound, "the contents of attachment %d are gone", attachment.Id)
+	}
+	return body, err
+}
+
+// blobKey returns a random key, spread over 256 prefixes so that no
+// directory of the local storage grows too large.
+func blobKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(b)
+	return name[:2] + "/" + name, nil
+}
+
+// cleanFileName strips the directories and control characters from the
+// name the client gave the file and shortens it to maxNameLength bytes.
+func cleanFileName(name string) string {
+	name = name[strings.LastIndexAny(name, `/\`)+1:]
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	for len(name) > maxNameLength {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+
+	if name == "" || name == "." || name == ".." {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,175 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"techno-forum/src/auth"
+	"techno-forum/src/config"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+	"time"
+)
+
+type AuthUseCase struct {
+	UserRepo    repository.UserRepository
+	SessionRepo repository.SessionRepository
+	cfg         config.Auth
+	// dummyHash is checked on logins without a hash of their own, unknown
+	// nicknames and accounts without a password, so that they take as long
+	// as a wrong password and don't tell which accounts exist.
+	dummyHash func() (string, error)
+	// admins are the lowercased nicknames of auth.admins.
+	admins map[string]bool
+}
+
+func NewAuthUseCase(users repository.UserRepository, sessions repository.SessionRepository, cfg config.Auth) *AuthUseCase {
+	admins := map[string]bool{}
+	for _, nickname := range strings.Split(cfg.Admins, ",") {
+		if nickname = strings.TrimSpace(nickname); nickname != "" {
+			admins[strings.ToLower(nickname)] = true
+		}
+	}
+
+	return &AuthUseCase{
+		UserRepo:    users,
+		SessionRepo: sessions,
+		cfg:         cfg,
+		admins:      admins,
+		dummyHash: sync.OnceValues(func() (string, error) {
+			return auth.HashPassword("dummy password", cfg.BcryptCost)
+		}),
+	}
+}
+
+// Login checks the credentials and opens a session. The returned session is
+// the only place the plain token ever appears.
+func (usecase *AuthUseCase) Login(ctx context.Context, creds *models.Credentials) (_ *models.Session, err error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.Login")
+	defer tracing.End(span, &err)
+
+	user, err := usecase.UserRepo.GetByNickName(ctx, creds.Nickname)
+	if err != nil && !errors.Is(err, models.ErrNotFound) {
+		return nil, err
+	}
+
+	if user == nil || user.PasswordHash == "" {
+		hash, err := usecase.dummyHash()
+		if err != nil {
+			return nil, err
+		}
+		auth.CheckPassword(hash, creds.Password)
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	if !auth.CheckPassword(user.PasswordHash, creds.Password) {
+		return nil, auth.ErrInvalidCredentials
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := &models.Session{
+		TokenHash: hash,
+		UserId:    user.Id,
+		Token:     token,
+		User:      user,
+		Expires:   time.Now().Add(usecase.cfg.SessionTTL),
+	}
+	usecase.grantAdmin(user)
+
+	if err = usecase.SessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+
+	// Logins are rare enough to carry the cleanup of abandoned sessions.
+	if err = usecase.SessionRepo.DeleteExpired(ctx); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+func (usecase *AuthUseCase) Logout(ctx context.Context, token string) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.Logout")
+	defer tracing.End(span, &err)
+
+	return usecase.SessionRepo.Delete(ctx, auth.HashToken(token))
+}
+
+// Authenticate returns the session of a token, or ErrUnauthorized when the
+// token is unknown or expired.
+func (usecase *AuthUseCase) Authenticate(ctx context.Context, token string) (_ *models.Session, err error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.Authenticate")
+	defer tracing.End(span, &err)
+
+	session, err := usecase.SessionRepo.Get(ctx, auth.HashToken(token))
+	if errors.Is(err, models.ErrNotFound) {
+		return nil, models.NewError(models.ErrUnauthorized, "invalid or expired session token")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	usecase.grantAdmin(session.User)
+	return session, nil
+}
+
+// grantAdmin makes the users listed in auth.admins admins.
+func (usecase *AuthUseCase) grantAdmin(user *models.User) {
+	if usecase.admins[strings.ToLower(user.Nickname)] {
+		user.Role = models.RoleAdmin
+	}
+}
+
+// SetRole changes the stored role of a user. Only admins may do it.
+func (usecase *AuthUseCase) SetRole(ctx context.Context, nickname string, role string) (err error) {
+	ctx, span := tracing.Start(ctx, "AuthUseCase.SetRole")
+	defer tracing.End(span, &err)
+
+	if err = auth.RequireAdmin(ctx); err != nil {
+		return err
+	}
+
+	if role != models.RoleUser && role != models.RoleAdmin {
+		return models.NewError(models.ErrValidation, "role must be %s or %s", models.RoleUser, models.RoleAdmin)
+	}
+
+	if usecase.admins[strings.ToLower(nickname)] && role != models.RoleAdmin {
+		return models.NewError(models.ErrValidation, "%s is an admin by configuration", nickname)
+	}
+
+	return usecase.UserRepo.SetRole(ctx, nickname, role)
+}
+
+// SetPassword validates profile.Password and replaces it with its hash.
+// A profile without a password is left as is.
+func (usecase *AuthUseCase) SetPassword(profile *models.User) error {
+	password := profile.Password
+	profile.Password = ""
+
+	if password == "" {
+		return nil
+	}
+
+	if err := auth.ValidatePassword(password); err != nil {
+		return err
+	}
+
+	hash, err := auth.HashPassword(password, usecase.cfg.BcryptCost)
+	if err != nil {
+		return err
+	}
+
+	profile.PasswordHash = hash
+	return nil
+}
+
+// Required reports whether writes need a session token.
+func (usecase *AuthUseCase) Required() bool {
+	return usecase.cfg.Required
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"context"
+	"techno-forum/src/auth"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+)
+
+type DigestUseCase struct {
+	DigestRepo repository.DigestRepository
+	UserRepo   repository.UserRepository
+}
+
+func NewDigestUseCase(digests repository.DigestRepository, users repository.UserRepository) *DigestUseCase {
+	return &DigestUseCase{
+		DigestRepo: digests,
+		UserRepo:   users,
+	}
+}
+
+// owner returns the user with nickname if the request may manage their
+// digest, which only they may.
+func (usecase *DigestUseCase) owner(ctx context.Context, nickname string) (*models.User, error) {
+	user, err := usecase.UserRepo.GetByNickName(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.ActAs(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (usecase *DigestUseCase) GetSettings(ctx context.Context, nickname string) (_ *models.DigestSettings, err error) {
+	ctx, span := tracing.Start(ctx, "DigestUseCase.GetSettings")
+	defer tracing.End(span, &err)
+
+	user, err := usecase.owner(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := usecase.DigestRepo.GetSettings(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.User = user.Nickname
+	return settings, nil
+}
+
+func (usecase *DigestUseCase) SetSettings(ctx context.Context, nickname string, req *models.DigestSettingsRequest) (_ *models.DigestSettings, err error) {
+	ctx, span := tracing.Start(ctx, "DigestUseCase.SetSettings")
+	defer tracing.End(span, &err)
+
+	switch req.Frequency {
+	case models.DigestOff, models.DigestDaily, models.DigestWeekly:
+	default:
+		return nil, models.NewError(models.ErrValidation, "frequency must be one of %s, %s or %s",
+			models.DigestOff, models.DigestDaily, models.DigestWeekly)
+	}
+
+	user, err := usecase.owner(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	token, _, err := auth.NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	settings := &models.DigestSettings{
+		User:      user.Nickname,
+		UserId:    user.Id,
+		Frequency: req.Frequency,
+		Token:     token,
+	}
+
+	if err = usecase.DigestRepo.SetSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// Unsubscribe turns off the digest the token was sent with. The token is
+// all it takes, so that the link in the email works without signing in.
+func (usecase *DigestUseCase) Unsubscribe(ctx context.Context, token string) (_ *models.DigestSettings, err error) {
+	ctx, span := tracing.Start(ctx, "DigestUseCase.Unsubscribe")
+	defer tracing.End(span, &err)
+
+	if token == "" {
+		return nil, models.NewError(models.ErrBadRequest, "token is required")
+	}
+	return usecase.DigestRepo.Unsubscribe(ctx, token)
+}
@@ -1,32 +1,118 @@
 package usecase
 
 import (
+	"context"
+	"techno-forum/src/auth"
 	"techno-forum/src/models"
 	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
 )
 
 type ForumUseCase struct {
-	ForumRepo *repository.ForumRepository
-	UserRepo  *repository.UserRepository
+	ForumRepo repository.ForumRepository
+	UserRepo  repository.UserRepository
+	SubsRepo  repository.SubscriptionRepository
 }
 
-func NewForumUseCase(forum *repository.ForumRepository, user *repository.UserRepository) *ForumUseCase {
+func NewForumUseCase(forum repository.ForumRepository, user repository.UserRepository, subs repository.SubscriptionRepository) *ForumUseCase {
 	return &ForumUseCase{
 		ForumRepo: forum,
 		UserRepo:  user,
+		SubsRepo:  subs,
 	}
 }
 
-func (usecase *ForumUseCase) Create(forum *models.Forum) error {
-	user, err := usecase.UserRepo.GetByNickName(forum.Author)
+func (usecase *ForumUseCase) Create(ctx context.Context, forum *models.Forum) (err error) {
+	ctx, span := tracing.Start(ctx, "ForumUseCase.Create")
+	defer tracing.End(span, &err)
+
+	user, err := usecase.UserRepo.GetByNickName(ctx, forum.Author)
 	if err != nil {
 		return err
 	}
 
+	if err = auth.ActAs(ctx, user); err != nil {
+		return err
+	}
+
 	forum.Author = user.Nickname
-	return usecase.ForumRepo.Create(forum, user.Id)
+	return usecase.ForumRepo.Create(ctx, forum, user.Id)
+}
+
+func (usecase *ForumUseCase) Get(ctx context.Context, slug string) (_ *models.Forum, err error) {
+	ctx, span := tracing.Start(ctx, "ForumUseCase.Get")
+	defer tracing.End(span, &err)
+
+	return usecase.ForumRepo.Get(ctx, slug)
+}
+
+// subscriber returns the forum and the user with nickname, or the signed in
+// user, if the request may manage their subscription.
+func (usecase *ForumUseCase) subscriber(ctx context.Context, slug string, nickname string) (*models.Forum, *models.User, error) {
+	nickname = auth.Nickname(ctx, nickname)
+	if nickname == "" {
+		return nil, nil, models.NewError(models.ErrBadRequest, "nickname is required")
+	}
+
+	user, err := usecase.UserRepo.GetByNickName(ctx, nickname)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = auth.ActAs(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	forum, err := usecase.ForumRepo.Get(ctx, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+	return forum, user, nil
+}
+
+func (usecase *ForumUseCase) GetSubscription(ctx context.Context, slug string, nickname string) (_ *models.ForumSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "ForumUseCase.GetSubscription")
+	defer tracing.End(span, &err)
+
+	forum, user, err := usecase.subscriber(ctx, slug, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.SubsRepo.GetForum(ctx, forum.Id, user.Id)
 }
 
-func (usecase *ForumUseCase) Get(slug string) (*models.Forum, error) {
-	return usecase.ForumRepo.Get(slug)
+// Subscribe adds the threads of the forum to the digests of the user.
+func (usecase *ForumUseCase) Subscribe(ctx context.Context, slug string, nickname string) (_ *models.ForumSubscription, err error) {
+	ctx, span := tracing.Start(ctx, "ForumUseCase.Subscribe")
+	defer tracing.End(span, &err)
+
+	forum, user, err := usecase.subscriber(ctx, slug, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &models.ForumSubscription{
+		User:    user.Nickname,
+		UserId:  user.Id,
+		Forum:   forum.Slug,
+		ForumId: forum.Id,
+	}
+
+	if err = usecase.SubsRepo.SetForum(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (usecase *ForumUseCase) Unsubscribe(ctx context.Context, slug string, nickname string) (err error) {
+	ctx, span := tracing.Start(ctx, "ForumUseCase.Unsubscribe")
+	defer tracing.End(span, &err)
+
+	forum, user, err := usecase.subscriber(ctx, slug, nickname)
+	if err != nil {
+		return err
+	}
+
+	return usecase.SubsRepo.DeleteForum(ctx, forum.Id, user.Id)
 }
@@ -0,0 +1,293 @@
+package usecase
+
+import (
+	"context"
+	"strings"
+	"techno-forum/src/auth"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+	"time"
+)
+
+// checkBans fails with ErrForbidden when any of users is banned in the forum.
+func checkBans(ctx context.Context, bans repository.BanRepository, forumId int, users ...*models.User) error {
+	ids := make([]int, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.Id)
+	}
+
+	active, err := bans.Active(ctx, forumId, ids)
+	if err != nil {
+		return err
+	}
+
+	if len(active) == 0 {
+		return nil
+	}
+
+	ban := active[0]
+	if ban.Expires == nil {
+		return models.NewError(models.ErrForbidden, "%s is banned from forum %s", ban.User, ban.Forum)
+	}
+	return models.NewError(models.ErrForbidden, "%s is banned from forum %s until %s",
+		ban.User, ban.Forum, ban.Expires.UTC().Format(time.RFC3339))
+}
+
+// maskPosts replaces the messages of deleted and hidden posts with a
+// placeholder. The posts stay in place so that trees keep their shape.
+func maskPosts(posts ...*models.Post) {
+	for _, post := range posts {
+		switch {
+		case post.Deleted:
+			post.Message = models.DeletedPostMessage
+			post.MessageHTML = ""
+		case post.Hidden:
+			post.Message = models.HiddenPostMessage
+			post.MessageHTML = ""
+		}
+	}
+}
+
+type ModerationUseCase struct {
+	ForumRepo  repository.ForumRepository
+	ThreadRepo repository.ThreadRepository
+	PostRepo   repository.PostRepository
+	UserRepo   repository.UserRepository
+	BanRepo    repository.BanRepository
+}
+
+func NewModerationUseCase(forums repository.ForumRepository, threads repository.ThreadRepository,
+	posts repository.PostRepository, users repository.UserRepository, bans repository.BanRepository) *ModerationUseCase {
+	return &ModerationUseCase{
+		ForumRepo:  forums,
+		ThreadRepo: threads,
+		PostRepo:   posts,
+		UserRepo:   users,
+		BanRepo:    bans,
+	}
+}
+
+// moderate lets admins and moderators of the forum through.
+func (usecase *ModerationUseCase) moderate(ctx context.Context, forumId int) error {
+	return auth.Moderate(ctx, func(user *models.User) (bool, error) {
+		return usecase.ForumRepo.IsModerator(ctx, forumId, user.Id)
+	})
+}
+
+// forumAndUser resolves a forum and a user, and checks that the request may
+// manage the forum: the forum author and admins appoint moderators, the
+// moderators themselves handle bans.
+func (usecase *ModerationUseCase) forumAndUser(ctx context.Context, slug string, nickname string, owner bool) (*models.Forum, *models.User, error) {
+	forum, err := usecase.ForumRepo.Get(ctx, slug)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if owner {
+		err = auth.Moderate(ctx, func(user *models.User) (bool, error) {
+			return strings.EqualFold(user.Nickname, forum.Author), nil
+		})
+	} else {
+		err = usecase.moderate(ctx, forum.Id)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := usecase.UserRepo.GetByNickName(ctx, nickname)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return forum, user, nil
+}
+
+func (usecase *ModerationUseCase) AddModerator(ctx context.Context, slug string, nickname string) (_ *models.User, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.AddModerator")
+	defer tracing.End(span, &err)
+
+	forum, user, err := usecase.forumAndUser(ctx, slug, nickname, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, usecase.ForumRepo.AddModerator(ctx, forum.Id, user.Id)
+}
+
+func (usecase *ModerationUseCase) RemoveModerator(ctx context.Context, slug string, nickname string) (err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.RemoveModerator")
+	defer tracing.End(span, &err)
+
+	forum, user, err := usecase.forumAndUser(ctx, slug, nickname, true)
+	if err != nil {
+		return err
+	}
+
+	return usecase.ForumRepo.RemoveModerator(ctx, forum.Id, user.Id)
+}
+
+func (usecase *ModerationUseCase) GetModerators(ctx context.Context, slug string) (_ []*models.User, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.GetModerators")
+	defer tracing.End(span, &err)
+
+	forum, err := usecase.ForumRepo.Get(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.ForumRepo.GetModerators(ctx, forum.Id)
+}
+
+func (usecase *ModerationUseCase) SetLocked(ctx context.Context, slugOrId string, locked bool) (_ *models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.SetLocked")
+	defer tracing.End(span, &err)
+
+	thread, err := getThread(ctx, usecase.ThreadRepo, slugOrId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = usecase.moderate(ctx, thread.ForumId); err != nil {
+		return nil, err
+	}
+
+	if err = usecase.ThreadRepo.SetLocked(ctx, thread.Id, locked); err != nil {
+		return nil, err
+	}
+
+	thread.Locked = locked
+	return thread, nil
+}
+
+func (usecase *ModerationUseCase) SetHidden(ctx context.Context, id int64, hidden bool) (_ *models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.SetHidden")
+	defer tracing.End(span, &err)
+
+	post, err := usecase.PostRepo.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	forum, err := usecase.ForumRepo.Get(ctx, post.Forum)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = usecase.moderate(ctx, forum.Id); err != nil {
+		return nil, err
+	}
+
+	if err = usecase.PostRepo.SetHidden(ctx, id, hidden); err != nil {
+		return nil, err
+	}
+
+	post.Hidden = hidden
+	return post, nil
+}
+
+func (usecase *ModerationUseCase) Ban(ctx context.Context, slug string, nickname string, req *models.BanRequest) (_ *models.Ban, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.Ban")
+	defer tracing.End(span, &err)
+
+	if req.Expires != nil && !req.Expires.After(time.Now()) {
+		return nil, models.NewError(models.ErrValidation, "expires must be in the future")
+	}
+
+	forum, user, err := usecase.forumAndUser(ctx, slug, nickname, false)
+	if err != nil {
+		return nil, err
+	}
+
+	acting := auth.User(ctx)
+	ban := &models.Ban{
+		ForumId: forum.Id,
+		UserId:  user.Id,
+		ById:    acting.Id,
+		Forum:   forum.Slug,
+		User:    user.Nickname,
+		By:      acting.Nickname,
+		Reason:  req.Reason,
+		Expires: req.Expires,
+	}
+
+	if err = usecase.BanRepo.Ban(ctx, ban); err != nil {
+		return nil, err
+	}
+	return ban, nil
+}
+
+func (usecase *ModerationUseCase) Unban(ctx context.Context, slug string, nickname string) (err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.Unban")
+	defer tracing.End(span, &err)
+
+	forum, user, err := usecase.forumAndUser(ctx, slug, nickname, false)
+	if err != nil {
+		return err
+	}
+
+	return usecase.BanRepo.Unban(ctx, forum.Id, user.Id)
+}
+
+func (usecase *ModerationUseCase) GetBans(ctx context.Context, slug string) (_ []*models.Ban, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.GetBans")
+	defer tracing.End(span, &err)
+
+	forum, err := usecase.ForumRepo.Get(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = usecase.moderate(ctx, forum.Id); err != nil {
+		return nil, err
+	}
+
+	return usecase.BanRepo.GetByForum(ctx, forum.Id)
+}
+
+func (usecase *ModerationUseCase) RestoreThread(ctx context.Context, slugOrId string) (_ *models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.RestoreThread")
+	defer tracing.End(span, &err)
+
+	thread, err := findThread(ctx, usecase.ThreadRepo, slugOrId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = usecase.moderate(ctx, thread.ForumId); err != nil {
+		return nil, err
+	}
+
+	if err = usecase.ThreadRepo.SetDeleted(ctx, thread.Id, false); err != nil {
+		return nil, err
+	}
+
+	thread.Deleted = false
+	return thread, nil
+}
+
+func (usecase *ModerationUseCase) RestorePost(ctx context.Context, id int64) (_ *models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "ModerationUseCase.RestorePost")
+	defer tracing.End(span, &err)
+
+	post, err := usecase.PostRepo.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	forum, err := usecase.ForumRepo.Get(ctx, post.Forum)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = usecase.moderate(ctx, forum.Id); err != nil {
+		return nil, err
+	}
+
+	if err = usecase.PostRepo.SetDeleted(ctx, id, false); err != nil {
+		return nil, err
+	}
+
+	post.Deleted = false
+	return post, nil
+}
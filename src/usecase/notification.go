@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"context"
+	"techno-forum/src/auth"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+)
+
+type NotificationUseCase struct {
+	NotificationRepo repository.NotificationRepository
+	UserRepo         repository.UserRepository
+}
+
+func NewNotificationUseCase(notifications repository.NotificationRepository, users repository.UserRepository) *NotificationUseCase {
+	return &NotificationUseCase{
+		NotificationRepo: notifications,
+		UserRepo:         users,
+	}
+}
+
+// owner returns the user with nickname if the request may read their
+// notifications, which only they may.
+func (usecase *NotificationUseCase) owner(ctx context.Context, nickname string) (*models.User, error) {
+	user, err := usecase.UserRepo.GetByNickName(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.ActAs(ctx, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func (usecase *NotificationUseCase) List(ctx context.Context, nickname string, params *models.NotificationListParams) (_ []*models.Notification, err error) {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.List")
+	defer tracing.End(span, &err)
+
+	user, err := usecase.owner(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	params.UserId = user.Id
+	return usecase.NotificationRepo.List(ctx, params)
+}
+
+// MarkRead marks one notification read and returns it.
+func (usecase *NotificationUseCase) MarkRead(ctx context.Context, nickname string, id int64) (_ *models.Notification, err error) {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.MarkRead")
+	defer tracing.End(span, &err)
+
+	user, err := usecase.owner(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	notification, err := usecase.NotificationRepo.Get(ctx, user.Id, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = usecase.NotificationRepo.MarkRead(ctx, user.Id, []int64{id}); err != nil {
+		return nil, err
+	}
+
+	notification.Read = true
+	return notification, nil
+}
+
+// MarkAllRead marks the notifications with req.Ids read, or all of them
+// when there are none.
+func (usecase *NotificationUseCase) MarkAllRead(ctx context.Context, nickname string, req *models.NotificationsReadRequest) (_ *models.NotificationsRead, err error) {
+	ctx, span := tracing.Start(ctx, "NotificationUseCase.MarkAllRead")
+	defer tracing.End(span, &err)
+
+	user, err := usecase.owner(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	marked, err := usecase.NotificationRepo.MarkRead(ctx, user.Id, req.Ids)
+	if err != nil {
+		return nil, err
+	}
+	return &models.NotificationsRead{Marked: marked}, nil
+}
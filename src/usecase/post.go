@@ -1,45 +1,348 @@
 package usecase
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"techno-forum/src/auth"
+	"techno-forum/src/events"
+	"techno-forum/src/markdown"
+	"techno-forum/src/metrics"
 	"techno-forum/src/models"
 	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type PostUseCase struct {
-	PostRepo  *repository.PostRepository
-	ForumRepo *repository.ForumRepository
+	PostRepo       repository.PostRepository
+	ThreadRepo     repository.ThreadRepository
+	AttachmentRepo repository.AttachmentRepository
+	ForumRepo      repository.ForumRepository
+	UserRepo       repository.UserRepository
+	BanRepo        repository.BanRepository
+	Events         *events.Bus
+	// MaxAttachments is the most attachments one post may link.
+	MaxAttachments int
 }
 
-func NewPostUseCase(posts *repository.PostRepository, forum *repository.ForumRepository) *PostUseCase {
+func NewPostUseCase(posts repository.PostRepository, threads repository.ThreadRepository, attachments repository.AttachmentRepository,
+	forum repository.ForumRepository, users repository.UserRepository, bans repository.BanRepository, maxAttachments int, bus *events.Bus) *PostUseCase {
 	return &PostUseCase{
-		PostRepo:  posts,
-		ForumRepo: forum,
+		PostRepo:       posts,
+		ThreadRepo:     threads,
+		AttachmentRepo: attachments,
+		ForumRepo:      forum,
+		UserRepo:       users,
+		BanRepo:        bans,
+		Events:         bus,
+		MaxAttachments: maxAttachments,
 	}
 }
 
-func (usecase *PostUseCase) AddPosts(thread *models.Thread, posts []*models.Post) error {
-	return usecase.PostRepo.AddPosts(thread, posts)
+func (usecase *PostUseCase) AddPosts(ctx context.Context, thread *models.Thread, posts []*models.Post) (err error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.AddPosts")
+	defer tracing.End(span, &err)
+
+	if thread.Locked {
+		return models.NewError(models.ErrForbidden, "thread %d is locked", thread.Id)
+	}
+
+	for _, post := range posts {
+		if len(post.Attachments) > usecase.MaxAttachments {
+			return models.NewError(models.ErrValidation, "a post may have at most %d attachments", usecase.MaxAttachments)
+		}
+	}
+
+	if err = usecase.checkAuthors(ctx, thread, posts); err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		post.MessageHTML = markdown.Render(post.Message)
+	}
+
+	if err = usecase.PostRepo.AddPosts(ctx, thread, posts); err != nil {
+		return err
+	}
+
+	metrics.PostsCreated.Add(float64(len(posts)))
+
+	created := make([]*events.Event, 0, len(posts))
+	for _, post := range posts {
+		created = append(created, &events.Event{Type: events.PostCreated, ThreadId: thread.Id, Post: post})
+	}
+	usecase.Events.Publish(ctx, created...)
+	return nil
 }
 
-func (usecase *PostUseCase) GetPost(id int64) (*models.Post, error) {
-	return usecase.PostRepo.GetPost(id)
+// checkAuthors makes sure that no author is banned in the forum and that an
+// anonymous request doesn't post as a user with a password. Authenticated
+// requests already have the author set to their user.
+func (usecase *PostUseCase) checkAuthors(ctx context.Context, thread *models.Thread, posts []*models.Post) error {
+	nicknames := make([]string, 0, len(posts))
+	for _, post := range posts {
+		nicknames = append(nicknames, post.Author)
+	}
+
+	authors, err := usecase.UserRepo.GetByNickNames(ctx, nicknames)
+	if err != nil {
+		return err
+	}
+
+	if auth.User(ctx) == nil {
+		for _, author := range authors {
+			if err = auth.ActAs(ctx, author); err != nil {
+				return err
+			}
+		}
+	}
+
+	return checkBans(ctx, usecase.BanRepo, thread.ForumId, authors...)
 }
 
-func (usecase *PostUseCase) Update(post *models.Post) error {
-	return usecase.PostRepo.Update(post)
+// getPost returns the post unless its thread is deleted, which takes the
+// posts with it.
+func (usecase *PostUseCase) getPost(ctx context.Context, id int64) (*models.Post, error) {
+	post, err := usecase.PostRepo.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = getThread(ctx, usecase.ThreadRepo, fmt.Sprint(post.Thread)); errors.Is(err, models.ErrNotFound) {
+		return nil, repository.PostNotFound(id)
+	} else if err != nil {
+		return nil, err
+	}
+	return post, nil
 }
 
-func (u *PostUseCase) GetPosts(thread *models.Thread, params *models.PostListParams) ([]*models.Post, error) {
-	var posts []*models.Post
-	var err error
+func (usecase *PostUseCase) GetPost(ctx context.Context, id int64) (_ *models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetPost")
+	defer tracing.End(span, &err)
+
+	post, err := usecase.getPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	maskPosts(post)
+	if err = usecase.setAttachments(ctx, post); err != nil {
+		return nil, err
+	}
+	return post, nil
+}
+
+func (usecase *PostUseCase) Update(ctx context.Context, post *models.Post) (err error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.Update")
+	defer tracing.End(span, &err)
+
+	previous, editor, err := usecase.authorizeEdit(ctx, post.Id)
+	if err != nil {
+		return err
+	}
+
+	if post.Message != "" {
+		post.MessageHTML = markdown.Render(post.Message)
+	} else {
+		post.MessageHTML = markdown.Render(previous.Message)
+	}
+
+	if err = usecase.PostRepo.Update(ctx, post, editor.Id); err != nil {
+		return err
+	}
+
+	if err = usecase.setAttachments(ctx, post); err != nil {
+		return err
+	}
+
+	published := *post
+	maskPosts(&published)
+	usecase.Events.Publish(ctx, &events.Event{Type: events.PostUpdated, ThreadId: post.Thread, Post: &published})
+	return nil
+}
+
+// RenderHTML sets the rendered messages of the posts when render is set and
+// clears them otherwise. Messages stored before rendering existed and the
+// placeholders of deleted and hidden posts are rendered on the spot.
+func (usecase *PostUseCase) RenderHTML(ctx context.Context, render bool, posts ...*models.Post) (err error) {
+	if !render {
+		for _, post := range posts {
+			post.MessageHTML = ""
+		}
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "PostUseCase.RenderHTML")
+	defer tracing.End(span, &err)
+
+	var ids []int64
+	for _, post := range posts {
+		if post.MessageHTML == "" && !post.Deleted && !post.Hidden {
+			ids = append(ids, post.Id)
+		}
+	}
+
+	cached := map[int64]string{}
+	if len(ids) > 0 {
+		if cached, err = usecase.PostRepo.GetMessagesHTML(ctx, ids); err != nil {
+			return err
+		}
+	}
+
+	for _, post := range posts {
+		if post.MessageHTML != "" {
+			continue
+		}
+
+		html, ok := cached[post.Id]
+		if !ok || post.Deleted || post.Hidden {
+			html = markdown.Render(post.Message)
+		}
+		post.MessageHTML = html
+	}
+	return nil
+}
+
+// setAttachments sets the attachment ids of the posts. Deleted and hidden
+// posts show none.
+func (usecase *PostUseCase) setAttachments(ctx context.Context, posts ...*models.Post) error {
+	var ids []int64
+	for _, post := range posts {
+		post.Attachments = nil
+		if !post.Deleted && !post.Hidden {
+			ids = append(ids, post.Id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	attachments, err := usecase.AttachmentRepo.GetIds(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		if !post.Deleted && !post.Hidden {
+			post.Attachments = attachments[post.Id]
+		}
+	}
+	return nil
+}
+
+// authorizeEdit returns the post if the request may edit it, along with the
+// editor: the acting user or, for anonymous requests, the author. Deleted
+// posts can't be edited.
+func (usecase *PostUseCase) authorizeEdit(ctx context.Context, id int64) (*models.Post, *models.User, error) {
+	post, err := usecase.getPost(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if post.Deleted {
+		return nil, nil, repository.PostNotFound(id)
+	}
+
+	author, err := usecase.UserRepo.GetByNickName(ctx, post.Author)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = auth.CanEdit(ctx, author, usecase.moderates(ctx, post)); err != nil {
+		return nil, nil, err
+	}
+
+	if user := auth.User(ctx); user != nil {
+		return post, user, nil
+	}
+	return post, author, nil
+}
+
+// moderates reports whether a user moderates the forum of post.
+func (usecase *PostUseCase) moderates(ctx context.Context, post *models.Post) func(*models.User) (bool, error) {
+	return func(user *models.User) (bool, error) {
+		forum, err := usecase.ForumRepo.Get(ctx, post.Forum)
+		if err != nil {
+			return false, err
+		}
+		return usecase.ForumRepo.IsModerator(ctx, forum.Id, user.Id)
+	}
+}
+
+// GetRevisions returns every revision of the post. Those of hidden and
+// deleted posts are for moderators only.
+func (usecase *PostUseCase) GetRevisions(ctx context.Context, id int64) (_ []*models.Revision, err error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetRevisions")
+	defer tracing.End(span, &err)
+
+	post, err := usecase.getPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if post.Hidden || post.Deleted {
+		if err = auth.Moderate(ctx, usecase.moderates(ctx, post)); err != nil {
+			return nil, err
+		}
+	}
+
+	revs, err := usecase.PostRepo.GetRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return withOriginal(revs, post.Author, post.Created, "", post.Message), nil
+}
+
+// Diff compares the messages of two revisions of the post.
+func (usecase *PostUseCase) Diff(ctx context.Context, id int64, from int, to int) (_ *models.RevisionDiff, err error) {
+	revs, err := usecase.GetRevisions(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffRevisions(revs, from, to, func(rev *models.Revision) string {
+		return rev.Message
+	})
+}
+
+// Delete soft deletes the post. Its replies stay in place under a tombstone.
+func (usecase *PostUseCase) Delete(ctx context.Context, id int64) (err error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.Delete")
+	defer tracing.End(span, &err)
+
+	if _, _, err = usecase.authorizeEdit(ctx, id); err != nil {
+		return err
+	}
+
+	return usecase.PostRepo.SetDeleted(ctx, id, true)
+}
+
+var sortNames = map[int]string{
+	models.SortFlat:   "flat",
+	models.SortTree:   "tree",
+	models.SortParent: "parent_tree",
+}
+
+func (u *PostUseCase) GetPosts(ctx context.Context, thread *models.Thread, params *models.PostListParams) (posts []*models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "PostUseCase.GetPosts", trace.WithAttributes(
+		attribute.String("sort", sortNames[params.Sort]),
+		attribute.Int("thread", params.ThreadId),
+		attribute.Int("limit", params.Limit),
+	))
+	defer tracing.End(span, &err)
 
 	switch params.Sort {
 	case models.SortFlat:
-		posts, err = u.PostRepo.GetPostsFlat(params)
+		posts, err = u.PostRepo.GetPostsFlat(ctx, params)
 	case models.SortTree:
-		posts, err = u.PostRepo.GetPostsTree(params)
+		posts, err = u.PostRepo.GetPostsTree(ctx, params)
 	case models.SortParent:
-		posts, err = u.PostRepo.GetPostsParent(params)
+		posts, err = u.PostRepo.GetPostsParent(ctx, params)
 	default:
 		return nil, models.ErrInvalidArgument
 	}
@@ -51,5 +354,9 @@ func (u *PostUseCase) GetPosts(thread *models.Thread, params *models.PostListPar
 	for _, post := range posts {
 		post.Forum = thread.Forum
 	}
+	maskPosts(posts...)
+	if err = u.setAttachments(ctx, posts...); err != nil {
+		return nil, err
+	}
 	return posts, nil
 }
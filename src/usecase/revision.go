@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"fmt"
+	"techno-forum/src/diff"
+	"techno-forum/src/models"
+)
+
+// withOriginal returns revs, or the current text as revision 1 when the
+// post or thread was never edited.
+func withOriginal(revs []*models.Revision, author string, created string, title string, message string) []*models.Revision {
+	if len(revs) != 0 {
+		return revs
+	}
+
+	return []*models.Revision{{
+		Number:  1,
+		Editor:  author,
+		Created: created,
+		Title:   title,
+		Message: message,
+	}}
+}
+
+// diffRevisions compares revisions from and to of revs. Zero to stands for
+// the latest revision and zero from for the one before to.
+func diffRevisions(revs []*models.Revision, from int, to int, text func(*models.Revision) string) (*models.RevisionDiff, error) {
+	if to == 0 {
+		to = len(revs)
+	}
+	if from == 0 {
+		from = max(to-1, 1)
+	}
+
+	for _, number := range []int{from, to} {
+		if number < 1 || number > len(revs) {
+			return nil, models.NewError(models.ErrNotFound, "can't find revision %d", number)
+		}
+	}
+
+	a, b := revs[from-1], revs[to-1]
+	return &models.RevisionDiff{
+		From: from,
+		To:   to,
+		Diff: diff.Unified(fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to), text(a), text(b)),
+	}, nil
+}
@@ -0,0 +1,53 @@
+package usecase
+
+import (
+	"context"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type SearchUseCase struct {
+	SearchRepo repository.SearchRepository
+	ForumRepo  repository.ForumRepository
+	UserRepo   repository.UserRepository
+}
+
+func NewSearchUseCase(search repository.SearchRepository, forums repository.ForumRepository, users repository.UserRepository) *SearchUseCase {
+	return &SearchUseCase{
+		SearchRepo: search,
+		ForumRepo:  forums,
+		UserRepo:   users,
+	}
+}
+
+// Search finds posts and threads. Unknown forums and authors are reported
+// rather than silently matching nothing.
+func (usecase *SearchUseCase) Search(ctx context.Context, params *models.SearchParams) (_ []*models.SearchHit, err error) {
+	ctx, span := tracing.Start(ctx, "SearchUseCase.Search", trace.WithAttributes(
+		attribute.String("type", params.Type),
+		attribute.Int("limit", params.Limit),
+	))
+	defer tracing.End(span, &err)
+
+	if params.Query == "" {
+		return nil, models.NewError(models.ErrValidation, "q is required")
+	}
+
+	if params.Forum != "" {
+		if _, err = usecase.ForumRepo.Get(ctx, params.Forum); err != nil {
+			return nil, err
+		}
+	}
+
+	if params.Author != "" {
+		if _, err = usecase.UserRepo.GetByNickName(ctx, params.Author); err != nil {
+			return nil, err
+		}
+	}
+
+	return usecase.SearchRepo.Search(ctx, params)
+}
@@ -1,73 +1,283 @@
 package usecase
 
 import (
-	"fmt"
+	"context"
+	"strconv"
+	"techno-forum/src/auth"
+	"techno-forum/src/events"
+	"techno-forum/src/markdown"
+	"techno-forum/src/metrics"
 	"techno-forum/src/models"
 	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
 	"techno-forum/src/utils"
 )
 
 type ThreadUseCase struct {
-	ThreadRepo *repository.ThreadRepository
-	UserRepo   *repository.UserRepository
-	ForumRepo  *repository.ForumRepository
+	ThreadRepo repository.ThreadRepository
+	UserRepo   repository.UserRepository
+	ForumRepo  repository.ForumRepository
+	BanRepo    repository.BanRepository
+	VoteRepo   repository.VoteRepository
+	SubsRepo   repository.SubscriptionRepository
+	Events     *events.Bus
 }
 
-func NewThreadUseCase(thread *repository.ThreadRepository, user *repository.UserRepository, forum *repository.ForumRepository) *ThreadUseCase {
+func NewThreadUseCase(thread repository.ThreadRepository, user repository.UserRepository, forum repository.ForumRepository,
+	bans repository.BanRepository, votes repository.VoteRepository, subs repository.SubscriptionRepository, bus *events.Bus) *ThreadUseCase {
 	return &ThreadUseCase{
 		ThreadRepo: thread,
 		UserRepo:   user,
 		ForumRepo:  forum,
+		BanRepo:    bans,
+		VoteRepo:   votes,
+		SubsRepo:   subs,
+		Events:     bus,
 	}
 }
 
-func (usecase *ThreadUseCase) Create(thread *models.Thread, forumSlug string) error {
-	user, err := usecase.UserRepo.GetByNickName(thread.Author)
+// findThread returns the thread even when it is deleted.
+func findThread(ctx context.Context, threads repository.ThreadRepository, slugOrId string) (*models.Thread, error) {
+	if utils.IsNumeric(slugOrId) {
+		return threads.GetById(ctx, slugOrId)
+	}
+	return threads.GetBySlug(ctx, slugOrId)
+}
+
+func getThread(ctx context.Context, threads repository.ThreadRepository, slugOrId string) (*models.Thread, error) {
+	thread, err := findThread(ctx, threads, slugOrId)
+	if err != nil {
+		return nil, err
+	}
+
+	if thread.Deleted {
+		return nil, repository.ThreadNotFound(slugOrId)
+	}
+	return thread, nil
+}
+
+func (usecase *ThreadUseCase) Create(ctx context.Context, thread *models.Thread, forumSlug string) (err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.Create")
+	defer tracing.End(span, &err)
+
+	user, err := usecase.UserRepo.GetByNickName(ctx, thread.Author)
 	if err != nil {
 		return err
 	}
 
-	forum, err := usecase.ForumRepo.Get(forumSlug)
+	if err = auth.ActAs(ctx, user); err != nil {
+		return err
+	}
+
+	forum, err := usecase.ForumRepo.Get(ctx, forumSlug)
 	if err != nil {
 		return err
 	}
 
+	if err = checkBans(ctx, usecase.BanRepo, forum.Id, user); err != nil {
+		return err
+	}
+
 	thread.Author = user.Nickname
 	thread.Forum = forum.Slug
-	return usecase.ThreadRepo.Create(thread, user.Id, forum.Id)
+	thread.MessageHTML = markdown.Render(thread.Message)
+	if err = usecase.ThreadRepo.Create(ctx, thread, user.Id, forum.Id); err != nil {
+		return err
+	}
+
+	metrics.ThreadsCreated.Inc()
+	return nil
 }
 
-func (usecase *ThreadUseCase) Get(slugOrId string) (*models.Thread, error) {
-	if utils.IsNumeric(slugOrId) {
-		return usecase.ThreadRepo.GetById(slugOrId)
+func (usecase *ThreadUseCase) Get(ctx context.Context, slugOrId string) (_ *models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.Get")
+	defer tracing.End(span, &err)
+
+	return getThread(ctx, usecase.ThreadRepo, slugOrId)
+}
+
+func validSubscriptionLevel(level string) bool {
+	switch level {
+	case models.SubscriptionWatching, models.SubscriptionTracking, models.SubscriptionMuted:
+		return true
+	}
+	return false
+}
+
+// subscriber returns the thread and the user with nickname, or the signed in
+// user, if the request may manage their subscription.
+func (usecase *ThreadUseCase) subscriber(ctx context.Context, slugOrId string, nickname string) (*models.Thread, *models.User, error) {
+	nickname = auth.Nickname(ctx, nickname)
+	if nickname == "" {
+		return nil, nil, models.NewError(models.ErrBadRequest, "nickname is required")
+	}
+
+	user, err := usecase.UserRepo.GetByNickName(ctx, nickname)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err = auth.ActAs(ctx, user); err != nil {
+		return nil, nil, err
+	}
+
+	thread, err := getThread(ctx, usecase.ThreadRepo, slugOrId)
+	if err != nil {
+		return nil, nil, err
 	}
-	return usecase.ThreadRepo.GetBySlug(slugOrId)
+	return thread, user, nil
 }
 
-func (usecase *ThreadUseCase) GetByForum(forumSlug string, since string, desc bool, limit int) ([]*models.Thread, error) {
-	forum, err := usecase.ForumRepo.Get(forumSlug)
+func (usecase *ThreadUseCase) GetSubscription(ctx context.Context, slugOrId string, nickname string) (_ *models.Subscription, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.GetSubscription")
+	defer tracing.End(span, &err)
 
+	thread, user, err := usecase.subscriber(ctx, slugOrId, nickname)
 	if err != nil {
 		return nil, err
 	}
 
-	return usecase.ThreadRepo.GetByForum(forum.Id, since, desc, limit)
+	return usecase.SubsRepo.Get(ctx, thread.Id, user.Id)
 }
 
-func (usecase *ThreadUseCase) Update(thread *models.Thread, slugOrId string) error {
-	var foundThread *models.Thread
-	var err error
-	if utils.IsNumeric(slugOrId) {
-		foundThread, err = usecase.ThreadRepo.GetById(slugOrId)
-	} else {
-		foundThread, err = usecase.ThreadRepo.GetBySlug(slugOrId)
+// Subscribe sets the subscription level of the user to the thread.
+func (usecase *ThreadUseCase) Subscribe(ctx context.Context, slugOrId string, req *models.SubscriptionRequest) (_ *models.Subscription, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.Subscribe")
+	defer tracing.End(span, &err)
+
+	if !validSubscriptionLevel(req.Level) {
+		return nil, models.NewError(models.ErrValidation, "level must be one of %s, %s or %s",
+			models.SubscriptionWatching, models.SubscriptionTracking, models.SubscriptionMuted)
+	}
+
+	thread, user, err := usecase.subscriber(ctx, slugOrId, req.Nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &models.Subscription{
+		User:     user.Nickname,
+		UserId:   user.Id,
+		ThreadId: thread.Id,
+		Level:    req.Level,
 	}
 
+	if err = usecase.SubsRepo.Set(ctx, sub); err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (usecase *ThreadUseCase) Unsubscribe(ctx context.Context, slugOrId string, nickname string) (err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.Unsubscribe")
+	defer tracing.End(span, &err)
+
+	thread, user, err := usecase.subscriber(ctx, slugOrId, nickname)
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("FOUND:", foundThread)
+	return usecase.SubsRepo.Delete(ctx, thread.Id, user.Id)
+}
+
+// RenderHTML sets the rendered messages of the threads when render is set
+// and clears them otherwise. Messages stored before rendering existed are
+// rendered on the spot.
+func (usecase *ThreadUseCase) RenderHTML(ctx context.Context, render bool, threads ...*models.Thread) (err error) {
+	if !render {
+		for _, thread := range threads {
+			thread.MessageHTML = ""
+		}
+		return nil
+	}
+
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.RenderHTML")
+	defer tracing.End(span, &err)
+
+	var ids []int
+	for _, thread := range threads {
+		if thread.MessageHTML == "" {
+			ids = append(ids, thread.Id)
+		}
+	}
+
+	cached := map[int]string{}
+	if len(ids) > 0 {
+		if cached, err = usecase.ThreadRepo.GetMessagesHTML(ctx, ids); err != nil {
+			return err
+		}
+	}
+
+	for _, thread := range threads {
+		if thread.MessageHTML != "" {
+			continue
+		}
+
+		html, ok := cached[thread.Id]
+		if !ok {
+			html = markdown.Render(thread.Message)
+		}
+		thread.MessageHTML = html
+	}
+	return nil
+}
+
+// Vote records the vote of user and returns the thread with its new rating.
+func (usecase *ThreadUseCase) Vote(ctx context.Context, thread *models.Thread, user *models.User, voice int) (_ *models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.Vote")
+	defer tracing.End(span, &err)
+
+	if err = checkBans(ctx, usecase.BanRepo, thread.ForumId, user); err != nil {
+		return nil, err
+	}
+
+	vote := &models.Vote{
+		UserId:   user.Id,
+		ThreadId: thread.Id,
+		Value:    voice,
+	}
+
+	if err = usecase.VoteRepo.Vote(ctx, vote); err != nil {
+		return nil, err
+	}
+
+	metrics.Vote(vote.Value)
+
+	thread, err = getThread(ctx, usecase.ThreadRepo, strconv.Itoa(thread.Id))
+	if err != nil {
+		return nil, err
+	}
+
+	usecase.Events.Publish(ctx, &events.Event{Type: events.ThreadVoted, ThreadId: thread.Id, Thread: thread})
+	return thread, nil
+}
+
+func (usecase *ThreadUseCase) GetByForum(ctx context.Context, forumSlug string, since string, desc bool, limit int) (_ []*models.Thread, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.GetByForum")
+	defer tracing.End(span, &err)
+
+	forum, err := usecase.ForumRepo.Get(ctx, forumSlug)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return usecase.ThreadRepo.GetByForum(ctx, forum.Id, since, desc, limit)
+}
+
+func (usecase *ThreadUseCase) Update(ctx context.Context, thread *models.Thread, slugOrId string) (err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.Update")
+	defer tracing.End(span, &err)
+
+	foundThread, err := getThread(ctx, usecase.ThreadRepo, slugOrId)
+	if err != nil {
+		return err
+	}
+
+	editor, err := usecase.authorizeEdit(ctx, foundThread)
+	if err != nil {
+		return err
+	}
 
 	thread.Id = foundThread.Id
 	thread.Slug = foundThread.Slug
@@ -75,6 +285,7 @@ func (usecase *ThreadUseCase) Update(thread *models.Thread, slugOrId string) err
 	thread.Author = foundThread.Author
 	thread.Votes = foundThread.Votes
 	thread.Created = foundThread.Created
+	thread.Locked = foundThread.Locked
 
 	if thread.Title == "" {
 		thread.Title = foundThread.Title
@@ -83,6 +294,91 @@ func (usecase *ThreadUseCase) Update(thread *models.Thread, slugOrId string) err
 	if thread.Message == "" {
 		thread.Message = foundThread.Message
 	}
+	thread.MessageHTML = markdown.Render(thread.Message)
+
+	if err = usecase.ThreadRepo.Update(ctx, thread, editor.Id); err != nil {
+		return err
+	}
+
+	usecase.Events.Publish(ctx, &events.Event{Type: events.ThreadUpdated, ThreadId: thread.Id, Thread: thread})
+	return nil
+}
+
+// authorizeEdit returns the editor of thread: the acting user or, for
+// anonymous requests, the author.
+func (usecase *ThreadUseCase) authorizeEdit(ctx context.Context, thread *models.Thread) (*models.User, error) {
+	author, err := usecase.UserRepo.GetByNickName(ctx, thread.Author)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = auth.CanEdit(ctx, author, usecase.moderates(ctx, thread)); err != nil {
+		return nil, err
+	}
+
+	if user := auth.User(ctx); user != nil {
+		return user, nil
+	}
+	return author, nil
+}
+
+// moderates reports whether a user moderates the forum of thread.
+func (usecase *ThreadUseCase) moderates(ctx context.Context, thread *models.Thread) func(*models.User) (bool, error) {
+	return func(user *models.User) (bool, error) {
+		return usecase.ForumRepo.IsModerator(ctx, thread.ForumId, user.Id)
+	}
+}
+
+// GetRevisions returns every revision of the thread. Those of a deleted
+// thread are for moderators only.
+func (usecase *ThreadUseCase) GetRevisions(ctx context.Context, slugOrId string) (_ []*models.Revision, err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.GetRevisions")
+	defer tracing.End(span, &err)
+
+	thread, err := findThread(ctx, usecase.ThreadRepo, slugOrId)
+	if err != nil {
+		return nil, err
+	}
+
+	if thread.Deleted {
+		if err = auth.Moderate(ctx, usecase.moderates(ctx, thread)); err != nil {
+			return nil, err
+		}
+	}
+
+	revs, err := usecase.ThreadRepo.GetRevisions(ctx, thread.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	return withOriginal(revs, thread.Author, thread.Created, thread.Title, thread.Message), nil
+}
+
+// Diff compares the titles and messages of two revisions of the thread.
+func (usecase *ThreadUseCase) Diff(ctx context.Context, slugOrId string, from int, to int) (_ *models.RevisionDiff, err error) {
+	revs, err := usecase.GetRevisions(ctx, slugOrId)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffRevisions(revs, from, to, func(rev *models.Revision) string {
+		return rev.Title + "\n\n" + rev.Message
+	})
+}
+
+// Delete soft deletes the thread. Moderators can restore it.
+func (usecase *ThreadUseCase) Delete(ctx context.Context, slugOrId string) (err error) {
+	ctx, span := tracing.Start(ctx, "ThreadUseCase.Delete")
+	defer tracing.End(span, &err)
+
+	thread, err := getThread(ctx, usecase.ThreadRepo, slugOrId)
+	if err != nil {
+		return err
+	}
+
+	if _, err = usecase.authorizeEdit(ctx, thread); err != nil {
+		return err
+	}
 
-	return usecase.ThreadRepo.Update(thread)
+	return usecase.ThreadRepo.SetDeleted(ctx, thread.Id, true)
 }
@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"context"
+	"techno-forum/src/auth"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+)
+
+type UserUseCase struct {
+	UserRepo repository.UserRepository
+	Auth     *AuthUseCase
+}
+
+func NewUserUseCase(users repository.UserRepository, auth *AuthUseCase) *UserUseCase {
+	return &UserUseCase{
+		UserRepo: users,
+		Auth:     auth,
+	}
+}
+
+// Update changes the profile of profile.Nickname. Empty fields are left as
+// they are.
+func (usecase *UserUseCase) Update(ctx context.Context, profile *models.User) (err error) {
+	ctx, span := tracing.Start(ctx, "UserUseCase.Update")
+	defer tracing.End(span, &err)
+
+	user, err := usecase.UserRepo.GetByNickName(ctx, profile.Nickname)
+	if err != nil {
+		return err
+	}
+
+	if err = auth.CanEditProfile(ctx, user); err != nil {
+		return err
+	}
+
+	// Anonymous requests may edit users without a password, but setting one
+	// would let anybody claim the account.
+	if profile.Password != "" && auth.User(ctx) == nil {
+		return models.NewError(models.ErrUnauthorized, "sign in to set the password of %s", user.Nickname)
+	}
+
+	if err = usecase.Auth.SetPassword(profile); err != nil {
+		return err
+	}
+
+	return usecase.UserRepo.Update(ctx, profile)
+}
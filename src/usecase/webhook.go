@@ -0,0 +1,122 @@
+package usecase
+
+import (
+	"context"
+	"net/url"
+	"slices"
+	"techno-forum/src/auth"
+	"techno-forum/src/events"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"techno-forum/src/tracing"
+)
+
+// webhookEvents are the event types queued in the outbox.
+var webhookEvents = []string{events.PostCreated, events.ThreadCreated, events.ThreadVoted}
+
+type WebhookUseCase struct {
+	WebhookRepo repository.WebhookRepository
+	ForumRepo   repository.ForumRepository
+}
+
+func NewWebhookUseCase(webhooks repository.WebhookRepository, forums repository.ForumRepository) *WebhookUseCase {
+	return &WebhookUseCase{
+		WebhookRepo: webhooks,
+		ForumRepo:   forums,
+	}
+}
+
+// Create registers a webhook and returns it with its secret, which is never
+// shown again.
+func (usecase *WebhookUseCase) Create(ctx context.Context, req *models.WebhookRequest) (_ *models.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Create")
+	defer tracing.End(span, &err)
+
+	if err = auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(req.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, models.NewError(models.ErrValidation, "url must be an absolute http or https URL")
+	}
+
+	for _, typ := range req.Events {
+		if !slices.Contains(webhookEvents, typ) {
+			return nil, models.NewError(models.ErrValidation, "unknown event %q, expected one of %v", typ, webhookEvents)
+		}
+	}
+
+	hook := &models.Webhook{
+		URL:    req.URL,
+		Events: append([]string{}, req.Events...),
+		Secret: req.Secret,
+		ById:   auth.User(ctx).Id,
+		By:     auth.User(ctx).Nickname,
+	}
+
+	if req.Forum != "" {
+		forum, err := usecase.ForumRepo.Get(ctx, req.Forum)
+		if err != nil {
+			return nil, err
+		}
+		hook.Forum = forum.Slug
+		hook.ForumId = forum.Id
+	}
+
+	if hook.Secret == "" {
+		if hook.Secret, _, err = auth.NewToken(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = usecase.WebhookRepo.Create(ctx, hook); err != nil {
+		return nil, err
+	}
+	return hook, nil
+}
+
+func (usecase *WebhookUseCase) List(ctx context.Context) (_ []*models.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.List")
+	defer tracing.End(span, &err)
+
+	if err = auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return usecase.WebhookRepo.List(ctx)
+}
+
+func (usecase *WebhookUseCase) Get(ctx context.Context, id int) (_ *models.Webhook, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Get")
+	defer tracing.End(span, &err)
+
+	if err = auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return usecase.WebhookRepo.Get(ctx, id)
+}
+
+func (usecase *WebhookUseCase) Delete(ctx context.Context, id int) (err error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.Delete")
+	defer tracing.End(span, &err)
+
+	if err = auth.RequireAdmin(ctx); err != nil {
+		return err
+	}
+	return usecase.WebhookRepo.Delete(ctx, id)
+}
+
+// GetDeliveries returns the latest deliveries of the webhook, newest first.
+func (usecase *WebhookUseCase) GetDeliveries(ctx context.Context, id int, limit int) (_ []*models.WebhookDelivery, err error) {
+	ctx, span := tracing.Start(ctx, "WebhookUseCase.GetDeliveries")
+	defer tracing.End(span, &err)
+
+	if err = auth.RequireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err = usecase.WebhookRepo.Get(ctx, id); err != nil {
+		return nil, err
+	}
+	return usecase.WebhookRepo.GetDeliveries(ctx, id, limit)
+}
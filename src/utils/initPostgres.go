@@ -2,57 +2,79 @@ package utils
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"log/slog"
+	"techno-forum/src/config"
 	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-const dsn = "user=postgres dbname=forum password=12345 host=localhost port=5432 sslmode=disable pool_max_conns=20"
+// InitPostgres opens the pool and keeps pinging the database for up to
+// cfg.ConnectRetry. It fails if the database never answers, so the caller
+// doesn't start serving requests it can't handle. tracer, if not nil, is
+// attached to every connection.
+func InitPostgres(ctx context.Context, cfg config.Database, logger *slog.Logger, tracer pgx.QueryTracer) (*pgxpool.Pool, error) {
+	till := time.Now().Add(cfg.ConnectRetry)
 
-func InitPostgres() (*pgxpool.Pool, error) {
-	till := time.Now().Add(time.Second * 10)
-
-	conf, err := pgxpool.ParseConfig(dsn)
+	conf, err := pgxpool.ParseConfig(cfg.DSN)
 	if err != nil {
 		return nil, err
 	}
 
-	db, err := pgxpool.NewWithConfig(context.Background(), conf)
+	conf.MaxConns = int32(cfg.MaxConns)
+	conf.MinConns = int32(cfg.MinConns)
+	conf.ConnConfig.Tracer = tracer
+
+	db, err := pgxpool.NewWithConfig(ctx, conf)
 	if err != nil {
 		return nil, err
 	}
 
-	for time.Now().Before(till) {
-		log.Println("Trying to open pg connection")
-
-		err = db.Ping(context.Background())
+	for attempt := 1; ; attempt++ {
+		// A ping to an address that drops packets would otherwise hang past
+		// the retry time. Each attempt still gets at least one interval.
+		pingCtx, cancel := context.WithTimeout(ctx, max(time.Until(till), cfg.RetryInterval))
+		err = db.Ping(pingCtx)
+		cancel()
 		if err == nil {
-			log.Println("Ping sucessful")
+			logger.InfoContext(ctx, "connected to database", "attempt", attempt)
+			return db, nil
+		}
+
+		logger.WarnContext(ctx, "database is not reachable yet", "attempt", attempt, "error", err)
+
+		if !time.Now().Add(cfg.RetryInterval).Before(till) {
 			break
 		}
 
-		time.Sleep(time.Second)
+		select {
+		case <-ctx.Done():
+			db.Close()
+			return nil, ctx.Err()
+		case <-time.After(cfg.RetryInterval):
+		}
 	}
 
-	return db, nil
+	db.Close()
+	return nil, fmt.Errorf("database is unreachable after %s: %w", cfg.ConnectRetry, err)
 }
 
-func MakeTx(db *pgxpool.Pool, fb func(tx pgx.Tx) error) error {
-	tx, err := db.Begin(context.Background())
+func MakeTx(ctx context.Context, db *pgxpool.Pool, fb func(tx pgx.Tx) error) error {
+	tx, err := db.Begin(ctx)
 	if err != nil {
 		return err
 	}
 
 	err = fb(tx)
 	if err != nil {
-		rollBackErr := tx.Rollback(context.Background())
+		rollBackErr := tx.Rollback(ctx)
 		if rollBackErr != nil {
 			return rollBackErr
 		}
 		return err
 	}
 
-	return tx.Commit(context.Background())
+	return tx.Commit(ctx)
 }
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryTracers lets several pgx tracers observe the same connection, pgx
+// only accepts one.
+type QueryTracers []pgx.QueryTracer
+
+func (tracers QueryTracers) TraceQueryStart(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	for _, t := range tracers {
+		ctx = t.TraceQueryStart(ctx, conn, data)
+	}
+	return ctx
+}
+
+func (tracers QueryTracers) TraceQueryEnd(ctx context.Context, conn *pgx.Conn, data pgx.TraceQueryEndData) {
+	for i := len(tracers) - 1; i >= 0; i-- {
+		tracers[i].TraceQueryEnd(ctx, conn, data)
+	}
+}
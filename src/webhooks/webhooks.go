@@ -0,0 +1,192 @@
+// Package webhooks delivers the events queued in the outbox to the
+// registered webhooks, retrying failures with exponential backoff.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"techno-forum/src/config"
+	"techno-forum/src/metrics"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"time"
+)
+
+const (
+	EventHeader     = "X-Forum-Event"
+	DeliveryHeader  = "X-Forum-Delivery"
+	SignatureHeader = "X-Forum-Signature-256"
+)
+
+// maxErrorBody is how much of a failed response is kept in the delivery log.
+const maxErrorBody = 512
+
+// Sign returns the signature header value of body: the hex encoded
+// HMAC-SHA256 of body keyed by secret, prefixed with "sha256=".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Worker moves events from the outbox to the deliveries of the webhooks and
+// sends the deliveries that are due. Deliveries are claimed with a lease, so
+// several workers may share one database.
+type Worker struct {
+	repo   repository.WebhookRepository
+	cfg    config.Webhooks
+	client *http.Client
+	log    *slog.Logger
+}
+
+func NewWorker(repo repository.WebhookRepository, cfg config.Webhooks, logger *slog.Logger) *Worker {
+	return &Worker{
+		repo:   repo,
+		cfg:    cfg,
+		client: &http.Client{Timeout: cfg.Timeout},
+		log:    logger.With("component", "webhooks"),
+	}
+}
+
+// Run delivers until ctx is done. Attempts in flight are allowed to finish.
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := w.poll(ctx); err != nil && ctx.Err() == nil {
+			w.log.Warn("delivering webhooks failed", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (w *Worker) poll(ctx context.Context) error {
+	for {
+		moved, err := w.repo.Dispatch(ctx, w.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		if moved < w.cfg.BatchSize {
+			break
+		}
+	}
+
+	for ctx.Err() == nil {
+		due, err := w.repo.ClaimDue(ctx, w.cfg.BatchSize, 2*w.cfg.Timeout)
+		if err != nil {
+			return err
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range due {
+			wg.Add(1)
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				w.attempt(delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		if len(due) < w.cfg.BatchSize {
+			break
+		}
+	}
+	return nil
+}
+
+// attempt sends the delivery and records the outcome. It doesn't use the
+// worker context so that shutting down doesn't count as a failed attempt.
+func (w *Worker) attempt(delivery *models.WebhookDelivery) {
+	status, err := w.send(delivery)
+
+	delivery.LastStatus = status
+	delivery.LastError = ""
+	delivery.NextAttempt = nil
+
+	switch {
+	case err == nil:
+		delivery.Status = models.DeliveryDelivered
+	case delivery.Attempts >= w.cfg.MaxAttempts:
+		delivery.Status = models.DeliveryFailed
+		delivery.LastError = err.Error()
+	default:
+		delivery.Status = models.DeliveryPending
+		delivery.LastError = err.Error()
+		next := time.Now().Add(w.backoff(delivery.Attempts))
+		delivery.NextAttempt = &next
+	}
+
+	outcome := delivery.Status
+	if outcome == models.DeliveryPending {
+		outcome = "retry"
+	}
+	metrics.WebhookAttempts.WithLabelValues(outcome).Inc()
+
+	w.log.Debug("webhook attempt", "delivery", delivery.Id, "webhook", delivery.WebhookId,
+		"event", delivery.Type, "attempt", delivery.Attempts, "outcome", outcome, "status", status, "error", err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), w.cfg.Timeout)
+	defer cancel()
+
+	if err := w.repo.RecordAttempt(ctx, delivery); err != nil {
+		w.log.Warn("recording webhook attempt failed", "delivery", delivery.Id, "error", err)
+	}
+}
+
+// send posts the payload and returns the response status. Anything but a
+// 2xx response is an error.
+func (w *Worker) send(delivery *models.WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "techno-forum-webhooks")
+	req.Header.Set(EventHeader, delivery.Type)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(SignatureHeader, Sign(delivery.Secret, body))
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		io.Copy(io.Discard, resp.Body)
+		return resp.StatusCode, nil
+	}
+
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if excerpt = bytes.TrimSpace(excerpt); len(excerpt) == 0 {
+		return resp.StatusCode, errors.New(resp.Status)
+	}
+	return resp.StatusCode, fmt.Errorf("%s: %s", resp.Status, excerpt)
+}
+
+// backoff returns the pause after the given number of failed attempts.
+func (w *Worker) backoff(attempts int) time.Duration {
+	d := w.cfg.BackoffBase
+	for i := 1; i < attempts && d < w.cfg.BackoffMax; i++ {
+		d *= 2
+	}
+	return min(d, w.cfg.BackoffMax)
+}
@@ -0,0 +1,148 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"techno-forum/src/config"
+	"techno-forum/src/models"
+	"techno-forum/src/repository"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+		body   string
+		want   string
+	}{
+		{
+			// RFC 4231, test case 2.
+			"rfc 4231", "Jefe", "what do ya want for nothing?",
+			"sha256=5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843",
+		},
+		{
+			// The example of the GitHub webhook documentation, which uses
+			// the same header format.
+			"github", "It's a Secret to Everybody", "Hello, World!",
+			"sha256=757107ea0eb2509fc211221cce984b8a37570b6d7586c22c46f4379c8b043e17",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(tt.secret, []byte(tt.body)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	w := &Worker{cfg: config.Webhooks{BackoffBase: time.Second, BackoffMax: 10 * time.Second}}
+
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := w.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff after %d attempts: got %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+// recorder keeps the attempts the worker records. The other methods of the
+// repository aren't used by attempt.
+type recorder struct {
+	repository.WebhookRepository
+	recorded []models.WebhookDelivery
+}
+
+func (r *recorder) RecordAttempt(ctx context.Context, delivery *models.WebhookDelivery) error {
+	r.recorded = append(r.recorded, *delivery)
+	return nil
+}
+
+func TestAttempt(t *testing.T) {
+	var status int
+	var got *http.Request
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(body)
+		w.WriteHeader(status)
+		io.WriteString(w, "  server says no  ")
+	}))
+	defer server.Close()
+
+	repo := &recorder{}
+	cfg := config.Webhooks{Timeout: time.Second, MaxAttempts: 3, BackoffBase: time.Minute, BackoffMax: time.Hour}
+	w := NewWorker(repo, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+		want     string
+		retry    bool
+	}{
+		{"delivered", http.StatusNoContent, 1, models.DeliveryDelivered, false},
+		{"server error", http.StatusInternalServerError, 1, models.DeliveryPending, true},
+		{"retried server error", http.StatusBadGateway, 2, models.DeliveryPending, true},
+		{"out of attempts", http.StatusInternalServerError, 3, models.DeliveryFailed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status = tt.status
+			delivery := &models.WebhookDelivery{
+				Id:       7,
+				Type:     "post.created",
+				Payload:  []byte(`{"type":"post.created"}`),
+				Attempts: tt.attempts,
+				URL:      server.URL,
+				Secret:   "secret",
+			}
+
+			start := time.Now()
+			w.attempt(delivery)
+
+			if got.Header.Get(EventHeader) != "post.created" || got.Header.Get(DeliveryHeader) != "7" {
+				t.Errorf("got headers %v", got.Header)
+			}
+			if sig := got.Header.Get(SignatureHeader); sig != Sign("secret", []byte(gotBody)) {
+				t.Errorf("got signature %s of body %s", sig, gotBody)
+			}
+
+			rec := repo.recorded[len(repo.recorded)-1]
+			if rec.Status != tt.want || rec.LastStatus != tt.status {
+				t.Errorf("got status %s after HTTP %d, want %s after %d", rec.Status, rec.LastStatus, tt.want, tt.status)
+			}
+			if tt.want != models.DeliveryDelivered && !strings.HasSuffix(rec.LastError, ": server says no") {
+				t.Errorf("got last error %q", rec.LastError)
+			}
+			if retry := rec.NextAttempt != nil; retry != tt.retry {
+				t.Fatalf("got next attempt %v, want a retry %t", rec.NextAttempt, tt.retry)
+			}
+			if tt.retry {
+				if wait := rec.NextAttempt.Sub(start); wait < w.backoff(tt.attempts) || wait > w.backoff(tt.attempts)+time.Second {
+					t.Errorf("got the next attempt in %s, want %s", wait, w.backoff(tt.attempts))
+				}
+			}
+		})
+	}
+}